@@ -0,0 +1,156 @@
+package aclmatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	cisco_asa_nat "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-nat"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// what ACLs of the firewall do with a flow, nil verdict if no ACL applied in that direction
+type Explanation struct {
+	Flow     network_entities.Flow
+	Inbound  *cisco_asa_acl.Verdict
+	Outbound *cisco_asa_acl.Verdict
+	Permit   bool
+}
+
+func parseAddrPort(str string, with_port bool) (uint32, uint16, error) {
+	if !with_port {
+		ip, err := utils.ParseIP(str)
+		return ip, 0, err
+	}
+
+	ip_str, port_str, found := strings.Cut(str, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("port is missing (address: %v)", str)
+	}
+	ip, err := utils.ParseIP(ip_str)
+	if err != nil {
+		return 0, 0, err
+	}
+	port, err := strconv.ParseUint(port_str, 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ip, uint16(port), nil
+}
+
+// parses flow given in command line, arrow is optional:
+// tcp 10.1.1.1:1234 -> 8.8.8.8:443
+// icmp 10.1.1.1 -> 8.8.8.8 8 0
+func ParseFlow(args []string) (network_entities.Flow, error) {
+	flow := network_entities.Flow{Icmp_type: -1, Icmp_code: -1}
+
+	var fields []string
+	for _, field := range strings.Fields(strings.Join(args, " ")) {
+		if field != "->" {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) < 3 {
+		return flow, fmt.Errorf("flow must be <protocol> <src> -> <dst> (flow: %v)", strings.Join(args, " "))
+	}
+
+	proto, ok := network_entities.Protocols_map[strings.ToLower(fields[0])]
+	if !ok {
+		return flow, fmt.Errorf("unknown protocol (protocol: %v)", fields[0])
+	}
+	flow.Protocol = proto
+
+	var with_port bool
+	switch proto.Title {
+	case "tcp", "udp", "sctp":
+		with_port = true
+		if len(fields) != 3 {
+			return flow, fmt.Errorf("flow must be <protocol> <src_ip>:<port> -> <dst_ip>:<port> (flow: %v)", strings.Join(args, " "))
+		}
+	case "icmp":
+		if len(fields) > 5 {
+			return flow, fmt.Errorf("flow must be icmp <src_ip> -> <dst_ip> [type [code]] (flow: %v)", strings.Join(args, " "))
+		}
+	default:
+		return flow, fmt.Errorf("only tcp, udp, sctp and icmp flows can be explained (protocol: %v)", fields[0])
+	}
+
+	var err error
+	flow.Src_ip, flow.Src_port, err = parseAddrPort(fields[1], with_port)
+	if err != nil {
+		return flow, err
+	}
+	flow.Dst_ip, flow.Dst_port, err = parseAddrPort(fields[2], with_port)
+	if err != nil {
+		return flow, err
+	}
+
+	if len(fields) > 3 {
+		flow.Icmp_type, err = strconv.Atoi(fields[3])
+		if err != nil {
+			return flow, err
+		}
+	}
+	if len(fields) > 4 {
+		flow.Icmp_code, err = strconv.Atoi(fields[4])
+		if err != nil {
+			return flow, err
+		}
+	}
+
+	return flow, nil
+}
+
+// looks up interfaces of the flow in the routing table and finds ACEs making a decision on it,
+// inbound and outbound ACLs are picked the same way syslog flows are matched
+func Explain(flow network_entities.Flow, app_ctx app_context.AppContext) (Explanation, error) {
+	var explanation Explanation
+	var err error
+
+	if flow.Src_iface == "" {
+		flow.Src_iface, err = app_ctx.Routing_table.GetIface(flow.Src_ip)
+		if err != nil {
+			return explanation, err
+		}
+	}
+	if flow.Dst_iface == "" && !flow.To_box {
+		flow.Dst_iface, err = app_ctx.Routing_table.GetIface(flow.Dst_ip)
+		if err != nil {
+			return explanation, err
+		}
+	}
+
+	if app_ctx.Address_form == cisco_asa_nat.Mapped {
+		flow = app_ctx.Nat_rules.FillMapped(flow)
+	}
+	explanation.Flow = flow
+
+	inbound_acl, outbound_acl, err := getACLsByFlow(flow, app_ctx)
+	if err != nil {
+		return explanation, err
+	}
+
+	// --- no access-group, traffic is left to security levels
+	explanation.Permit = true
+	if inbound_acl != nil {
+		verdict, err := inbound_acl.Explain(flowAsSeenBy(flow, true, app_ctx))
+		if err != nil {
+			return explanation, err
+		}
+		explanation.Inbound = &verdict
+		explanation.Permit = verdict.Permit
+	}
+	if outbound_acl != nil && explanation.Permit {
+		verdict, err := outbound_acl.Explain(flowAsSeenBy(flow, false, app_ctx))
+		if err != nil {
+			return explanation, err
+		}
+		explanation.Outbound = &verdict
+		explanation.Permit = verdict.Permit
+	}
+
+	return explanation, nil
+}
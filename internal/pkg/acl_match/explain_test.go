@@ -0,0 +1,156 @@
+package aclmatch
+
+import (
+	"testing"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_acg "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-group"
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+	sh_ip_route "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/sh-ip-route"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+func loadTestAppContext(t *testing.T) app_context.AppContext {
+	sh_run, err := sh_run_pipe.Load("testdata/sh_run_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	access_groups, err := cisco_asa_acg.Parse(sh_run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	access_lists, err := cisco_asa_acl.Parse(sh_run, access_groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routing_table, err := sh_ip_route.FitConfig(sh_run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app_context.AppContext{
+		Access_groups: access_groups,
+		Access_lists:  access_lists,
+		Routing_table: routing_table,
+	}
+}
+
+func ip(t *testing.T, str string) uint32 {
+	result, err := utils.ParseIP(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestParseFlow(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    network_entities.Flow
+		wantErr bool
+	}{
+		{
+			name: "tcp",
+			args: []string{"tcp 10.1.1.1:1234 -> 8.8.8.8:443"},
+			want: network_entities.Flow{Protocol: network_entities.Protocols_map["tcp"], Src_ip: ip(t, "10.1.1.1"), Src_port: 1234, Dst_ip: ip(t, "8.8.8.8"), Dst_port: 443, Icmp_type: -1, Icmp_code: -1},
+		},
+		{
+			name: "udp without arrow, split args",
+			args: []string{"udp", "10.1.1.1:53", "8.8.8.8:53"},
+			want: network_entities.Flow{Protocol: network_entities.Protocols_map["udp"], Src_ip: ip(t, "10.1.1.1"), Src_port: 53, Dst_ip: ip(t, "8.8.8.8"), Dst_port: 53, Icmp_type: -1, Icmp_code: -1},
+		},
+		{
+			name: "icmp type and code",
+			args: []string{"icmp 10.1.1.1 -> 8.8.8.8 8 0"},
+			want: network_entities.Flow{Protocol: network_entities.Protocols_map["icmp"], Src_ip: ip(t, "10.1.1.1"), Dst_ip: ip(t, "8.8.8.8"), Icmp_type: 8, Icmp_code: 0},
+		},
+		{
+			name: "icmp without type",
+			args: []string{"icmp 10.1.1.1 -> 8.8.8.8"},
+			want: network_entities.Flow{Protocol: network_entities.Protocols_map["icmp"], Src_ip: ip(t, "10.1.1.1"), Dst_ip: ip(t, "8.8.8.8"), Icmp_type: -1, Icmp_code: -1},
+		},
+		{name: "tcp without port", args: []string{"tcp 10.1.1.1 -> 8.8.8.8:443"}, wantErr: true},
+		{name: "unknown protocol", args: []string{"foo 10.1.1.1 -> 8.8.8.8"}, wantErr: true},
+		{name: "no destination", args: []string{"tcp 10.1.1.1:1234"}, wantErr: true},
+		{name: "bad port", args: []string{"tcp 10.1.1.1:1234 -> 8.8.8.8:99999"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFlow(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFlow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseFlow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	app_ctx := loadTestAppContext(t)
+
+	tests := []struct {
+		name         string
+		flow         string
+		wantInbound  string
+		wantOutbound string
+		wantPermit   bool
+	}{
+		{
+			name:         "permitted by both ACLs",
+			flow:         "tcp 192.168.0.10:1234 -> 8.8.8.8:443",
+			wantInbound:  "access-list inside_in extended permit tcp 192.168.0.0 255.255.255.0 any eq 443",
+			wantOutbound: "access-list outside_out extended permit ip any any",
+			wantPermit:   true,
+		},
+		{
+			name:        "denied by inbound ACL, outbound is not checked",
+			flow:        "tcp 192.168.0.5:1234 -> 8.8.8.8:443",
+			wantInbound: "access-list inside_in extended deny tcp host 192.168.0.5 any eq 443",
+		},
+		{
+			name:         "denied by outbound ACL",
+			flow:         "tcp 192.168.0.10:1234 -> 8.8.4.4:443",
+			wantInbound:  "access-list inside_in extended permit tcp 192.168.0.0 255.255.255.0 any eq 443",
+			wantOutbound: "access-list outside_out extended deny tcp any host 8.8.4.4 eq 443",
+		},
+		{
+			name:        "implicit deny",
+			flow:        "udp 192.168.0.10:53 -> 8.8.8.8:53",
+			wantInbound: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow, err := ParseFlow([]string{tt.flow})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Explain(flow, app_ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Flow.Src_iface != "inside" || got.Flow.Dst_iface != "outside" {
+				t.Errorf("Explain() interfaces = %s->%s, want inside->outside", got.Flow.Src_iface, got.Flow.Dst_iface)
+			}
+			if got.Inbound == nil || got.Inbound.Line != tt.wantInbound {
+				t.Errorf("Explain() inbound = %v, want %q", got.Inbound, tt.wantInbound)
+			}
+			if tt.wantOutbound == "" && got.Outbound != nil {
+				t.Errorf("Explain() outbound = %v, want nil", got.Outbound)
+			}
+			if tt.wantOutbound != "" && (got.Outbound == nil || got.Outbound.Line != tt.wantOutbound) {
+				t.Errorf("Explain() outbound = %v, want %q", got.Outbound, tt.wantOutbound)
+			}
+			if got.Permit != tt.wantPermit {
+				t.Errorf("Explain() permit = %v, want %v", got.Permit, tt.wantPermit)
+			}
+		})
+	}
+}
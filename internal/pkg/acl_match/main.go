@@ -2,18 +2,26 @@ package aclmatch
 
 import (
 	"context"
-	"fmt"
 
 	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
 	cisco_asa_acg "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-group"
 	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	cisco_asa_nat "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-nat"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 	"golang.org/x/sync/errgroup"
 )
 
 func getACLNamesByFlow(flow network_entities.Flow, app_ctx app_context.AppContext) (inbound_acl_name, outbound_acl_name string, err error) {
+	if flow.Acl_name != "" {
+		// --- message names the ACL made a decision, no need to guess it by interfaces
+		return flow.Acl_name, "", nil
+	}
+
 	for _, acg := range app_ctx.Access_groups {
+		if acg.Control_plane != flow.To_box {
+			continue
+		}
 		if acg.Iface == flow.Src_iface && acg.Direction == cisco_asa_acg.Inbound {
 			inbound_acl_name = acg.Acl_name
 		} else if acg.Iface == flow.Dst_iface && acg.Direction == cisco_asa_acg.Outbound {
@@ -38,43 +46,77 @@ func getACLsByFlow(flow network_entities.Flow, app_ctx app_context.AppContext) (
 	return inbound_acl, outbound_acl, nil
 }
 
+// ACLs before ASA 8.3 use addresses as they seen on the interface ACL applied to:
+// inbound ACL sees real source and mapped destination, outbound ACL sees mapped source and real destination.
+func flowAsSeenBy(flow network_entities.Flow, inbound bool, app_ctx app_context.AppContext) network_entities.Flow {
+	if app_ctx.Address_form != cisco_asa_nat.Mapped {
+		return flow
+	}
+
+	if inbound {
+		if flow.Dst_mapped_ip != 0 {
+			flow.Dst_ip, flow.Dst_port = flow.Dst_mapped_ip, flow.Dst_mapped_port
+		}
+	} else {
+		if flow.Src_mapped_ip != 0 {
+			flow.Src_ip, flow.Src_port = flow.Src_mapped_ip, flow.Src_mapped_port
+		}
+	}
+	return flow
+}
+
+// credits flow to inbound and outbound ACLs of its interfaces
+func MatchFlow(flow network_entities.Flow, app_ctx app_context.AppContext) error {
+	if flow.Protocol == nil {
+		return nil
+	}
+
+	if app_ctx.Address_form == cisco_asa_nat.Mapped {
+		flow = app_ctx.Nat_rules.FillMapped(flow)
+	}
+
+	inbound_acl, outbound_acl, err := getACLsByFlow(flow, app_ctx)
+	if err != nil {
+		return err
+	}
+
+	if utils.IsTrace() {
+		inbound_name, outbound_name := "", ""
+		if inbound_acl != nil {
+			inbound_name = inbound_acl.Name
+		}
+		if outbound_acl != nil {
+			outbound_name = outbound_acl.Name
+		}
+		utils.Trace("flow", "flow", flow.String(), "inbound_acl", inbound_name, "outbound_acl", outbound_name)
+	}
+
+	if inbound_acl != nil {
+		err = inbound_acl.AddFlow(flowAsSeenBy(flow, true, app_ctx), app_ctx.Stats_options)
+		if err != nil {
+			return err
+		}
+	}
+	if outbound_acl != nil {
+		err = outbound_acl.AddFlow(flowAsSeenBy(flow, false, app_ctx), app_ctx.Stats_options)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func StartRoutines(num int, app_ctx app_context.AppContext) error {
 	errs, _ := errgroup.WithContext(context.TODO())
 
 	for i := 0; i < num; i++ {
 		errs.Go(func() error {
 			for flow := range app_ctx.Flows {
-				if flow.Protocol == nil {
-					continue
-				}
-
-				inbound_acl, outbound_acl, err := getACLsByFlow(flow, app_ctx)
+				err := MatchFlow(flow, app_ctx)
 				if err != nil {
 					return err
 				}
-
-				if utils.GetLogLevel() == utils.Trace {
-					fmt.Printf("flow: %s\n", flow)
-					if inbound_acl != nil {
-						fmt.Printf("\tinbound_acl: %s\n", inbound_acl.Name)
-					}
-					if outbound_acl != nil {
-						fmt.Printf("\toutbound_acl: %s\n", outbound_acl.Name)
-					}
-				}
-
-				if inbound_acl != nil {
-					err = inbound_acl.AddFlow(flow)
-					if err != nil {
-						return err
-					}
-				}
-				if outbound_acl != nil {
-					err = outbound_acl.AddFlow(flow)
-					if err != nil {
-						return err
-					}
-				}
 			}
 			return nil
 
@@ -0,0 +1,82 @@
+package aclmatch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+const testShowAccessList = `access-list inside_in; 3 elements; name hash: 0x1b2c3d4e
+access-list inside_in line 1 extended deny tcp host 192.168.0.5 any eq https (hitcnt=0) 0x11111111
+access-list inside_in line 2 extended permit tcp 192.168.0.0 255.255.255.0 any eq https (hitcnt=0) 0x22222222
+access-list inside_in line 3 extended permit icmp any any echo (hitcnt=0) 0x33333333
+access-list outside_out line 1 extended permit tcp any host 8.8.4.4 eq https (hitcnt=0) 0x44444444
+`
+
+func TestMatchFlow_aceHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		ace_hash uint32
+		// --- flows credited to ACEs of inside_in
+		want []uint64
+	}{
+		{name: "no hash, first match", want: []uint64{1, 0, 0}},
+		{name: "hash of later ACE", ace_hash: 0x22222222, want: []uint64{0, 1, 0}},
+		{name: "hash of ACE not matching the flow, first match", ace_hash: 0x33333333, want: []uint64{1, 0, 0}},
+		{name: "unknown hash, first match", ace_hash: 0x55555555, want: []uint64{1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app_ctx := loadTestAppContext(t)
+			hashes, err := cisco_asa_acl.ParseHashes(strings.NewReader(testShowAccessList))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dropped := 0
+			for i := range app_ctx.Access_lists {
+				dropped += app_ctx.Access_lists[i].SetHashes(hashes)
+			}
+			// --- line 1 of outside_out is "deny" in config
+			if dropped != 1 {
+				t.Errorf("SetHashes() dropped %d hashes, want 1", dropped)
+			}
+
+			flow := network_entities.Flow{
+				Protocol:  network_entities.Protocols_map["tcp"],
+				Src_iface: "inside",
+				Dst_iface: "outside",
+				Src_ip:    ip(t, "192.168.0.5"),
+				Dst_ip:    ip(t, "8.8.8.8"),
+				Src_port:  1234,
+				Dst_port:  443,
+				Icmp_type: -1,
+				Icmp_code: -1,
+				Acl_name:  "inside_in",
+				Ace_hash:  tt.ace_hash,
+			}
+			err = MatchFlow(flow, app_ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := app_ctx.Access_lists[0].GetResults()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []uint64
+			for _, result := range results {
+				var flows uint64
+				for _, entry := range result.Entries {
+					flows += entry.Flows
+				}
+				got = append(got, flows)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchFlow() credited %v flows, want %v", got, tt.want)
+			}
+		})
+	}
+}
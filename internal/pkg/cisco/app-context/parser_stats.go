@@ -0,0 +1,42 @@
+package app_context
+
+func NewParserStats() *ParserStats {
+	return &ParserStats{skipped: make(map[string]uint64)}
+}
+
+func (s *ParserStats) AddLine() {
+	if s == nil {
+		return
+	}
+	s.lines_read.Add(1)
+}
+
+func (s *ParserStats) AddError() {
+	if s == nil {
+		return
+	}
+	s.parse_errors.Add(1)
+}
+
+func (s *ParserStats) AddSkipped(message_id string) {
+	if s == nil {
+		return
+	}
+	s.m.Lock()
+	s.skipped[message_id]++
+	s.m.Unlock()
+}
+
+// snapshot of counters, skipped is a copy
+func (s *ParserStats) Get() (lines_read, parse_errors uint64, skipped map[string]uint64) {
+	if s == nil {
+		return 0, 0, nil
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	skipped = make(map[string]uint64, len(s.skipped))
+	for id, count := range s.skipped {
+		skipped[id] = count
+	}
+	return s.lines_read.Load(), s.parse_errors.Load(), skipped
+}
@@ -0,0 +1,66 @@
+package app_context
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// flows counted by previous runs sharing a state, see --state.
+// Syslog is read from the beginning every run, so flows logged before the newest second of previous run are skipped,
+// as well as flows of that second previous run counted already. Flows without timestamp can't be told apart, they are skipped.
+type SeenFlows struct {
+	m sync.Mutex
+	// --- newest second of previous run, flows of it left to skip
+	until   time.Time
+	to_skip uint64
+	// --- newest second counted so far, flows of it counted
+	newest    time.Time
+	at_newest uint64
+	// --- flows skipped for lack of timestamp
+	no_timestamp uint64
+}
+
+func NewSeenFlows(until time.Time, at_until uint64) *SeenFlows {
+	return &SeenFlows{until: until, to_skip: at_until, newest: until, at_newest: at_until}
+}
+
+// false if flow is counted by previous run or has no timestamp, nil counts every flow
+func (s *SeenFlows) Add(flow network_entities.Flow) bool {
+	if s == nil {
+		return true
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	switch {
+	case flow.Timestamp.IsZero():
+		s.no_timestamp++
+		return false
+	case flow.Timestamp.Before(s.until):
+		return false
+	case flow.Timestamp.Equal(s.until) && s.to_skip > 0:
+		s.to_skip--
+		return false
+	}
+
+	switch {
+	case flow.Timestamp.After(s.newest):
+		s.newest = flow.Timestamp
+		s.at_newest = 1
+	case flow.Timestamp.Equal(s.newest):
+		s.at_newest++
+	}
+	return true
+}
+
+// newest second counted, by this or previous runs, and number of flows of it
+func (s *SeenFlows) Get() (newest time.Time, at_newest, no_timestamp uint64) {
+	if s == nil {
+		return time.Time{}, 0, 0
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.newest, s.at_newest, s.no_timestamp
+}
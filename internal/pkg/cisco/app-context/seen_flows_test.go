@@ -0,0 +1,77 @@
+package app_context
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+func TestSeenFlows_Add(t *testing.T) {
+	t0 := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Second)
+	tests := []struct {
+		name       string
+		until      time.Time
+		at_until   uint64
+		timestamps []time.Time
+		want       []bool
+		// --- state saved after the run
+		want_newest       time.Time
+		want_at_newest    uint64
+		want_no_timestamp uint64
+	}{
+		{
+			name:           "first run",
+			timestamps:     []time.Time{t0, t0, t1},
+			want:           []bool{true, true, true},
+			want_newest:    t1,
+			want_at_newest: 1,
+		},
+		{
+			name:           "same log again",
+			until:          t1,
+			at_until:       1,
+			timestamps:     []time.Time{t0, t0, t1},
+			want:           []bool{false, false, false},
+			want_newest:    t1,
+			want_at_newest: 1,
+		},
+		{
+			name:           "new flows logged in the same second",
+			until:          t1,
+			at_until:       1,
+			timestamps:     []time.Time{t0, t1, t1, t1},
+			want:           []bool{false, false, true, true},
+			want_newest:    t1,
+			want_at_newest: 3,
+		},
+		{
+			name:              "flows without timestamp",
+			until:             t0,
+			at_until:          1,
+			timestamps:        []time.Time{{}, t0, t1},
+			want:              []bool{false, false, true},
+			want_newest:       t1,
+			want_at_newest:    1,
+			want_no_timestamp: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSeenFlows(tt.until, tt.at_until)
+			var got []bool
+			for _, timestamp := range tt.timestamps {
+				got = append(got, s.Add(network_entities.Flow{Timestamp: timestamp}))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SeenFlows.Add() = %v, want %v", got, tt.want)
+			}
+			newest, at_newest, no_timestamp := s.Get()
+			if !newest.Equal(tt.want_newest) || at_newest != tt.want_at_newest || no_timestamp != tt.want_no_timestamp {
+				t.Errorf("SeenFlows.Get() = %v, %v, %v, want %v, %v, %v", newest, at_newest, no_timestamp, tt.want_newest, tt.want_at_newest, tt.want_no_timestamp)
+			}
+		})
+	}
+}
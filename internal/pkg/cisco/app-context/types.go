@@ -1,15 +1,72 @@
 package app_context
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
 	cisco_asa_acg "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-group"
 	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	cisco_asa_nat "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-nat"
 	sh_ip_route "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/sh-ip-route"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 )
 
+// everything known about a single firewall
 type AppContext struct {
+	Name          string
 	Access_groups []cisco_asa_acg.Accessgroup
 	Access_lists  []cisco_asa_acl.Accesslist
 	Routing_table sh_ip_route.RoutingTable
+	Nat_rules     cisco_asa_nat.NatRules
+	Address_form  cisco_asa_nat.AddressForm
+	// --- flows outside of [Since, Until) are skipped, zero means no limit
+	Since time.Time
+	Until time.Time
+	// --- flows already counted in the state of previous run, see --state. nil counts every flow
+	Seen_flows *SeenFlows
+	// --- syslog shared by contexts: only messages tagged by one of these device-ids are taken, empty takes all
+	Device_ids []string
+	// --- capacity model of ACLs, overridden per ACL name
+	Capacity_model      network_entities.CapacityModel
+	Acl_capacity_models map[string]network_entities.CapacityModel
+	// --- how per-ACE statistics are collected
+	Stats_options network_entities.StatsOptions
 	Flows         chan network_entities.Flow
+	// --- syslog parser counters, nil counts nothing
+	Parser_stats *ParserStats
+}
+
+// counters of syslog parser, shared by parsing workers
+type ParserStats struct {
+	lines_read   atomic.Uint64
+	parse_errors atomic.Uint64
+	m            sync.Mutex
+	// --- lines with messages not used for analysis, per message ID
+	skipped map[string]uint64
+}
+
+// flows passed are counted as seen, see SeenFlows
+func (app_ctx AppContext) IsInTimeWindow(flow network_entities.Flow) bool {
+	if !app_ctx.isInSinceUntil(flow) {
+		return false
+	}
+	return app_ctx.Seen_flows.Add(flow)
+}
+
+func (app_ctx AppContext) isInSinceUntil(flow network_entities.Flow) bool {
+	if app_ctx.Since.IsZero() && app_ctx.Until.IsZero() {
+		return true
+	}
+	// --- no timestamp, can't tell if flow in the window
+	if flow.Timestamp.IsZero() {
+		return false
+	}
+	if !app_ctx.Since.IsZero() && flow.Timestamp.Before(app_ctx.Since) {
+		return false
+	}
+	if !app_ctx.Until.IsZero() && !flow.Timestamp.Before(app_ctx.Until) {
+		return false
+	}
+	return true
 }
@@ -1,18 +1,23 @@
 package ciscoasaaccessgroup
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
 )
 
+// example:
+// access-group inside_in in interface inside
+// access-group outside_cp in interface outside control-plane
 func isAccessGroup(str string) (*Accessgroup, error) {
 	const prefix = "access-group "
 	if len(str) > len(prefix) {
 		if str[:len(prefix)] == prefix {
 			fields := strings.Fields(str)
-			if len(fields) == 5 {
+			control_plane := len(fields) == 6 && fields[5] == "control-plane"
+			if len(fields) == 5 || control_plane {
 				var direction direction
 				if fields[2] == "in" {
 					direction = Inbound
@@ -20,28 +25,18 @@ func isAccessGroup(str string) (*Accessgroup, error) {
 					direction = Outbound
 				}
 
-				return &Accessgroup{Iface: fields[4], Acl_name: fields[1], Direction: direction}, nil
+				return &Accessgroup{Iface: fields[4], Acl_name: fields[1], Direction: direction, Control_plane: control_plane}, nil
 			}
 		}
 	}
 	return nil, nil
 }
 
-func Parse(in_file string) ([]Accessgroup, error) {
+func Parse(sh_run sh_run_pipe.Text) ([]Accessgroup, error) {
 	var ags []Accessgroup
 
-	readFile, err := os.Open(in_file)
-	if err != nil {
-		// log.Println(err)
-		return nil, err
-	}
-	defer readFile.Close()
-
-	fileScanner := bufio.NewScanner(readFile)
-	fileScanner.Split(bufio.ScanLines)
-
-	for fileScanner.Scan() {
-		ag, err := isAccessGroup(fileScanner.Text())
+	for _, line := range sh_run.Prefix("access-group ") {
+		ag, err := isAccessGroup(line)
 		if err != nil {
 			// log.Println(err)
 			return nil, err
@@ -55,6 +50,6 @@ func Parse(in_file string) ([]Accessgroup, error) {
 	return ags, nil
 }
 
-func (acg Accessgroup) Print() {
-	fmt.Printf("access-group %v\n", acg)
+func (acg Accessgroup) Print(w io.Writer) {
+	fmt.Fprintf(w, "access-group %v\n", acg)
 }
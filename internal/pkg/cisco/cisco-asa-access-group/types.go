@@ -10,4 +10,6 @@ const (
 type Accessgroup struct {
 	Iface, Acl_name string
 	Direction       direction
+	// --- ACL filters to-the-box traffic
+	Control_plane bool
 }
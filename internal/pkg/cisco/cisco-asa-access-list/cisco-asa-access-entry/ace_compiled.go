@@ -3,33 +3,104 @@ package ciscoasaaccessentry
 import (
 	"errors"
 	"fmt"
-	"sync"
+	"math"
+	"math/bits"
+	"sort"
+	"time"
 
+	"github.com/ivankuchin/excessive-acl/internal/pkg/hyperloglog"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 )
 
-func (ace *accessEntryCompiled) AddFlow(flow network_entities.Flow) error {
-	if ace.m == nil {
-		ace.m = &sync.Mutex{}
+// default number of flows kept per compiled entry as examples
+const maxSamples = 100
+
+// bytes per second of connection, an address or port used at the last rate counts in full in bytes-weighted utilization
+var rateClasses = [...]float64{1, 1e3, 1e6}
+
+func isAnyAddress(addr_range utils.AddressObject) bool {
+	return addr_range.Start == 0 && addr_range.Finish == 0xffffffff
+}
+
+func (ace *accessEntryCompiled) newFlowStats(stats_options network_entities.StatsOptions) (*flowStats, error) {
+	// --- any address is counted only if capacity model takes it as whole address space
+	track_src := ace.capacity_model.AnySrcAddressFull() || !isAnyAddress(ace.src_addr_range)
+	track_dst := ace.capacity_model.AnyDstAddressFull() || !isAnyAddress(ace.dst_addr_range)
+	stats, err := newFlowStats(track_src, track_dst, stats_options.Hll_precision)
+	if err != nil {
+		return nil, err
 	}
+	stats.max_prefix_length = stats_options.Max_prefix_length
+	stats.max_port_range = stats_options.Max_port_range
+	return stats, nil
+}
+
+func (ace *accessEntryCompiled) AddFlow(flow network_entities.Flow, stats_options network_entities.StatsOptions) error {
+	var err error
 
 	ace.m.Lock()
 	defer ace.m.Unlock()
-	ace.flows = append(ace.flows, flow)
+
+	if ace.stats == nil {
+		ace.stats, err = ace.newFlowStats(stats_options)
+		if err != nil {
+			return err
+		}
+	}
+	ace.stats.add(flow)
+
+	rate := flowRate(flow)
+	for i, min_rate := range rateClasses {
+		if rate < min_rate {
+			break
+		}
+		if ace.rate_stats[i] == nil {
+			ace.rate_stats[i], err = ace.newFlowStats(stats_options)
+			if err != nil {
+				return err
+			}
+		}
+		ace.rate_stats[i].add(flow)
+	}
+
+	bucket := stats_options.Bucket
+	if bucket != network_entities.BucketNone {
+		if ace.bucket_stats == nil {
+			ace.bucket_stats = make(map[time.Time]*flowStats)
+		}
+		// --- flows without timestamp go to zero time bucket
+		var start time.Time
+		if !flow.Timestamp.IsZero() {
+			start = bucket.Start(flow.Timestamp)
+		}
+		stats, ok := ace.bucket_stats[start]
+		if !ok {
+			stats, err = ace.newFlowStats(stats_options)
+			if err != nil {
+				return err
+			}
+			ace.bucket_stats[start] = stats
+		}
+		stats.add(flow)
+	}
+
+	max_samples := maxSamples
+	if stats_options.Samples > 0 {
+		max_samples = stats_options.Samples
+	}
+	if len(ace.samples) < max_samples {
+		ace.samples = append(ace.samples, flow)
+	}
 	return nil
 }
 
 func (ace *accessEntryCompiled) MatchFlow(flow network_entities.Flow) (bool, error) {
 	if ace.proto == nil {
-		error_message := "ERROR: compiled access entry protocol is nil"
-		fmt.Printf("%s\n", error_message)
-		return false, errors.New(error_message)
+		return false, errors.New("compiled access entry protocol is nil")
 	}
 	if flow.Protocol == nil {
-		error_message := "ERROR: flow protocol is nil"
-		fmt.Printf("%s (%s)\n", error_message, flow)
-		return false, errors.New(error_message)
+		return false, fmt.Errorf("flow protocol is nil (flow: %v)", flow)
 	}
 	if !ace.proto.Match(flow.Protocol) {
 		return false, nil
@@ -46,7 +117,7 @@ func (ace *accessEntryCompiled) MatchFlow(flow network_entities.Flow) (bool, err
 	switch ace.proto.Id {
 	case 4: // ip
 		return true, nil
-	case 6, 17: // tcp, udp
+	case 6, 17, 132: // tcp, udp, sctp
 		if ace.proto.ExactMatch(flow.Protocol) {
 			// both protocols are TCP or UDP, so we ьгые check ports
 			switch {
@@ -136,41 +207,76 @@ func (compiled accessEntryCompiled) String() string {
 	return str1 + str2
 }
 
-func (ace *accessEntryCompiled) getCapacity() (uint, error) {
-	var src_ip_space, dst_ip_space uint
-	var src_port_space, dst_port_space uint
-	var icmp_type_space, icmp_code_space uint
+// space of every dimension the entry restricts, capacity is their product.
+// Dimensions not used by protocol (ex: ports of icmp) or not counted by capacity model are 0.
+type dimensions struct {
+	src_ips   uint
+	dst_ips   uint
+	src_ports uint
+	dst_ports uint
+	icmp      uint
+}
 
-	src_ip_space += uint(int(ace.src_addr_range.Finish) - int(ace.src_addr_range.Start) + 1)
-	dst_ip_space += uint(int(ace.dst_addr_range.Finish) - int(ace.dst_addr_range.Start) + 1)
+// capacity of any to any with whole address space is 2^64, product is capped by the max uint.
+// Addresses are always counted, flows without addresses have zero capacity.
+func (d dimensions) product() uint {
+	capacity := uint64(d.src_ips)
+	factors := []uint{d.dst_ips}
+	for _, space := range []uint{d.src_ports, d.dst_ports, d.icmp} {
+		if space != 0 {
+			factors = append(factors, space)
+		}
+	}
+	for _, factor := range factors {
+		hi, lo := bits.Mul64(capacity, uint64(factor))
+		if hi != 0 {
+			return math.MaxUint
+		}
+		capacity = lo
+	}
+	return uint(capacity)
+}
+
+func (ace *accessEntryCompiled) getDimensions() (dimensions, error) {
+	var dims dimensions
 
-	if src_ip_space == 0x100000000 {
-		src_ip_space = 1
+	dims.src_ips = uint(int(ace.src_addr_range.Finish) - int(ace.src_addr_range.Start) + 1)
+	dims.dst_ips = uint(int(ace.dst_addr_range.Finish) - int(ace.dst_addr_range.Start) + 1)
+
+	// --- "any" is a placeholder that doesn't require optimization, unless model says otherwise
+	if dims.src_ips == 0x100000000 && !ace.capacity_model.AnySrcAddressFull() {
+		dims.src_ips = 1
 	}
-	if dst_ip_space == 0x100000000 {
-		dst_ip_space = 1
+	if dims.dst_ips == 0x100000000 && !ace.capacity_model.AnyDstAddressFull() {
+		dims.dst_ips = 1
 	}
 
 	switch ace.proto.Id {
 	case 4: // ip
-		return src_ip_space * dst_ip_space, nil
-	case 6, 17: // tcp, udp
-		if ace.src_port_range.finish == 0 {
+		return dims, nil
+	case 6, 17, 132: // tcp, udp, sctp
+		switch {
+		case ace.src_port_range.finish != 0:
+			dims.src_ports = uint(ace.src_port_range.finish-ace.src_port_range.start) + 1
+		case ace.capacity_model.OmittedSrcPortFull():
+			dims.src_ports = 65536
+		default:
 			// most protocols uses ephemeral ports to source connections,
 			// we do not take them into account
-			src_port_space = 1
-		} else {
-			src_port_space += uint(ace.src_port_range.finish-ace.src_port_range.start) + 1
 		}
-		if ace.dst_port_range.finish == 0 {
+		switch {
+		case ace.dst_port_range.finish == 0 && ace.capacity_model.OmittedDstPortFull():
 			// if destination ports are not explicitely pointed out, means they probably forgotten
 			// whole tcp port range (1-65535) is open
-			dst_port_space += 65536
-		} else {
-			dst_port_space += uint(ace.dst_port_range.finish-ace.dst_port_range.start) + 1
+			dims.dst_ports = 65536
+		case ace.dst_port_range.finish == 0:
+			// --- not counted by capacity model
+		default:
+			dims.dst_ports = uint(ace.dst_port_range.finish-ace.dst_port_range.start) + 1
 		}
-		return src_port_space * src_ip_space * dst_port_space * dst_ip_space, nil
+		return dims, nil
 	case 1: // icmp
+		var icmp_type_space, icmp_code_space uint
 		if ace.icmp_flows.icmp_type == 0 {
 			// calculate ACE capacity
 			if ace.icmp.icmp_type == -1 {
@@ -188,126 +294,135 @@ func (ace *accessEntryCompiled) getCapacity() (uint, error) {
 			icmp_type_space = uint(ace.icmp_flows.icmp_type)
 			icmp_code_space = uint(ace.icmp_flows.icmp_code)
 		}
+		// --- omitted type and code are not counted by capacity model, neither in flows
+		if !ace.capacity_model.OmittedICMPFull() {
+			if ace.icmp.icmp_type == -1 {
+				icmp_type_space = 1
+			}
+			if ace.icmp.icmp_code == -1 {
+				icmp_code_space = 1
+			}
+		}
 
-		icmp_space := icmp_code_space * icmp_type_space
-		ip_space := src_ip_space * dst_ip_space
-		return ip_space * icmp_space, nil
+		dims.icmp = icmp_code_space * icmp_type_space
+		return dims, nil
 	default:
-		error_message := "ERROR: unknown protocol"
-		fmt.Printf("%s (%v)\n", error_message, ace.proto)
-		return 0, errors.New(error_message)
+		return dims, fmt.Errorf("unknown protocol (proto: %v)", ace.proto)
 	}
 }
 
-func (ace *accessEntryCompiled) getFlowsUniqueSrcIPs() uint32 {
-	var ips map[uint32]bool
-	ips = make(map[uint32]bool)
-
-	for _, flow := range ace.flows {
-		ips[flow.Src_ip] = true
+func (ace *accessEntryCompiled) getCapacity() (uint, error) {
+	dims, err := ace.getDimensions()
+	if err != nil {
+		return 0, err
 	}
-
-	return uint32(len(ips))
+	return dims.product(), nil
 }
 
-func (ace *accessEntryCompiled) getFlowsUniqueDstIPs() uint32 {
-	var ips map[uint32]bool
-	ips = make(map[uint32]bool)
-
-	for _, flow := range ace.flows {
-		ips[flow.Dst_ip] = true
-	}
-
-	return uint32(len(ips))
+func (stats *flowStats) getFlowsUniqueSrcIPs() uint32 {
+	return stats.src_ips.count()
 }
 
-func (ace *accessEntryCompiled) getFlowsUniqueSrcPorts() port {
-	var ports map[uint32]bool
-	ports = make(map[uint32]bool)
+func (stats *flowStats) getFlowsUniqueDstIPs() uint32 {
+	return stats.dst_ips.count()
+}
 
-	for _, flow := range ace.flows {
-		ports[uint32(flow.Src_port)] = true
+// address space of flows: unique addresses, or prefixes covering them
+// if max_prefix_length is set. Estimated addresses can't be summarized.
+func (stats *flowStats) getFlowsIPSpace(counter ipCounter, addr_range utils.AddressObject) uint {
+	set, ok := counter.(ipSet)
+	if !ok || stats.max_prefix_length == 0 || stats.max_prefix_length >= 32 {
+		return uint(counter.count())
 	}
-
-	return port(len(ports))
+	return set.prefixSpace(stats.max_prefix_length, addr_range)
 }
 
-func (ace *accessEntryCompiled) getFlowsUniqueDstPorts() port {
-	var ports map[uint32]bool
-	ports = make(map[uint32]bool)
-
-	for _, flow := range ace.flows {
-		ports[uint32(flow.Dst_port)] = true
+// range of space addresses for fake ACE, whole address space doesn't fit 1..space
+func ipSpaceRange(space uint) utils.AddressObject {
+	if space > math.MaxUint32 {
+		return utils.AddressObject{Start: 0, Finish: math.MaxUint32}
 	}
+	return utils.AddressObject{Start: 1, Finish: uint32(space)}
+}
 
-	return port(len(ports))
+func (stats *flowStats) getFlowsUniqueSrcPorts() port {
+	return port(stats.src_ports.count())
 }
 
-func (ace *accessEntryCompiled) getFlowsUniqueICMPTypes() int {
-	var types map[uint32]bool
-	types = make(map[uint32]bool)
+func (stats *flowStats) getFlowsUniqueDstPorts() port {
+	return port(stats.dst_ports.count())
+}
 
-	for _, flow := range ace.flows {
-		types[uint32(flow.Icmp_type)] = true
+// port space of flows: unique ports, or ranges covering them
+// if max_port_range is set. Omitted ports of ACE are 0..65535.
+func (stats *flowStats) getFlowsPortSpace(ports *bitmap, ace_range port_range) port {
+	if stats.max_port_range <= 1 {
+		return port(ports.count())
 	}
-
-	return len(types)
+	if ace_range.finish == 0 {
+		ace_range = port_range{start: 0, finish: 65535}
+	}
+	return port(min(ports.rangeSpace(stats.max_port_range, ace_range.finish), 65535))
 }
 
-func (ace *accessEntryCompiled) getFlowsUniqueICMPCodes() int {
-	var codes map[uint32]bool
-	codes = make(map[uint32]bool)
-
-	for _, flow := range ace.flows {
-		codes[uint32(flow.Icmp_code)] = true
-	}
+func (stats *flowStats) getFlowsUniqueICMPTypes() int {
+	return stats.icmp_types.count()
+}
 
-	return len(codes)
+func (stats *flowStats) getFlowsUniqueICMPCodes() int {
+	return stats.icmp_codes.count()
 }
 
-func (ace *accessEntryCompiled) getFakeACE() (accessEntryCompiled, error) {
+func (ace *accessEntryCompiled) getFakeACE(stats *flowStats) (accessEntryCompiled, error) {
 	fake_ace := accessEntryCompiled{
 		action: ace.action,
 		proto:  ace.proto,
+		// --- omitted ICMP type and code are told by ACE, see getDimensions
+		icmp:           ace.icmp,
+		capacity_model: ace.capacity_model,
 		// src_addr_range: utils.AddressObject{Start: 1, Finish: ace.getFlowsUniqueSrcIPs()},
 		// dst_addr_range: utils.AddressObject{Start: 1, Finish: ace.getFlowsUniqueDstIPs()},
 	}
 
-	if ace.src_addr_range.Start == 0 && ace.src_addr_range.Finish == 0xffffffff {
+	if stats.src_ips == nil {
 		fake_ace.src_addr_range = utils.AddressObject{Start: 0, Finish: 0xffffffff}
 	} else {
-		fake_ace.src_addr_range = utils.AddressObject{Start: 1, Finish: ace.getFlowsUniqueSrcIPs()}
+		fake_ace.src_addr_range = ipSpaceRange(stats.getFlowsIPSpace(stats.src_ips, ace.src_addr_range))
 	}
-	if ace.dst_addr_range.Start == 0 && ace.dst_addr_range.Finish == 0xffffffff {
+	if stats.dst_ips == nil {
 		fake_ace.dst_addr_range = utils.AddressObject{Start: 0, Finish: 0xffffffff}
 	} else {
-		fake_ace.dst_addr_range = utils.AddressObject{Start: 1, Finish: ace.getFlowsUniqueDstIPs()}
+		fake_ace.dst_addr_range = ipSpaceRange(stats.getFlowsIPSpace(stats.dst_ips, ace.dst_addr_range))
 	}
 
 	switch ace.proto.Id {
 	case 4: // ip
 		return fake_ace, nil
-	case 6, 17: // tcp, udp
-		if ace.src_port_range.finish == 0 {
-			// most protocols uses ephemeral ports to source connections,
-			// we do not take them into account
-			fake_ace.src_port_range = port_range{start: 1, finish: 1}
-		} else {
-			fake_ace.src_port_range = port_range{start: 1, finish: ace.getFlowsUniqueSrcPorts()}
+	case 6, 17, 132: // tcp, udp, sctp
+		// --- ports not counted in ACE capacity are omitted in flows capacity too
+		if ace.src_port_range.finish != 0 || ace.capacity_model.OmittedSrcPortFull() {
+			fake_ace.src_port_range = port_range{start: 1, finish: stats.getFlowsPortSpace(&stats.src_ports, ace.src_port_range)}
+		}
+		if ace.dst_port_range.finish != 0 || ace.capacity_model.OmittedDstPortFull() {
+			fake_ace.dst_port_range = port_range{start: 1, finish: stats.getFlowsPortSpace(&stats.dst_ports, ace.dst_port_range)}
 		}
-		fake_ace.dst_port_range = port_range{start: 1, finish: ace.getFlowsUniqueDstPorts()}
 		return fake_ace, nil
 	case 1: // icmp
-		fake_ace.icmp_flows.icmp_type = ace.getFlowsUniqueICMPTypes()
-		fake_ace.icmp_flows.icmp_code = ace.getFlowsUniqueICMPCodes()
+		fake_ace.icmp_flows.icmp_type = stats.getFlowsUniqueICMPTypes()
+		fake_ace.icmp_flows.icmp_code = stats.getFlowsUniqueICMPCodes()
 		return fake_ace, nil
 	}
 
 	return fake_ace, nil
 }
 
-func (ace *accessEntryCompiled) getFlowsCapacity() (uint, error) {
-	fake_ace, err := ace.getFakeACE()
+// zero without flows: dimensions not counted (ex: any) would give capacity 1 otherwise
+func (ace *accessEntryCompiled) getFlowsCapacity(stats *flowStats) (uint, error) {
+	if stats == nil || stats.flows == 0 {
+		return 0, nil
+	}
+
+	fake_ace, err := ace.getFakeACE(stats)
 	if err != nil {
 		return 0, err
 	}
@@ -319,22 +434,225 @@ func (ace *accessEntryCompiled) getFlowsCapacity() (uint, error) {
 	return capacity, nil
 }
 
-func (ace *accessEntryCompiled) Analyze() error {
+// utilization of every dimension entry restricts, shows which one is over-permissive.
+// Dimensions not counted by capacity model (ex: omitted source port) are left out.
+func (ace *accessEntryCompiled) getDimensionsUtilization(stats *flowStats) ([]DimensionUtilization, error) {
+	if stats == nil || stats.flows == 0 {
+		return nil, nil
+	}
+
+	ace_dims, err := ace.getDimensions()
+	if err != nil {
+		return nil, err
+	}
+	fake_ace, err := ace.getFakeACE(stats)
+	if err != nil {
+		return nil, err
+	}
+	flows_dims, err := fake_ace.getDimensions()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DimensionUtilization
+	add := func(dimension string, space, flows_space uint, counter ipCounter) {
+		if space == 0 {
+			return
+		}
+		utilization := getUtilization(flows_space, space, nil)
+		// --- only unique addresses are estimated, any address is not counted at all
+		if counter != nil && stats.hll_precision != 0 {
+			utilization.Approximate = true
+			utilization.Margin = utilization.Percent * 2 * hyperloglog.RelativeError(stats.hll_precision)
+		}
+		result = append(result, DimensionUtilization{
+			Dimension:   dimension,
+			Space:       space,
+			Flows_space: flows_space,
+			Utilization: utilization,
+		})
+	}
+	add("src ips", ace_dims.src_ips, flows_dims.src_ips, stats.src_ips)
+	add("dst ips", ace_dims.dst_ips, flows_dims.dst_ips, stats.dst_ips)
+	add("src ports", ace_dims.src_ports, flows_dims.src_ports, nil)
+	add("dst ports", ace_dims.dst_ports, flows_dims.dst_ports, nil)
+	add("icmp", ace_dims.icmp, flows_dims.icmp, nil)
+
+	return result, nil
+}
+
+// bytes per second of connection, connections shorter than a second are taken as a second long.
+// Flows without accounting (denied, ICMP, not torn down) are taken as the busiest ones.
+func flowRate(flow network_entities.Flow) float64 {
+	if !flow.Accounted {
+		return math.Inf(1)
+	}
+	return float64(flow.Bytes) / max(flow.Duration.Seconds(), 1)
+}
+
+func (ace *accessEntryCompiled) getFlows() uint64 {
+	if ace.stats == nil {
+		return 0
+	}
+	return ace.stats.flows
+}
+
+func (ace *accessEntryCompiled) getBytes() uint64 {
+	if ace.stats == nil {
+		return 0
+	}
+	return ace.stats.bytes
+}
+
+func getUtilization(flows_capacity, ace_space uint, stats *flowStats) Utilization {
+	utilization := Utilization{Percent: float64(flows_capacity) / float64(ace_space) * 100.0}
+	if stats != nil && stats.hll_precision != 0 {
+		utilization.Approximate = true
+		utilization.Margin = utilization.Percent * stats.getErrorMargin()
+	}
+	return utilization
+}
+
+// utilization in percents, estimated one is followed by its error margin
+func (u Utilization) String() string {
+	if !u.Approximate {
+		return fmt.Sprintf("%.3f", u.Percent)
+	}
+	return fmt.Sprintf("%.3f ±%.3f", u.Percent, u.Margin)
+}
+
+// mean of utilizations of flows at least as busy as every rate class: address or port used by
+// 1 MB/s connection counts in full, by 1 KB/s one as two thirds, by zero bytes connections (scanners, failed handshakes) not at all
+func (ace *accessEntryCompiled) getBytesWeightedUtilization(ace_space uint) (Utilization, error) {
+	var result Utilization
+	for _, stats := range ace.rate_stats {
+		flows_capacity, err := ace.getFlowsCapacity(stats)
+		if err != nil {
+			return result, err
+		}
+		utilization := getUtilization(flows_capacity, ace_space, stats)
+		result.Percent += utilization.Percent / float64(len(rateClasses))
+		result.Margin += utilization.Margin / float64(len(rateClasses))
+		result.Approximate = result.Approximate || utilization.Approximate
+	}
+	return result, nil
+}
+
+func (ace *accessEntryCompiled) getBuckets(ace_space uint) ([]BucketResult, error) {
+	starts := make([]time.Time, 0, len(ace.bucket_stats))
+	for start := range ace.bucket_stats {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+
+	var buckets []BucketResult
+	for _, start := range starts {
+		stats := ace.bucket_stats[start]
+		flows_capacity, err := ace.getFlowsCapacity(stats)
+		if err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, BucketResult{
+			Start:       start,
+			Flows:       stats.flows,
+			Utilization: getUtilization(flows_capacity, ace_space, stats),
+		})
+	}
+
+	return buckets, nil
+}
+
+// entry is locked, results may be taken while flows are added
+func (ace *accessEntryCompiled) getResult() (EntryResult, error) {
+	var result EntryResult
+
+	ace.m.Lock()
+	defer ace.m.Unlock()
 
 	ace_space, err := ace.getCapacity()
 	if err != nil {
-		return err
+		return result, err
+	}
+	flows_capacity, err := ace.getFlowsCapacity(ace.stats)
+	if err != nil {
+		return result, err
+	}
+	bytes_weighted_utilization, err := ace.getBytesWeightedUtilization(ace_space)
+	if err != nil {
+		return result, err
+	}
+	dims, err := ace.getDimensionsUtilization(ace.stats)
+	if err != nil {
+		return result, err
 	}
-	fmt.Printf("\t\tACE compiled: capacity 0x%x, %v\n", ace_space, ace)
+	buckets, err := ace.getBuckets(ace_space)
+	if err != nil {
+		return result, err
+	}
+
+	var first_seen, last_seen time.Time
+	var unique_src_ips, unique_dst_ips uint32
+	if ace.stats != nil {
+		first_seen, last_seen = ace.stats.first_seen, ace.stats.last_seen
+		if ace.stats.src_ips != nil {
+			unique_src_ips = ace.stats.getFlowsUniqueSrcIPs()
+		}
+		if ace.stats.dst_ips != nil {
+			unique_dst_ips = ace.stats.getFlowsUniqueDstIPs()
+		}
+	}
+
+	result = EntryResult{
+		Entry:                      ace.String(),
+		Permit:                     ace.action == permit,
+		Capacity:                   ace_space,
+		Flows:                      ace.getFlows(),
+		Bytes:                      ace.getBytes(),
+		Unique_src_ips:             unique_src_ips,
+		Unique_dst_ips:             unique_dst_ips,
+		First_seen:                 first_seen,
+		Last_seen:                  last_seen,
+		Flows_capacity:             flows_capacity,
+		Utilization:                getUtilization(flows_capacity, ace_space, ace.stats),
+		Bytes_weighted_utilization: bytes_weighted_utilization,
+		Dimensions:                 dims,
+		Buckets:                    buckets,
+		Samples:                    ace.samples,
+	}
+	return result, nil
+}
 
-	flows_capacity, err := ace.getFlowsCapacity()
+func (ace *accessEntryCompiled) Analyze() error {
+	result, err := ace.getResult()
 	if err != nil {
 		return err
 	}
-	fmt.Printf("\t\t# of flows: %v, capacity: 0x%x, ACE capacity utilization(%%): %.3f\n", len(ace.flows), flows_capacity, float64(flows_capacity)/float64(ace_space)*100.0)
-	for _, flow := range ace.flows {
+
+	fmt.Printf("\t\tACE compiled: capacity 0x%x, %v\n", result.Capacity, result.Entry)
+	fmt.Printf("\t\t# of flows: %v, bytes: %v, capacity: 0x%x, ACE capacity utilization(%%): %s, bytes-weighted utilization(%%): %s\n",
+		result.Flows, result.Bytes, result.Flows_capacity,
+		result.Utilization, result.Bytes_weighted_utilization,
+	)
+	if len(result.Dimensions) > 0 {
+		fmt.Printf("\t\tutilization by dimension(%%): %s\n", FormatDimensions(result.Dimensions))
+	}
+	for _, bucket := range result.Buckets {
+		title := "no timestamp"
+		if !bucket.Start.IsZero() {
+			title = bucket.Start.Format("2006-01-02")
+		}
+		fmt.Printf("\t\t\t%s: # of flows: %v, ACE capacity utilization(%%): %s\n",
+			title, bucket.Flows, bucket.Utilization)
+	}
+	for _, flow := range result.Samples {
 		fmt.Printf("\t\t\t %v\n", flow)
 	}
+	if result.Flows > uint64(len(result.Samples)) {
+		fmt.Printf("\t\t\t ... %v more flows\n", result.Flows-uint64(len(result.Samples)))
+	}
 
 	return nil
 }
@@ -1,7 +1,10 @@
 package ciscoasaaccessentry
 
 import (
+	"math"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
@@ -150,6 +153,29 @@ func Test_accessEntryCompiled_MatchFlow(t *testing.T) {
 			want:    false,
 			wantErr: false,
 		},
+		{
+			name: "no match: ACL-SCTP and SCTP-flow",
+			ace: &accessEntryCompiled{
+				action:         permit,
+				proto:          &network_entities.Protocol{Id: 132, Title: "sctp"},
+				src_addr_range: utils.AddressObject{Start: 0, Finish: 0xffffffff},
+				dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+				src_port_range: port_range{0, 0},
+				dst_port_range: port_range{3868, 3868},
+				icmp:           icmp_type_code{icmp_type: -1, icmp_code: -1},
+			},
+			args: args{
+				flow: network_entities.Flow{
+					Protocol: &network_entities.Protocol{Id: 132, Title: "sctp"},
+					Src_ip:   0x01020304,
+					Dst_ip:   0x0a0a0a0a,
+					Src_port: 1024,
+					Dst_port: 2905,
+				},
+			},
+			want:    false,
+			wantErr: false,
+		},
 		{
 			name: "no match: ACL-TCP and UDP-flow",
 			ace: &accessEntryCompiled{
@@ -346,6 +372,8 @@ func Test_accessEntryCompiled_getFlowsCapacity(t *testing.T) {
 	tests := []struct {
 		name    string
 		ace     *accessEntryCompiled
+		flows   []network_entities.Flow
+		options network_entities.StatsOptions
 		want    uint
 		wantErr bool
 	}{
@@ -358,16 +386,16 @@ func Test_accessEntryCompiled_getFlowsCapacity(t *testing.T) {
 				dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
 				src_port_range: port_range{0, 0},
 				dst_port_range: port_range{22, 22},
-				flows: []network_entities.Flow{
-					{
-						Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
-						Src_iface: "inside",
-						Dst_iface: "outside",
-						Src_ip:    0x0a0a0a0a,
-						Dst_ip:    0x0a0a0a0a,
-						Src_port:  1024,
-						Dst_port:  22,
-					},
+			},
+			flows: []network_entities.Flow{
+				{
+					Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
+					Src_iface: "inside",
+					Dst_iface: "outside",
+					Src_ip:    0x0a0a0a0a,
+					Dst_ip:    0x0a0a0a0a,
+					Src_port:  1024,
+					Dst_port:  22,
 				},
 			},
 			want:    1 * 1 * 1 * 1,
@@ -382,34 +410,76 @@ func Test_accessEntryCompiled_getFlowsCapacity(t *testing.T) {
 				dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
 				src_port_range: port_range{0, 0},
 				dst_port_range: port_range{22, 22},
-				flows: []network_entities.Flow{
-					{
-						Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
-						Src_iface: "inside",
-						Dst_iface: "outside",
-						Src_ip:    0x0a0a0a0a,
-						Dst_ip:    0x0a0a0a0a,
-						Src_port:  1024,
-						Dst_port:  22,
-					},
-					{
-						Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
-						Src_iface: "inside",
-						Dst_iface: "outside",
-						Src_ip:    0x0a0a0a0a,
-						Dst_ip:    0x0a0a0a0a,
-						Src_port:  1025,
-						Dst_port:  22,
-					},
+			},
+			flows: []network_entities.Flow{
+				{
+					Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
+					Src_iface: "inside",
+					Dst_iface: "outside",
+					Src_ip:    0x0a0a0a0a,
+					Dst_ip:    0x0a0a0a0a,
+					Src_port:  1024,
+					Dst_port:  22,
+				},
+				{
+					Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
+					Src_iface: "inside",
+					Dst_iface: "outside",
+					Src_ip:    0x0a0a0a0a,
+					Dst_ip:    0x0a0a0a0a,
+					Src_port:  1025,
+					Dst_port:  22,
 				},
 			},
 			want:    1 * 1 * 1 * 1,
 			wantErr: false,
 		},
+		{
+			name: "prefixes covering whole address space",
+			ace: &accessEntryCompiled{
+				action:         permit,
+				capacity_model: network_entities.CapacityModel{Any_src_address: network_entities.SpaceFull},
+				proto:          &network_entities.Protocol{Id: 6, Title: "tcp"},
+				src_addr_range: utils.AddressObject{Start: 0x0, Finish: 0xffffffff},
+				dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+				src_port_range: port_range{0, 0},
+				dst_port_range: port_range{22, 22},
+			},
+			flows: []network_entities.Flow{
+				{
+					Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
+					Src_iface: "outside",
+					Dst_iface: "inside",
+					Src_ip:    0x0a0a0a0a,
+					Dst_ip:    0x0a0a0a0a,
+					Src_port:  1024,
+					Dst_port:  22,
+				},
+				{
+					Protocol:  &network_entities.Protocol{Id: 6, Title: "tcp"},
+					Src_iface: "outside",
+					Dst_iface: "inside",
+					Src_ip:    0xc8c8c8c8,
+					Dst_ip:    0x0a0a0a0a,
+					Src_port:  1024,
+					Dst_port:  22,
+				},
+			},
+			options: network_entities.StatsOptions{Max_prefix_length: 1},
+			want:    1 << 32,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.ace.getFlowsCapacity()
+			tt.ace.m = &sync.Mutex{}
+			for _, flow := range tt.flows {
+				err := tt.ace.AddFlow(flow, tt.options)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := tt.ace.getFlowsCapacity(tt.ace.stats)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("accessEntryCompiled.getFlowsCapacity() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -420,3 +490,237 @@ func Test_accessEntryCompiled_getFlowsCapacity(t *testing.T) {
 		})
 	}
 }
+
+func Test_accessEntryCompiled_getDimensionsUtilization(t *testing.T) {
+	tcp := &network_entities.Protocol{Id: 6, Title: "tcp"}
+	icmp := &network_entities.Protocol{Id: 1, Title: "icmp"}
+
+	tests := []struct {
+		name  string
+		ace   *accessEntryCompiled
+		flows []network_entities.Flow
+		want  string
+	}{
+		{
+			name: "any source, wide destination subnet",
+			ace: &accessEntryCompiled{
+				action:         permit,
+				proto:          tcp,
+				src_addr_range: utils.AddressObject{Start: 0x0, Finish: 0xffffffff},
+				dst_addr_range: utils.AddressObject{Start: 0x0a0a0000, Finish: 0x0a0a00ff},
+				dst_port_range: port_range{443, 443},
+			},
+			flows: []network_entities.Flow{
+				{Protocol: tcp, Src_ip: 0x01010101, Dst_ip: 0x0a0a0001, Src_port: 1024, Dst_port: 443},
+				{Protocol: tcp, Src_ip: 0x02020202, Dst_ip: 0x0a0a0001, Src_port: 1025, Dst_port: 443},
+			},
+			want: "src ips 100.000, dst ips 0.391, dst ports 100.000",
+		},
+		{
+			name: "source ports restricted, destination ports omitted",
+			ace: &accessEntryCompiled{
+				action:         permit,
+				proto:          tcp,
+				src_addr_range: utils.AddressObject{Start: 0x0a000000, Finish: 0x0a000003},
+				dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+				src_port_range: port_range{1000, 1001},
+			},
+			flows: []network_entities.Flow{
+				{Protocol: tcp, Src_ip: 0x0a000001, Dst_ip: 0x0a0a0a0a, Src_port: 1000, Dst_port: 22},
+			},
+			want: "src ips 25.000, dst ips 100.000, src ports 50.000, dst ports 0.002",
+		},
+		{
+			name: "icmp",
+			ace: &accessEntryCompiled{
+				action:         permit,
+				proto:          icmp,
+				src_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+				dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0b},
+				icmp:           icmp_type_code{icmp_type: 8, icmp_code: -1},
+			},
+			flows: []network_entities.Flow{
+				{Protocol: icmp, Src_ip: 0x0a0a0a0a, Dst_ip: 0x0a0a0a0a, Icmp_type: 8, Icmp_code: 0},
+			},
+			want: "src ips 100.000, dst ips 50.000, icmp 0.391",
+		},
+		{
+			name: "no flows",
+			ace: &accessEntryCompiled{
+				action:         permit,
+				proto:          tcp,
+				src_addr_range: utils.AddressObject{Start: 0x0, Finish: 0xffffffff},
+				dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.ace.m = &sync.Mutex{}
+			for _, flow := range tt.flows {
+				err := tt.ace.AddFlow(flow, network_entities.StatsOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := tt.ace.getDimensionsUtilization(tt.ace.stats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if FormatDimensions(got) != tt.want {
+				t.Errorf("accessEntryCompiled.getDimensionsUtilization() = %q, want %q", FormatDimensions(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_accessEntryCompiled_getBytesWeightedUtilization(t *testing.T) {
+	tcp := &network_entities.Protocol{Id: 6, Title: "tcp"}
+	// --- 4 destination hosts, 25% each
+	ace := accessEntryCompiled{
+		action:         permit,
+		proto:          tcp,
+		src_addr_range: utils.AddressObject{Start: 0x0, Finish: 0xffffffff},
+		dst_addr_range: utils.AddressObject{Start: 0x0a0a0a00, Finish: 0x0a0a0a03},
+		dst_port_range: port_range{443, 443},
+	}
+	flow := func(dst_ip uint32, bytes uint64, duration time.Duration) network_entities.Flow {
+		return network_entities.Flow{Protocol: tcp, Src_ip: 0x01010101, Dst_ip: dst_ip, Src_port: 1024, Dst_port: 443,
+			Accounted: true, Bytes: bytes, Duration: duration}
+	}
+
+	tests := []struct {
+		name  string
+		flows []network_entities.Flow
+		want  float64
+	}{
+		{
+			name:  "1 MB/s connection counts in full",
+			flows: []network_entities.Flow{flow(0x0a0a0a00, 2e6, time.Second)},
+			want:  25,
+		},
+		{
+			name:  "same bytes over longer time count less",
+			flows: []network_entities.Flow{flow(0x0a0a0a00, 2e6, time.Hour)},
+			want:  25.0 * 1 / 3,
+		},
+		{
+			name:  "zero bytes connections don't count",
+			flows: []network_entities.Flow{flow(0x0a0a0a00, 0, time.Second), flow(0x0a0a0a01, 0, 30*time.Second)},
+			want:  0,
+		},
+		{
+			name: "flows without accounting count in full",
+			flows: []network_entities.Flow{
+				{Protocol: tcp, Src_ip: 0x01010101, Dst_ip: 0x0a0a0a00, Src_port: 1024, Dst_port: 443},
+				flow(0x0a0a0a01, 0, time.Second),
+			},
+			want: 25,
+		},
+		{
+			name:  "address counts by its busiest connection",
+			flows: []network_entities.Flow{flow(0x0a0a0a00, 10, time.Second), flow(0x0a0a0a00, 5000, time.Second), flow(0x0a0a0a01, 10, time.Second)},
+			want:  25.0*2/3 + 25.0*1/3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ace := ace
+			ace.m = &sync.Mutex{}
+			for _, flow := range tt.flows {
+				err := ace.AddFlow(flow, network_entities.StatsOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := ace.getBytesWeightedUtilization(4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got.Percent-tt.want) > 1e-9 {
+				t.Errorf("accessEntryCompiled.getBytesWeightedUtilization() = %v, want %v", got.Percent, tt.want)
+			}
+		})
+	}
+}
+
+func Test_accessEntryCompiled_capacityModel(t *testing.T) {
+	tcp := &network_entities.Protocol{Id: 6, Title: "tcp"}
+	icmp := &network_entities.Protocol{Id: 1, Title: "icmp"}
+	// --- tcp any -> host, ports omitted
+	tcp_any := accessEntryCompiled{
+		action:         permit,
+		proto:          tcp,
+		src_addr_range: utils.AddressObject{Start: 0x0, Finish: 0xffffffff},
+		dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+	}
+	tcp_flows := []network_entities.Flow{
+		{Protocol: tcp, Src_ip: 0x01010101, Dst_ip: 0x0a0a0a0a, Src_port: 1024, Dst_port: 443},
+		{Protocol: tcp, Src_ip: 0x02020202, Dst_ip: 0x0a0a0a0a, Src_port: 1025, Dst_port: 443},
+	}
+	icmp_host := accessEntryCompiled{
+		action:         permit,
+		proto:          icmp,
+		src_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+		dst_addr_range: utils.AddressObject{Start: 0x0a0a0a0a, Finish: 0x0a0a0a0a},
+		icmp:           icmp_type_code{icmp_type: -1, icmp_code: -1},
+	}
+	icmp_flows := []network_entities.Flow{
+		{Protocol: icmp, Src_ip: 0x0a0a0a0a, Dst_ip: 0x0a0a0a0a, Icmp_type: 8, Icmp_code: 0},
+	}
+
+	tests := []struct {
+		name                string
+		ace                 accessEntryCompiled
+		model               string
+		flows               []network_entities.Flow
+		want_capacity       uint
+		want_flows_capacity uint
+	}{
+		{name: "default", ace: tcp_any, model: "default", flows: tcp_flows, want_capacity: 1 * 1 * 65536, want_flows_capacity: 1 * 1 * 1},
+		{name: "any is fully open", ace: tcp_any, model: "any=full", flows: tcp_flows, want_capacity: 0x100000000 * 1 * 65536, want_flows_capacity: 2 * 1 * 1},
+		{name: "source any fully open", ace: tcp_any, model: "src-any=full", flows: tcp_flows, want_capacity: 0x100000000 * 1 * 65536, want_flows_capacity: 2 * 1 * 1},
+		{name: "destination any doesn't count source any", ace: tcp_any, model: "dst-any=full", flows: tcp_flows, want_capacity: 1 * 1 * 65536, want_flows_capacity: 1 * 1 * 1},
+		{name: "omitted ports not counted", ace: tcp_any, model: "dst-port=one", flows: tcp_flows, want_capacity: 1, want_flows_capacity: 1},
+		{name: "omitted source port fully open", ace: tcp_any, model: "src-port=full", flows: tcp_flows, want_capacity: 65536 * 65536, want_flows_capacity: 2 * 1},
+		{name: "any to any saturates", ace: accessEntryCompiled{action: permit, proto: tcp, src_addr_range: tcp_any.src_addr_range, dst_addr_range: tcp_any.src_addr_range}, model: "any=full", want_capacity: math.MaxUint},
+		{name: "icmp default", ace: icmp_host, model: "default", flows: icmp_flows, want_capacity: 256 * 256, want_flows_capacity: 1},
+		{name: "icmp omitted not counted", ace: icmp_host, model: "icmp=one", flows: icmp_flows, want_capacity: 1, want_flows_capacity: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := network_entities.ParseCapacityModel(tt.model, network_entities.CapacityModel{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ace := tt.ace
+			ace.capacity_model = model
+			ace.m = &sync.Mutex{}
+			for _, flow := range tt.flows {
+				err := ace.AddFlow(flow, network_entities.StatsOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := ace.getCapacity()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want_capacity {
+				t.Errorf("accessEntryCompiled.getCapacity() = %v, want %v", got, tt.want_capacity)
+			}
+			if tt.flows == nil {
+				return
+			}
+			got, err = ace.getFlowsCapacity(ace.stats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want_flows_capacity {
+				t.Errorf("accessEntryCompiled.getFlowsCapacity() = %v, want %v", got, tt.want_flows_capacity)
+			}
+		})
+	}
+}
@@ -7,19 +7,19 @@ import (
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 )
 
-func (a *AccessEntry) AddFlow(flow network_entities.Flow) (bool, error) {
+func (a *AccessEntry) AddFlow(flow network_entities.Flow, stats_options network_entities.StatsOptions) (bool, error) {
 	for i := range a.compiled {
 		is_match, err := a.compiled[i].MatchFlow(flow)
 		if err != nil {
 			return false, err
 		}
 
-		if utils.GetLogLevel() == utils.Trace {
-			fmt.Printf("\tis_match: %v\n\t\t%s\n\t\t%s\n", is_match, a.compiled[i], flow)
+		if utils.IsTrace() {
+			utils.Trace("ace match", "is_match", is_match, "ace", a.compiled[i].String(), "flow", flow.String())
 		}
 
 		if is_match {
-			err = a.compiled[i].AddFlow(flow)
+			err = a.compiled[i].AddFlow(flow, stats_options)
 			if err != nil {
 				return false, err
 			}
@@ -40,3 +40,14 @@ func (a *AccessEntry) Analyze() error {
 	}
 	return nil
 }
+
+// number of flows matched all compiled entries
+func (a *AccessEntry) GetFlows() uint64 {
+	var flows uint64
+	for i := range a.compiled {
+		a.compiled[i].m.Lock()
+		flows += a.compiled[i].getFlows()
+		a.compiled[i].m.Unlock()
+	}
+	return flows
+}
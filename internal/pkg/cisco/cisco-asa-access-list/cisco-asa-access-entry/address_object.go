@@ -4,7 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
@@ -18,14 +18,12 @@ func parseFQDN(fqdn string) ([]utils.AddressObject, error) {
 
 	ips, err := net.LookupIP(fqdn)
 	if err != nil {
-		error_string := "ERROR: failed to resolve " + fqdn
-		log.Print(error_string)
-		return nil, errors.New(error_string)
+		return nil, errors.New("failed to resolve " + fqdn)
 	}
 
 	for _, ip := range ips {
 		if ip.To4() == nil {
-			fmt.Printf("Skipping IPv6 address: %s in %s name resolution\n", ip.String(), fqdn)
+			slog.Warn("IPv6 address of name resolution is skipped", "fqdn", fqdn, "ip", ip.String())
 			continue
 		}
 		_address_object := utils.AddressObject{Start: binary.BigEndian.Uint32(ip.To4()), Finish: binary.BigEndian.Uint32(ip.To4())}
@@ -41,9 +39,7 @@ func parseAddressObjectContent(fields []string) ([]utils.AddressObject, error) {
 	switch fields[0] {
 	case "fqdn":
 		if len(fields) < 2 {
-			error_string := "ERROR: not enough fields to parse fqdn in address object"
-			log.Print(error_string, "(", fields, ")")
-			return nil, errors.New(error_string)
+			return nil, fmt.Errorf("not enough fields to parse fqdn in address object (fields: %v)", fields)
 		}
 
 		_ao, err := parseFQDN(fields[1])
@@ -54,9 +50,7 @@ func parseAddressObjectContent(fields []string) ([]utils.AddressObject, error) {
 		return _address_objects, nil
 	case "host":
 		if len(fields) < 2 {
-			error_string := "ERROR: not enough fields to parse host in address object"
-			log.Print(error_string, "(", fields, ")")
-			return nil, errors.New(error_string)
+			return nil, fmt.Errorf("not enough fields to parse host in address object (fields: %v)", fields)
 		}
 
 		ip, err := utils.ParseIP(fields[1])
@@ -68,9 +62,7 @@ func parseAddressObjectContent(fields []string) ([]utils.AddressObject, error) {
 		return _address_objects, nil
 	case "subnet":
 		if len(fields) < 3 {
-			error_string := "ERROR: not enough fields to parse subnet in address object"
-			log.Print(error_string, "(", fields, ")")
-			return nil, errors.New(error_string)
+			return nil, fmt.Errorf("not enough fields to parse subnet in address object (fields: %v)", fields)
 		}
 
 		_, _address_object, err := utils.ParseSubnet(1, fields)
@@ -82,9 +74,7 @@ func parseAddressObjectContent(fields []string) ([]utils.AddressObject, error) {
 		return _address_objects, nil
 	case "range":
 		if len(fields) < 3 {
-			error_string := "ERROR: not enough fields to parse range in address object"
-			log.Print(error_string, "(", fields, ")")
-			return nil, errors.New(error_string)
+			return nil, fmt.Errorf("not enough fields to parse range in address object (fields: %v)", fields)
 		}
 
 		start, err := utils.ParseIP(fields[1])
@@ -101,18 +91,14 @@ func parseAddressObjectContent(fields []string) ([]utils.AddressObject, error) {
 
 		return _address_objects, nil
 	default:
-		error_string := "ERROR: failed to parse address object"
-		log.Print(error_string, "(", fields, ")")
-		return nil, errors.New(error_string)
+		return nil, fmt.Errorf("failed to parse address object (fields: %v)", fields)
 	}
 }
 
-func parseAddressObject(name string) ([]utils.AddressObject, error) {
-	address_object_text := sh_run_pipe.SectionExact("object network " + name).Exclude("object network " + name).Exclude("description ").Exclude(" nat ")
+func parseAddressObject(sh_run sh_run_pipe.Text, name string) ([]utils.AddressObject, error) {
+	address_object_text := sh_run.SectionExact("object network " + name).Exclude("object network " + name).Exclude("description ").Exclude(" nat ")
 	if address_object_text.Len() != 1 {
-		error_message := "object network must have only 1 line in it. object network " + name + " is " + strconv.Itoa(int(address_object_text.Len())) + " lines."
-		log.Printf("ERROR: %s", error_message)
-		return nil, errors.New(error_message)
+		return nil, errors.New("object network must have only 1 line in it. object network " + name + " is " + strconv.Itoa(int(address_object_text.Len())) + " lines.")
 	}
 
 	address_object_line, err := address_object_text.Get(0)
@@ -124,14 +110,12 @@ func parseAddressObject(name string) ([]utils.AddressObject, error) {
 	return parseAddressObjectContent(fields)
 }
 
-func parseAddressObjectGroup(name string) ([]utils.AddressObject, error) {
+func parseAddressObjectGroup(sh_run sh_run_pipe.Text, name string) ([]utils.AddressObject, error) {
 	var address_object_group []utils.AddressObject
 
-	address_object_group_text := sh_run_pipe.SectionExact("object-group network " + name).Exclude("object-group network " + name).Exclude("description ")
+	address_object_group_text := sh_run.SectionExact("object-group network " + name).Exclude("object-group network " + name).Exclude("description ")
 	if address_object_group_text.Len() == 0 {
-		error_message := "object-group address " + name + " is empty"
-		log.Println("ERROR: ", error_message)
-		return nil, errors.New(error_message)
+		return nil, errors.New("object-group address " + name + " is empty")
 	}
 
 	for _, address_object_group_line := range address_object_group_text {
@@ -140,29 +124,23 @@ func parseAddressObjectGroup(name string) ([]utils.AddressObject, error) {
 		switch fields[0] {
 		case "network-object":
 			if len(fields) < 2 {
-				error_message := "address-object must have at least 2 fields in it. address-object " + name + " is " + strconv.Itoa(len(fields)) + " fields."
-				log.Printf("ERROR: %s", error_message)
-				return nil, errors.New(error_message)
+				return nil, errors.New("address-object must have at least 2 fields in it. address-object " + name + " is " + strconv.Itoa(len(fields)) + " fields.")
 			}
 
 			switch fields[1] {
 			case "object":
 				if len(fields) != 3 {
-					error_message := "address-object object must have 3 fields in it. address-object object " + name + " is " + strconv.Itoa(len(fields)) + " fields."
-					log.Printf("ERROR: %s", error_message)
-					return nil, errors.New(error_message)
+					return nil, errors.New("address-object object must have 3 fields in it. address-object object " + name + " is " + strconv.Itoa(len(fields)) + " fields.")
 				}
 
-				_ao, err := parseAddressObject(fields[2])
+				_ao, err := parseAddressObject(sh_run, fields[2])
 				if err != nil {
 					return nil, err
 				}
 				address_object_group = append(address_object_group, _ao...)
 			case "host":
 				if len(fields) != 3 {
-					error_message := "address-object host must have 3 fields in it. address-object host " + name + " is " + strconv.Itoa(len(fields)) + " fields."
-					log.Printf("ERROR: %s", error_message)
-					return nil, errors.New(error_message)
+					return nil, errors.New("address-object host must have 3 fields in it. address-object host " + name + " is " + strconv.Itoa(len(fields)) + " fields.")
 				}
 
 				_ao, err := parseAddressObjectContent(fields[1:])
@@ -172,9 +150,7 @@ func parseAddressObjectGroup(name string) ([]utils.AddressObject, error) {
 				address_object_group = append(address_object_group, _ao...)
 			default:
 				if len(fields) < 3 {
-					error_string := "ERROR: not enough fields to parse subnet in address object"
-					log.Print(error_string, "(", fields, ")")
-					return nil, errors.New(error_string)
+					return nil, fmt.Errorf("not enough fields to parse subnet in address object (fields: %v)", fields)
 				}
 
 				_, _ao, err := utils.ParseSubnet(1, fields)
@@ -186,39 +162,41 @@ func parseAddressObjectGroup(name string) ([]utils.AddressObject, error) {
 			}
 		case "group-object":
 			if len(fields) != 2 {
-				error_message := "group-object must have 2 fields in it. group-object " + name + " is " + strconv.Itoa(len(fields)) + " fields."
-				log.Printf("ERROR: %s", error_message)
-				return nil, errors.New(error_message)
+				return nil, errors.New("group-object must have 2 fields in it. group-object " + name + " is " + strconv.Itoa(len(fields)) + " fields.")
 			}
 
-			_aog, err := parseAddressObjectGroup(fields[1])
+			_aog, err := parseAddressObjectGroup(sh_run, fields[1])
 			if err != nil {
 				return nil, err
 			}
 			address_object_group = append(address_object_group, _aog...)
 
 		default:
-			error_message := "address-object-group must have only network-object or group-object in it. address-object-group " + name + " is " + fields[0] + "."
-			log.Printf("ERROR: %s", error_message)
-			return nil, errors.New(error_message)
+			return nil, errors.New("address-object-group must have only network-object or group-object in it. address-object-group " + name + " is " + fields[0] + ".")
 		}
 	}
 
 	return address_object_group, nil
 }
 
-func getAddressObjects(parsing_pos uint, fields []string) (uint, []utils.AddressObject, error) {
+// resolves "object network" or "object-group network" by name
+func GetAddressObjectsByName(sh_run sh_run_pipe.Text, name string) ([]utils.AddressObject, error) {
+	if sh_run.Exact("object-group network "+name).Len() > 0 {
+		return parseAddressObjectGroup(sh_run, name)
+	}
+	return parseAddressObject(sh_run, name)
+}
+
+func getAddressObjects(sh_run sh_run_pipe.Text, parsing_pos uint, fields []string) (uint, []utils.AddressObject, error) {
 	var address_objects []utils.AddressObject
 
 	switch fields[parsing_pos] {
 	case "object":
 		if len(fields) < int(parsing_pos+2) {
-			error_string := "ERROR: not enough fields to get address name"
-			log.Print(error_string, "(", fields, ")")
-			return 0, nil, errors.New(error_string)
+			return 0, nil, fmt.Errorf("not enough fields to get address name (fields: %v)", fields)
 		}
 		obj_name := fields[parsing_pos+1]
-		_address_object, err := parseAddressObject(obj_name)
+		_address_object, err := parseAddressObject(sh_run, obj_name)
 		if err != nil {
 			return 0, nil, err
 		}
@@ -229,11 +207,9 @@ func getAddressObjects(parsing_pos uint, fields []string) (uint, []utils.Address
 
 	case "object-group":
 		if len(fields) < int(parsing_pos+2) {
-			error_string := "ERROR: not enough fields to get network object-group name"
-			log.Print(error_string, "(", fields, ")")
-			return 0, nil, errors.New(error_string)
+			return 0, nil, fmt.Errorf("not enough fields to get network object-group name (fields: %v)", fields)
 		}
-		_address_objects, err := parseAddressObjectGroup(fields[parsing_pos+1])
+		_address_objects, err := parseAddressObjectGroup(sh_run, fields[parsing_pos+1])
 		if err != nil {
 			return 0, nil, err
 		}
@@ -265,19 +241,13 @@ func getAddressObjects(parsing_pos uint, fields []string) (uint, []utils.Address
 		parsing_pos += 2
 
 	case "any6":
-		error_string := "ERROR: any6 not implemented as an address object"
-		log.Print(error_string)
-		return 0, nil, errors.New(error_string)
+		return 0, nil, errors.New("any6 not implemented as an address object")
 
 	case "any6-any":
-		error_string := "ERROR: any6-any not implemented as an address object"
-		log.Print(error_string)
-		return 0, nil, errors.New(error_string)
+		return 0, nil, errors.New("any6-any not implemented as an address object")
 
 	case "interface":
-		error_string := "ERROR: interface not implemented as an address object"
-		log.Print(error_string)
-		return 0, nil, errors.New(error_string)
+		return 0, nil, errors.New("interface not implemented as an address object")
 
 	default:
 		var _address_object utils.AddressObject
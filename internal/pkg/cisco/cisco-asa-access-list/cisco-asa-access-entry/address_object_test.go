@@ -405,10 +405,10 @@ func Test_parseAddressObject(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseAddressObject(tt.args.name)
+			got, err := parseAddressObject(sh_run, tt.args.name)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseAddressObject() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -573,10 +573,10 @@ func Test_getAddressObjects(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, err := getAddressObjects(tt.args.parsing_pos, tt.args.fields)
+			got, got1, err := getAddressObjects(sh_run, tt.args.parsing_pos, tt.args.fields)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getAddressObjects() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -636,10 +636,10 @@ func Test_parseAddressObjectGroup(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseAddressObjectGroup(tt.args.name)
+			got, err := parseAddressObjectGroup(sh_run, tt.args.name)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseAddressObjectGroup() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,23 @@
+package ciscoasaaccessentry
+
+import (
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// first compiled entry matching the flow, flow is not credited to it
+func (a *AccessEntry) Explain(flow network_entities.Flow) (entry string, permitted bool, matched bool, err error) {
+	for i := range a.compiled {
+		is_match, err := a.compiled[i].MatchFlow(flow)
+		if err != nil {
+			return "", false, false, err
+		}
+		if is_match {
+			return a.compiled[i].String(), a.compiled[i].action == permit, true, nil
+		}
+	}
+	return "", false, false, nil
+}
+
+func (a *AccessEntry) Line() string {
+	return a.line
+}
@@ -0,0 +1,183 @@
+package ciscoasaaccessentry
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/hyperloglog"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+func (b *bitmap) add(idx uint16) {
+	b[idx/64] |= 1 << (idx % 64)
+}
+
+func (b *bitmap) count() int {
+	result := 0
+	for _, word := range b {
+		result += bits.OnesCount64(word)
+	}
+	return result
+}
+
+// space of fewest ranges of max_range ports covering ports of the bitmap,
+// every range starts at a port not covered yet and is clipped by finish of ACE ports.
+func (b *bitmap) rangeSpace(max_range uint16, finish port) uint {
+	var space uint
+	covered := -1
+	for _, p := range b.values() {
+		if int(p) <= covered {
+			continue
+		}
+		covered = min(int(p)+int(max_range)-1, int(finish))
+		space += uint(covered-int(p)) + 1
+	}
+	return space
+}
+
+func (s ipSet) add(ip uint32) {
+	block, ok := s[uint16(ip>>16)]
+	if !ok {
+		block = &bitmap{}
+		s[uint16(ip>>16)] = block
+	}
+	block.add(uint16(ip))
+}
+
+func (s ipSet) count() uint32 {
+	var result uint32
+	for _, block := range s {
+		result += uint32(block.count())
+	}
+	return result
+}
+
+func (s ipSketch) add(ip uint32) {
+	s.sketch.Add(uint64(ip))
+}
+
+func (s ipSketch) count() uint32 {
+	return uint32(s.sketch.Count())
+}
+
+func newIPCounter(hll_precision uint8) (ipCounter, error) {
+	if hll_precision == 0 {
+		return make(ipSet), nil
+	}
+
+	sketch, err := hyperloglog.New(hll_precision)
+	if err != nil {
+		return nil, err
+	}
+	return ipSketch{sketch: sketch}, nil
+}
+
+// space of prefixes of prefix_length (1..32) covering addresses of the set,
+// clipped by addr_range. Addresses in the set are within addr_range.
+func (s ipSet) prefixSpace(prefix_length uint8, addr_range utils.AddressObject) uint {
+	mask := ^uint32(0) << (32 - prefix_length)
+
+	var ips []uint32
+	if prefix_length <= 16 {
+		// --- all addresses of a block share the prefix, block is enough
+		for block := range s {
+			ips = append(ips, uint32(block)<<16)
+		}
+		sort.Slice(ips, func(i, j int) bool { return ips[i] < ips[j] })
+	} else {
+		ips = s.values()
+	}
+
+	var space uint
+	for i, ip := range ips {
+		prefix := ip & mask
+		if i > 0 && ips[i-1]&mask == prefix {
+			continue
+		}
+		start := max(prefix, addr_range.Start)
+		finish := min(prefix|^mask, addr_range.Finish)
+		space += uint(finish-start) + 1
+	}
+	return space
+}
+
+// values outside of -1..255 are not valid icmp type or code and not counted
+func (s *icmpSet) add(value int) {
+	if value < -1 || value > 255 {
+		return
+	}
+	idx := uint(value + 1)
+	s[idx/64] |= 1 << (idx % 64)
+}
+
+func (s *icmpSet) count() int {
+	result := 0
+	for _, word := range s {
+		result += bits.OnesCount64(word)
+	}
+	return result
+}
+
+// track_src_ips/track_dst_ips are false if ACE permits any address,
+// unique addresses are not needed to calculate its utilization
+func newFlowStats(track_src_ips, track_dst_ips bool, hll_precision uint8) (*flowStats, error) {
+	var err error
+	stats := flowStats{hll_precision: hll_precision}
+
+	if track_src_ips {
+		stats.src_ips, err = newIPCounter(hll_precision)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if track_dst_ips {
+		stats.dst_ips, err = newIPCounter(hll_precision)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &stats, nil
+}
+
+// relative error of capacity calculated from estimated unique addresses,
+// two standard errors per estimated dimension (~95% confidence)
+func (stats *flowStats) getErrorMargin() float64 {
+	if stats.hll_precision == 0 {
+		return 0
+	}
+
+	margin := 1.0
+	dimension_error := 2 * hyperloglog.RelativeError(stats.hll_precision)
+	if stats.src_ips != nil {
+		margin *= 1 + dimension_error
+	}
+	if stats.dst_ips != nil {
+		margin *= 1 + dimension_error
+	}
+	return margin - 1
+}
+
+func (stats *flowStats) add(flow network_entities.Flow) {
+	stats.flows++
+	stats.bytes += flow.Bytes
+	if !flow.Timestamp.IsZero() {
+		if stats.first_seen.IsZero() || flow.Timestamp.Before(stats.first_seen) {
+			stats.first_seen = flow.Timestamp
+		}
+		if flow.Timestamp.After(stats.last_seen) {
+			stats.last_seen = flow.Timestamp
+		}
+	}
+
+	if stats.src_ips != nil {
+		stats.src_ips.add(flow.Src_ip)
+	}
+	if stats.dst_ips != nil {
+		stats.dst_ips.add(flow.Dst_ip)
+	}
+	stats.src_ports.add(flow.Src_port)
+	stats.dst_ports.add(flow.Dst_port)
+	stats.icmp_types.add(flow.Icmp_type)
+	stats.icmp_codes.add(flow.Icmp_code)
+}
@@ -0,0 +1,173 @@
+package ciscoasaaccessentry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+func Test_flowStats_add(t *testing.T) {
+	tests := []struct {
+		name          string
+		flows         []network_entities.Flow
+		want_flows    uint64
+		want_bytes    uint64
+		want_src_ips  uint32
+		want_dst_ips  uint32
+		want_ports    port
+		want_icmp_typ int
+		hll_precision uint8
+	}{
+		{
+			name: "duplicates counted once",
+			flows: []network_entities.Flow{
+				{Src_ip: 0x0a000001, Dst_ip: 0x0a000002, Dst_port: 22, Bytes: 10, Icmp_type: -1},
+				{Src_ip: 0x0a000001, Dst_ip: 0x0a000002, Dst_port: 22, Bytes: 20, Icmp_type: -1},
+			},
+			want_flows:    2,
+			want_bytes:    30,
+			want_src_ips:  1,
+			want_dst_ips:  1,
+			want_ports:    1,
+			want_icmp_typ: 1,
+		},
+		{
+			name: "addresses in different /16",
+			flows: []network_entities.Flow{
+				{Src_ip: 0x0a000001, Dst_ip: 0x0a000002, Dst_port: 0, Icmp_type: 0},
+				{Src_ip: 0x0a010001, Dst_ip: 0x0a000002, Dst_port: 65535, Icmp_type: 255},
+				{Src_ip: 0xffffffff, Dst_ip: 0x0a000002, Dst_port: 65535, Icmp_type: -1},
+			},
+			want_flows:    3,
+			want_bytes:    0,
+			want_src_ips:  3,
+			want_dst_ips:  1,
+			want_ports:    2,
+			want_icmp_typ: 3,
+		},
+		{
+			name: "approximate, few addresses are exact",
+			flows: []network_entities.Flow{
+				{Src_ip: 0x0a000001, Dst_ip: 0x0a000002, Dst_port: 22, Icmp_type: -1},
+				{Src_ip: 0x0a000003, Dst_ip: 0x0a000002, Dst_port: 22, Icmp_type: -1},
+				{Src_ip: 0x0a010001, Dst_ip: 0x0a000002, Dst_port: 22, Icmp_type: -1},
+			},
+			want_flows:    3,
+			want_bytes:    0,
+			want_src_ips:  3,
+			want_dst_ips:  1,
+			want_ports:    1,
+			want_icmp_typ: 1,
+			hll_precision: 14,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := newFlowStats(true, true, tt.hll_precision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, flow := range tt.flows {
+				stats.add(flow)
+			}
+			if stats.flows != tt.want_flows || stats.bytes != tt.want_bytes {
+				t.Errorf("flowStats.add() flows, bytes = %v, %v, want %v, %v", stats.flows, stats.bytes, tt.want_flows, tt.want_bytes)
+			}
+			if got := stats.getFlowsUniqueSrcIPs(); got != tt.want_src_ips {
+				t.Errorf("flowStats.getFlowsUniqueSrcIPs() = %v, want %v", got, tt.want_src_ips)
+			}
+			if got := stats.getFlowsUniqueDstIPs(); got != tt.want_dst_ips {
+				t.Errorf("flowStats.getFlowsUniqueDstIPs() = %v, want %v", got, tt.want_dst_ips)
+			}
+			if got := stats.getFlowsUniqueDstPorts(); got != tt.want_ports {
+				t.Errorf("flowStats.getFlowsUniqueDstPorts() = %v, want %v", got, tt.want_ports)
+			}
+			if got := stats.getFlowsUniqueICMPTypes(); got != tt.want_icmp_typ {
+				t.Errorf("flowStats.getFlowsUniqueICMPTypes() = %v, want %v", got, tt.want_icmp_typ)
+			}
+		})
+	}
+}
+
+func Test_flowStats_getErrorMargin(t *testing.T) {
+	tests := []struct {
+		name          string
+		track_src_ips bool
+		track_dst_ips bool
+		hll_precision uint8
+		want          float64
+	}{
+		{name: "exact", track_src_ips: true, track_dst_ips: true, hll_precision: 0, want: 0},
+		{name: "any to any", track_src_ips: false, track_dst_ips: false, hll_precision: 14, want: 0},
+		{name: "one dimension", track_src_ips: true, track_dst_ips: false, hll_precision: 14, want: 0.01625},
+		{name: "two dimensions", track_src_ips: true, track_dst_ips: true, hll_precision: 14, want: 0.0327641},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := newFlowStats(tt.track_src_ips, tt.track_dst_ips, tt.hll_precision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stats.getErrorMargin(); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("flowStats.getErrorMargin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ipSet_prefixSpace(t *testing.T) {
+	net8 := utils.AddressObject{Start: 0x0a000000, Finish: 0x0affffff}
+	tests := []struct {
+		name          string
+		ips           []uint32
+		prefix_length uint8
+		addr_range    utils.AddressObject
+		want          uint
+	}{
+		{name: "hosts of one /24", ips: []uint32{0x0a010101, 0x0a010102, 0x0a0101fe}, prefix_length: 24, addr_range: net8, want: 256},
+		{name: "hosts scattered across /8", ips: []uint32{0x0a010101, 0x0a020101, 0x0afe0101}, prefix_length: 24, addr_range: net8, want: 3 * 256},
+		{name: "host prefixes", ips: []uint32{0x0a010101, 0x0a020101}, prefix_length: 32, addr_range: net8, want: 2},
+		{name: "blocks of /16 share /12", ips: []uint32{0x0a010101, 0x0a020101, 0x0a100101}, prefix_length: 12, addr_range: net8, want: 2 << 20},
+		{name: "clipped by entry", ips: []uint32{0x0a000001, 0x0a000002}, prefix_length: 16, addr_range: utils.AddressObject{Start: 0x0a000000, Finish: 0x0a000003}, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := make(ipSet)
+			for _, ip := range tt.ips {
+				set.add(ip)
+			}
+			if got := set.prefixSpace(tt.prefix_length, tt.addr_range); got != tt.want {
+				t.Errorf("ipSet.prefixSpace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bitmap_rangeSpace(t *testing.T) {
+	tests := []struct {
+		name      string
+		ports     []uint16
+		max_range uint16
+		finish    port
+		want      uint
+	}{
+		{name: "ports of one range", ports: []uint16{1024, 1500, 2047}, max_range: 1024, finish: 65535, want: 1024},
+		{name: "range starts at first port", ports: []uint16{1500, 2600}, max_range: 1024, finish: 65535, want: 2048},
+		{name: "ports far apart", ports: []uint16{22, 443, 8080}, max_range: 100, finish: 65535, want: 300},
+		{name: "clipped by entry", ports: []uint16{65000}, max_range: 1024, finish: 65535, want: 536},
+		{name: "single port ranges", ports: []uint16{22, 23}, max_range: 1, finish: 65535, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ports bitmap
+			for _, p := range tt.ports {
+				ports.add(p)
+			}
+			if got := ports.rangeSpace(tt.max_range, tt.finish); got != tt.want {
+				t.Errorf("bitmap.rangeSpace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
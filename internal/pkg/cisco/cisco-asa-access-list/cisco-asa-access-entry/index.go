@@ -0,0 +1,173 @@
+package ciscoasaaccessentry
+
+import (
+	"sort"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// nodes with more rules get index on destination address, smaller are scanned
+const subIndexThreshold = 32
+
+func newSegmentTree(ids []int32, get_range func(id int32) utils.AddressObject) *segmentTree {
+	tree := segmentTree{nodes: make(map[uint32]*segmentNode)}
+
+	tree.boundaries = append(tree.boundaries, 0, 1<<32)
+	for _, id := range ids {
+		r := get_range(id)
+		tree.boundaries = append(tree.boundaries, uint64(r.Start), uint64(r.Finish)+1)
+	}
+	sort.Slice(tree.boundaries, func(i, j int) bool {
+		return tree.boundaries[i] < tree.boundaries[j]
+	})
+	uniq := tree.boundaries[:1]
+	for _, b := range tree.boundaries[1:] {
+		if b != uniq[len(uniq)-1] {
+			uniq = append(uniq, b)
+		}
+	}
+	tree.boundaries = uniq
+
+	last := len(tree.boundaries) - 2
+	for _, id := range ids {
+		r := get_range(id)
+		from := tree.boundaryIdx(uint64(r.Start))
+		to := tree.boundaryIdx(uint64(r.Finish)+1) - 1
+		tree.insert(1, 0, last, from, to, id)
+	}
+
+	return &tree
+}
+
+func (tree *segmentTree) boundaryIdx(value uint64) int {
+	return sort.Search(len(tree.boundaries), func(i int) bool {
+		return tree.boundaries[i] >= value
+	})
+}
+
+func (tree *segmentTree) insert(node_idx uint32, lo, hi, from, to int, id int32) {
+	if from <= lo && hi <= to {
+		node, ok := tree.nodes[node_idx]
+		if !ok {
+			node = &segmentNode{}
+			tree.nodes[node_idx] = node
+		}
+		node.rules = append(node.rules, id)
+		return
+	}
+
+	mid := (lo + hi) / 2
+	if from <= mid {
+		tree.insert(node_idx*2, lo, mid, from, to, id)
+	}
+	if to > mid {
+		tree.insert(node_idx*2+1, mid+1, hi, from, to, id)
+	}
+}
+
+// calls visit for every node on the path to the point, these are all nodes with rules covering the point
+func (tree *segmentTree) visit(point uint32, visit func(node *segmentNode)) {
+	leaf := tree.boundaryIdx(uint64(point)+1) - 1
+	node_idx, lo, hi := uint32(1), 0, len(tree.boundaries)-2
+
+	for {
+		if node, ok := tree.nodes[node_idx]; ok {
+			visit(node)
+		}
+		if lo == hi {
+			return
+		}
+
+		mid := (lo + hi) / 2
+		if leaf <= mid {
+			node_idx, hi = node_idx*2, mid
+		} else {
+			node_idx, lo = node_idx*2+1, mid+1
+		}
+	}
+}
+
+func NewIndex(aces []AccessEntry) *Index {
+	var index Index
+	for i := range aces {
+		for j := range aces[i].compiled {
+			index.entries = append(index.entries, &aces[i].compiled[j])
+		}
+	}
+
+	ids := make([]int32, len(index.entries))
+	for i := range ids {
+		ids[i] = int32(i)
+	}
+	index.src = newSegmentTree(ids, func(id int32) utils.AddressObject {
+		return index.entries[id].src_addr_range
+	})
+
+	for _, node := range index.src.nodes {
+		if len(node.rules) > subIndexThreshold {
+			node.sub = newSegmentTree(node.rules, func(id int32) utils.AddressObject {
+				return index.entries[id].dst_addr_range
+			})
+		}
+	}
+
+	return &index
+}
+
+// returns id of the first entry matching the flow, -1 if none
+func (index *Index) firstMatch(flow network_entities.Flow) (int32, error) {
+	best := int32(-1)
+	var match_err error
+
+	// --- rules are in ACL order, nothing after current best can win
+	scan := func(rules []int32) {
+		for _, id := range rules {
+			if match_err != nil || (best >= 0 && id >= best) {
+				return
+			}
+			is_match, err := index.entries[id].MatchFlow(flow)
+			if err != nil {
+				match_err = err
+				return
+			}
+			if is_match {
+				best = id
+				return
+			}
+		}
+	}
+
+	index.src.visit(flow.Src_ip, func(node *segmentNode) {
+		if node.sub == nil {
+			scan(node.rules)
+			return
+		}
+		node.sub.visit(flow.Dst_ip, func(sub_node *segmentNode) {
+			scan(sub_node.rules)
+		})
+	})
+
+	return best, match_err
+}
+
+// adds flow to the first matching entry, same entry linear scan of the ACL finds
+func (index *Index) AddFlow(flow network_entities.Flow, stats_options network_entities.StatsOptions) (bool, error) {
+	id, err := index.firstMatch(flow)
+	if err != nil {
+		return false, err
+	}
+	if id < 0 {
+		return false, nil
+	}
+
+	if utils.IsTrace() {
+		utils.Trace("ace match", "ace", index.entries[id].String(), "flow", flow.String())
+	}
+
+	err = index.entries[id].AddFlow(flow, stats_options)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
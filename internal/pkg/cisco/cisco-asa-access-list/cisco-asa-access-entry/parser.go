@@ -1,44 +1,38 @@
 package ciscoasaaccessentry
 
 import (
-	"errors"
 	"fmt"
-	"log"
-	"strconv"
+	"io"
 	"strings"
+	"sync"
 
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 )
 
-func tryToIdentifyAndParseServiceInsideACE(src_dst string, parsing_pos uint, fields []string, service_objects []serviceObject) (uint, error) {
-	isItServiceHere, err := isServiceAtAPosition(parsing_pos, fields)
+func tryToIdentifyAndParseServiceInsideACE(sh_run sh_run_pipe.Text, src_dst string, parsing_pos uint, fields []string, service_objects []serviceObject) (uint, error) {
+	isItServiceHere, err := isServiceAtAPosition(sh_run, parsing_pos, fields)
 	if err != nil {
 		return 0, err
 	}
 	if isItServiceHere {
 		if service_objects != nil {
 			if len(service_objects) == 1 {
-				if service_objects[0].proto[0].Title == "tcp" || service_objects[0].proto[0].Title == "udp" || service_objects[0].proto[0].Title == "icmp" {
+				if service_objects[0].proto[0].Title == "tcp" || service_objects[0].proto[0].Title == "udp" || service_objects[0].proto[0].Title == "sctp" || service_objects[0].proto[0].Title == "icmp" {
 
-					parsing_pos, err = service_objects[0].parseTcpUdpIcmpServicesInTheMiddleOfAnACL(src_dst, parsing_pos, fields)
+					parsing_pos, err = service_objects[0].parseTcpUdpIcmpServicesInTheMiddleOfAnACL(sh_run, src_dst, parsing_pos, fields)
 					if err != nil {
 						return 0, err
 					}
 				} else {
-					error_message := "protocol is not tcp or udp"
-					log.Printf("ERROR: %s (%s) in %v\n", error_message, fields[2], fields)
-					return 0, errors.New(error_message)
+					return 0, fmt.Errorf("protocol is not tcp or udp (field: %v, fields: %v)", fields[2], fields)
 				}
 			} else {
-				error_message := "service object len is " + strconv.Itoa(len(service_objects)) + ", but expected to be 1."
-				log.Printf("ERROR: %s in %v\n", error_message, fields)
-				return 0, errors.New(error_message)
+				return 0, fmt.Errorf("service object len is %d, but expected to be 1. (fields: %v)", len(service_objects), fields)
 			}
 		} else {
-			error_message := "service object is nil, but expected to be not nil."
-			log.Printf("ERROR: %s in %v\n", error_message, fields)
-			return 0, errors.New(error_message)
+			return 0, fmt.Errorf("service object is nil, but expected to be not nil. (fields: %v)", fields)
 		}
 	}
 	return parsing_pos, nil
@@ -56,8 +50,7 @@ func getAction(action string) (action, error) {
 	case "deny":
 		return deny, nil
 	default:
-		log.Printf("unknown action (%s) in ace\n", action)
-		return 0, errors.New("unknown action")
+		return 0, fmt.Errorf("unknown action in ace (action: %v)", action)
 	}
 }
 
@@ -111,10 +104,15 @@ func (ace *AccessEntry) compileACE(act action, serviceObjects []serviceObject, s
 		}
 	}
 
+	// --- entries are copies of compiledEntry, every one of them gets its own mutex
+	for i := range ace.compiled {
+		ace.compiled[i].m = &sync.Mutex{}
+	}
+
 	return nil
 }
 
-func (ace *AccessEntry) parseExtended(fields []string) error {
+func (ace *AccessEntry) parseExtended(sh_run sh_run_pipe.Text, fields []string) error {
 	// --- action block
 	action, err := getAction(fields[3])
 	if err != nil {
@@ -122,27 +120,27 @@ func (ace *AccessEntry) parseExtended(fields []string) error {
 	}
 
 	// --- protocol and service object block
-	parsing_pos, service_objects, err := getProtocolOrServiceObject(4, fields)
+	parsing_pos, service_objects, err := getProtocolOrServiceObject(sh_run, 4, fields)
 	if err != nil {
 		return err
 	}
 
-	parsing_pos, src_address_objects, err := getAddressObjects(parsing_pos, fields)
+	parsing_pos, src_address_objects, err := getAddressObjects(sh_run, parsing_pos, fields)
 	if err != nil {
 		return err
 	}
 
-	parsing_pos, err = tryToIdentifyAndParseServiceInsideACE("src", parsing_pos, fields, service_objects)
+	parsing_pos, err = tryToIdentifyAndParseServiceInsideACE(sh_run, "src", parsing_pos, fields, service_objects)
 	if err != nil {
 		return err
 	}
 
-	parsing_pos, dst_address_objects, err := getAddressObjects(parsing_pos, fields)
+	parsing_pos, dst_address_objects, err := getAddressObjects(sh_run, parsing_pos, fields)
 	if err != nil {
 		return err
 	}
 
-	parsing_pos, err = tryToIdentifyAndParseServiceInsideACE("dst", parsing_pos, fields, service_objects)
+	parsing_pos, err = tryToIdentifyAndParseServiceInsideACE(sh_run, "dst", parsing_pos, fields, service_objects)
 	if err != nil {
 		return err
 	}
@@ -172,14 +170,14 @@ func (ace *AccessEntry) parseExtended(fields []string) error {
 	return nil
 }
 
-func (ace *AccessEntry) Print() {
-	fmt.Printf("ACE: %s\n", ace.line)
+func (ace *AccessEntry) Print(w io.Writer) {
+	fmt.Fprintf(w, "ACE: %s\n", ace.line)
 	for _, compiled := range ace.compiled {
-		fmt.Printf("  %s\n", compiled)
+		fmt.Fprintf(w, "  %s\n", compiled)
 	}
 }
 
-func Parse(ace_text string) (AccessEntry, error) {
+func Parse(sh_run sh_run_pipe.Text, ace_text string) (AccessEntry, error) {
 	var ace AccessEntry
 	ace.line = ace_text
 
@@ -187,15 +185,13 @@ func Parse(ace_text string) (AccessEntry, error) {
 
 	switch fields[2] {
 	case "extended":
-		err := ace.parseExtended(fields)
+		err := ace.parseExtended(sh_run, fields)
 		if err != nil {
 			return ace, err
 		}
 	case "remark":
 	default:
-		error_message := "ERROR: unknown ACE type"
-		log.Printf("%s (%s) in %s\n", error_message, fields[2], ace_text)
-		return ace, errors.New(error_message)
+		return ace, fmt.Errorf("unknown ACE type (field: %v, ace_text: %v)", fields[2], ace_text)
 	}
 
 	return ace, nil
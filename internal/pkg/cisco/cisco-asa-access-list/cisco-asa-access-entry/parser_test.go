@@ -2,6 +2,7 @@ package ciscoasaaccessentry
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 
 	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
@@ -42,6 +43,7 @@ func TestParse(t *testing.T) {
 							Finish: 0xffffffff,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -70,6 +72,7 @@ func TestParse(t *testing.T) {
 							Finish: 0xffffffff,
 						},
 						icmp: icmp_type_code{8, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -98,6 +101,7 @@ func TestParse(t *testing.T) {
 							Finish: 0xffffffff,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -126,6 +130,7 @@ func TestParse(t *testing.T) {
 							Finish: 0x0a0a0a01,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -162,6 +167,7 @@ func TestParse(t *testing.T) {
 							finish: 123,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -198,6 +204,7 @@ func TestParse(t *testing.T) {
 							finish: 21,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -234,6 +241,7 @@ func TestParse(t *testing.T) {
 							finish: 123,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 					{
 						action: 1,
@@ -258,6 +266,7 @@ func TestParse(t *testing.T) {
 							finish: 123,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -290,6 +299,7 @@ func TestParse(t *testing.T) {
 							finish: 19,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -322,6 +332,7 @@ func TestParse(t *testing.T) {
 							finish: 3306,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 					{
 						action: 1,
@@ -342,6 +353,7 @@ func TestParse(t *testing.T) {
 							finish: 20,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 					{
 						action: 1,
@@ -362,6 +374,7 @@ func TestParse(t *testing.T) {
 							finish: 21,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
@@ -390,6 +403,7 @@ func TestParse(t *testing.T) {
 							Finish: 0x0a0a0a01,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 					{
 						action: 1,
@@ -406,16 +420,17 @@ func TestParse(t *testing.T) {
 							Finish: 0x0a0a0a01,
 						},
 						icmp: icmp_type_code{-1, -1},
+						m:    &sync.Mutex{},
 					},
 				},
 			},
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Parse(tt.args.ace_text)
+			got, err := Parse(sh_run, tt.args.ace_text)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,91 @@
+package ciscoasaaccessentry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// share of ACE capacity used by flows, in percents
+type Utilization struct {
+	Percent float64
+	// --- ±Margin percents, only if unique addresses are estimated
+	Margin      float64
+	Approximate bool
+}
+
+// flows and utilization during a period, see network_entities.Bucket
+type BucketResult struct {
+	// --- zero for flows without timestamp
+	Start       time.Time
+	Flows       uint64
+	Utilization Utilization
+}
+
+// utilization of a single dimension: values seen in flows over values permitted by entry.
+// Dimension is one of "src ips", "dst ips", "src ports", "dst ports", "icmp".
+type DimensionUtilization struct {
+	Dimension   string
+	Space       uint
+	Flows_space uint
+	Utilization Utilization
+}
+
+// numbers of a single compiled entry
+type EntryResult struct {
+	Entry                      string
+	Permit                     bool
+	Capacity                   uint
+	Flows                      uint64
+	Bytes                      uint64
+	Flows_capacity             uint
+	Utilization                Utilization
+	Bytes_weighted_utilization Utilization
+	// --- breakdown of Utilization, empty if entry has no flows
+	Dimensions []DimensionUtilization
+	Buckets    []BucketResult
+	// --- unique addresses of flows, 0 if entry permits any address and capacity model doesn't count it
+	Unique_src_ips uint32
+	Unique_dst_ips uint32
+	// --- oldest and newest flows, zero if flows have no timestamp
+	First_seen time.Time
+	Last_seen  time.Time
+	// --- first flows matched the entry
+	Samples []network_entities.Flow
+}
+
+// ACE line from the config and its compiled entries
+type ACEResult struct {
+	Line    string
+	Entries []EntryResult
+}
+
+// must be set before flows are added: any address is counted only if model takes it as whole address space
+func (a *AccessEntry) SetCapacityModel(model network_entities.CapacityModel) {
+	for i := range a.compiled {
+		a.compiled[i].capacity_model = model
+	}
+}
+
+func (a *AccessEntry) GetResult() (ACEResult, error) {
+	result := ACEResult{Line: a.line}
+	for i := range a.compiled {
+		entry, err := a.compiled[i].getResult()
+		if err != nil {
+			return result, err
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	return result, nil
+}
+
+// ex: "src ips 100.000, dst ips 0.391, dst ports 100.000"
+func FormatDimensions(dims []DimensionUtilization) string {
+	parts := make([]string, 0, len(dims))
+	for _, dim := range dims {
+		parts = append(parts, fmt.Sprintf("%s %s", dim.Dimension, dim.Utilization))
+	}
+	return strings.Join(parts, ", ")
+}
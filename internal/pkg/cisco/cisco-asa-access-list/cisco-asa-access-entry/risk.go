@@ -0,0 +1,40 @@
+package ciscoasaaccessentry
+
+import "slices"
+
+// what a permit ACE opens regardless of flows seen, used to rank ACEs by risk
+type Exposure struct {
+	Any_source bool
+	// --- sensitive destination ports permitted, all of them for ip or omitted destination port
+	Sensitive_ports []uint16
+}
+
+func (compiled *accessEntryCompiled) permitsDstPort(p uint16) bool {
+	switch compiled.proto.Id {
+	case 4: // ip
+		return true
+	case 6, 17, 132: // tcp, udp, sctp
+		r := compiled.dst_port_range
+		return r.finish == 0 || (r.start <= port(p) && port(p) <= r.finish)
+	}
+	return false
+}
+
+// exposure of permit entries of the ACE, zero for deny ACE and remarks
+func (a *AccessEntry) Exposure(sensitive_ports []uint16) Exposure {
+	var exposure Exposure
+	for i := range a.compiled {
+		compiled := &a.compiled[i]
+		if compiled.action != permit {
+			continue
+		}
+		exposure.Any_source = exposure.Any_source || isAnyAddress(compiled.src_addr_range)
+		for _, p := range sensitive_ports {
+			if compiled.permitsDstPort(p) && !slices.Contains(exposure.Sensitive_ports, p) {
+				exposure.Sensitive_ports = append(exposure.Sensitive_ports, p)
+			}
+		}
+	}
+	slices.Sort(exposure.Sensitive_ports)
+	return exposure
+}
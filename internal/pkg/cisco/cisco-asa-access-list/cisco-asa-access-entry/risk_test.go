@@ -0,0 +1,66 @@
+package ciscoasaaccessentry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+func TestAccessEntry_Exposure(t *testing.T) {
+	tcp := network_entities.Protocols_map["tcp"]
+	ip := network_entities.Protocols_map["ip"]
+	icmp := network_entities.Protocols_map["icmp"]
+	any := utils.AddressObject{Start: 0, Finish: 0xffffffff}
+	host := utils.AddressObject{Start: 0x0a000001, Finish: 0x0a000001}
+	sensitive_ports := []uint16{22, 3389, 445, 1433}
+
+	tests := []struct {
+		name     string
+		compiled []accessEntryCompiled
+		want     Exposure
+	}{
+		{
+			name:     "rdp from any",
+			compiled: []accessEntryCompiled{{action: permit, proto: tcp, src_addr_range: any, dst_addr_range: host, dst_port_range: port_range{3389, 3389}}},
+			want:     Exposure{Any_source: true, Sensitive_ports: []uint16{3389}},
+		},
+		{
+			name: "object-group of ports",
+			compiled: []accessEntryCompiled{
+				{action: permit, proto: tcp, src_addr_range: host, dst_addr_range: host, dst_port_range: port_range{443, 445}},
+				{action: permit, proto: tcp, src_addr_range: host, dst_addr_range: host, dst_port_range: port_range{20, 23}},
+			},
+			want: Exposure{Sensitive_ports: []uint16{22, 445}},
+		},
+		{
+			name:     "omitted destination port",
+			compiled: []accessEntryCompiled{{action: permit, proto: tcp, src_addr_range: host, dst_addr_range: host}},
+			want:     Exposure{Sensitive_ports: []uint16{22, 445, 1433, 3389}},
+		},
+		{
+			name:     "ip",
+			compiled: []accessEntryCompiled{{action: permit, proto: ip, src_addr_range: any, dst_addr_range: host}},
+			want:     Exposure{Any_source: true, Sensitive_ports: []uint16{22, 445, 1433, 3389}},
+		},
+		{
+			name:     "icmp",
+			compiled: []accessEntryCompiled{{action: permit, proto: icmp, src_addr_range: host, dst_addr_range: host}},
+			want:     Exposure{},
+		},
+		{
+			name:     "deny",
+			compiled: []accessEntryCompiled{{action: deny, proto: ip, src_addr_range: any, dst_addr_range: any}},
+			want:     Exposure{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &AccessEntry{compiled: tt.compiled}
+			if got := a.Exposure(sensitive_ports); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AccessEntry.Exposure() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
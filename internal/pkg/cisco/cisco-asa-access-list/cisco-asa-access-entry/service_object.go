@@ -3,7 +3,7 @@ package ciscoasaaccessentry
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
 	"strings"
 
@@ -12,15 +12,13 @@ import (
 )
 
 // evaluate if service object tcp/udp/tcp-udp is at a position
-func isServiceAtAPositionTCPUDP(name string) (bool, error) {
-	service_object_text := sh_run_pipe.Include("object-group service " + name + " ")
+func isServiceAtAPositionTCPUDP(sh_run sh_run_pipe.Text, name string) (bool, error) {
+	service_object_text := sh_run.Include("object-group service " + name + " ")
 	if service_object_text.Len() == 0 {
 		return false, nil
 	}
 	if service_object_text.Len() != 1 {
-		error_message := "found " + strconv.Itoa(int(service_object_text.Len())) + " object-group services " + name + ", expected 1."
-		log.Printf("ERROR: %s", error_message)
-		return false, errors.New(error_message)
+		return false, errors.New("found " + strconv.Itoa(int(service_object_text.Len())) + " object-group services " + name + ", expected 1.")
 	}
 
 	s, err := service_object_text.Get(0)
@@ -30,9 +28,7 @@ func isServiceAtAPositionTCPUDP(name string) (bool, error) {
 
 	fields := strings.Fields(s)
 	if len(fields) < 4 {
-		error_message := "not enough tokens in object-group service " + name + ". Expected at least 4, got " + strconv.Itoa(len(fields)) + "."
-		log.Printf("ERROR: %s %v\n", error_message, fields)
-		return false, errors.New(error_message)
+		return false, fmt.Errorf("not enough tokens in object-group service %s. Expected at least 4, got %d. (fields: %v)", name, len(fields), fields)
 	}
 
 	proto := fields[3]
@@ -40,13 +36,11 @@ func isServiceAtAPositionTCPUDP(name string) (bool, error) {
 	case "tcp", "udp", "tcp-udp":
 		return true, nil
 	default:
-		error_message := "unexpected protocol " + proto + " in object-group service " + name + ". Expected tcp, udp or tcp-udp."
-		log.Printf("ERROR: %s %v\n", error_message, fields)
-		return false, errors.New(error_message)
+		return false, fmt.Errorf("unexpected protocol %s in object-group service %s. Expected tcp, udp or tcp-udp. (fields: %v)", proto, name, fields)
 	}
 }
 
-func isServiceAtAPosition(parsing_pos uint, fields []string) (bool, error) {
+func isServiceAtAPosition(sh_run sh_run_pipe.Text, parsing_pos uint, fields []string) (bool, error) {
 	if len(fields) <= int(parsing_pos) {
 		return false, nil
 	}
@@ -56,12 +50,10 @@ func isServiceAtAPosition(parsing_pos uint, fields []string) (bool, error) {
 		return true, nil
 	case "object-group":
 		if len(fields) <= (int(parsing_pos) + 1) {
-			error_message := "not enough tokens in object group "
-			log.Printf("ERROR: %s %v\n", error_message, fields)
-			return false, errors.New(error_message)
+			return false, fmt.Errorf("not enough tokens in object group (fields: %v)", fields)
 		}
 
-		return isServiceAtAPositionTCPUDP(fields[parsing_pos+1])
+		return isServiceAtAPositionTCPUDP(sh_run, fields[parsing_pos+1])
 	default:
 		if isIcmpTypeCodeAtAPosition(parsing_pos, fields) {
 			return true, nil
@@ -89,9 +81,7 @@ func parsePortRange(parsing_pos uint, fields []string, proto *network_entities.P
 
 				parsing_pos += 1
 			} else {
-				error_message := "not enough tokens in port range "
-				log.Printf("ERROR: %s %v\n", error_message, fields)
-				return 0, nil, errors.New(error_message)
+				return 0, nil, fmt.Errorf("not enough tokens in port range (fields: %v)", fields)
 			}
 		}
 	case "lt":
@@ -108,9 +98,7 @@ func parsePortRange(parsing_pos uint, fields []string, proto *network_entities.P
 
 				parsing_pos += 1
 			} else {
-				error_message := "not enough tokens in port range "
-				log.Printf("ERROR: %s %v\n", error_message, fields)
-				return 0, nil, errors.New(error_message)
+				return 0, nil, fmt.Errorf("not enough tokens in port range (fields: %v)", fields)
 			}
 		}
 	case "gt":
@@ -127,9 +115,7 @@ func parsePortRange(parsing_pos uint, fields []string, proto *network_entities.P
 
 				parsing_pos += 1
 			} else {
-				error_message := "not enough tokens in port range "
-				log.Printf("ERROR: %s %v\n", error_message, fields)
-				return 0, nil, errors.New(error_message)
+				return 0, nil, fmt.Errorf("not enough tokens in port range (fields: %v)", fields)
 			}
 		}
 	case "range":
@@ -150,9 +136,7 @@ func parsePortRange(parsing_pos uint, fields []string, proto *network_entities.P
 
 				parsing_pos += 2
 			} else {
-				error_message := "not enough tokens in port range "
-				log.Printf("ERROR: %s %v\n", error_message, fields)
-				return 0, nil, errors.New(error_message)
+				return 0, nil, fmt.Errorf("not enough tokens in port range (fields: %v)", fields)
 			}
 		}
 	case "neq":
@@ -169,9 +153,7 @@ func parsePortRange(parsing_pos uint, fields []string, proto *network_entities.P
 
 				parsing_pos += 1
 			} else {
-				error_message := "not enough tokens in port range "
-				log.Printf("ERROR: %s %v\n", error_message, fields)
-				return 0, nil, errors.New(error_message)
+				return 0, nil, fmt.Errorf("not enough tokens in port range (fields: %v)", fields)
 			}
 		}
 
@@ -180,11 +162,11 @@ func parsePortRange(parsing_pos uint, fields []string, proto *network_entities.P
 	return parsing_pos, _port_range, nil
 }
 
-func parsePortGroup(name string) ([]port_range, error) {
+func parsePortGroup(sh_run sh_run_pipe.Text, name string) ([]port_range, error) {
 	var _port_range []port_range
 
 	// get the object group
-	service_object_text := sh_run_pipe.Section("object-group service " + name + " ").Exclude("object-group service " + name).Exclude("description ")
+	service_object_text := sh_run.Section("object-group service " + name + " ").Exclude("object-group service " + name).Exclude("description ")
 
 	for i := uint(0); i < service_object_text.Len(); i++ {
 		service_object_line, err := service_object_text.Get(i)
@@ -194,9 +176,7 @@ func parsePortGroup(name string) ([]port_range, error) {
 
 		fields := strings.Fields(service_object_line)
 		if len(fields) < 2 {
-			error_message := "not enough tokens in port-object #" + strconv.Itoa(int(i)) + " of object-group service " + name + ""
-			log.Printf("ERROR: %s %v\n", error_message, fields)
-			return nil, errors.New(error_message)
+			return nil, fmt.Errorf("not enough tokens in port-object #%d of object-group service %s (fields: %v)", i, name, fields)
 		}
 
 		switch fields[0] {
@@ -208,16 +188,14 @@ func parsePortGroup(name string) ([]port_range, error) {
 
 			_port_range = append(_port_range, _pr...)
 		case "group-object":
-			_pr, err := parsePortGroup(fields[1])
+			_pr, err := parsePortGroup(sh_run, fields[1])
 			if err != nil {
 				return nil, err
 			}
 
 			_port_range = append(_port_range, _pr...)
 		default:
-			error_message := "unknown token(" + fields[0] + ") in object-group service " + name
-			log.Printf("ERROR: %s %v\n", error_message, fields)
-			return nil, errors.New(error_message)
+			return nil, fmt.Errorf("unknown token(%s) in object-group service %s (fields: %v)", fields[0], name, fields)
 		}
 	}
 	return _port_range, nil
@@ -295,7 +273,7 @@ func parseServiceObjectContent(fields []string) (*serviceObject, error) {
 	// --- it it is tcp-udp, then it will be a tcp
 	proto := protocols[0]
 
-	if ((proto.Title == "tcp") || (proto.Title == "udp")) && (len(fields) > 1) {
+	if ((proto.Title == "tcp") || (proto.Title == "udp") || (proto.Title == "sctp")) && (len(fields) > 1) {
 		var parsing_pos uint
 		parsing_pos = 1
 
@@ -347,13 +325,11 @@ func parseServiceObjectContent(fields []string) (*serviceObject, error) {
 	return &service_object, nil
 }
 
-func parseServiceObject(name string) (*serviceObject, error) {
+func parseServiceObject(sh_run sh_run_pipe.Text, name string) (*serviceObject, error) {
 
-	service_object_text := sh_run_pipe.SectionExact("object service " + name).Exclude("object service " + name).Exclude("description ")
+	service_object_text := sh_run.SectionExact("object service " + name).Exclude("object service " + name).Exclude("description ")
 	if service_object_text.Len() != 1 {
-		error_message := "object service must have only 1 line in it. object service " + name + " is " + strconv.Itoa(int(service_object_text.Len())) + " lines."
-		log.Printf("ERROR: %s", error_message)
-		return nil, errors.New(error_message)
+		return nil, errors.New("object service must have only 1 line in it. object service " + name + " is " + strconv.Itoa(int(service_object_text.Len())) + " lines.")
 	}
 	service_object_line, err := service_object_text.Get(0)
 	if err != nil {
@@ -363,37 +339,32 @@ func parseServiceObject(name string) (*serviceObject, error) {
 	fields := strings.Fields(service_object_line)
 
 	if fields[0] != "service" {
-		error_message := "first keyword in object service " + name + " must be \"service\" (" + service_object_line + ")"
-		log.Println("ERROR: ", error_message)
-		return nil, errors.New(error_message)
+		return nil, errors.New("first keyword in object service " + name + " must be \"service\" (" + service_object_line + ")")
 	}
 
 	return parseServiceObjectContent(fields[1:])
 }
 
-func isServiceObjectGroup(name string) bool {
-	service_object_group_text := sh_run_pipe.Exact("object-group service " + name)
+func isServiceObjectGroup(sh_run sh_run_pipe.Text, name string) bool {
+	service_object_group_text := sh_run.Exact("object-group service " + name)
 	switch service_object_group_text.Len() {
 	case 0:
 		return false
 	case 1:
 		return true
 	default:
-		error_message := "found " + strconv.Itoa(int(service_object_group_text.Len())) + " instances of object-group service " + name
-		log.Println("ERROR: ", error_message)
+		slog.Warn("multiple instances of object-group service", "name", name, "instances", service_object_group_text.Len())
 		return false
 	}
 }
 
 // parse "object-group service xxx"
-func parseServiceObjectGroup(name string) ([]serviceObject, error) {
+func parseServiceObjectGroup(sh_run sh_run_pipe.Text, name string) ([]serviceObject, error) {
 	var service_object_group []serviceObject
 
-	service_object_group_text := sh_run_pipe.SectionExact("object-group service " + name).Exclude("object-group service " + name).Exclude("description ")
+	service_object_group_text := sh_run.SectionExact("object-group service " + name).Exclude("object-group service " + name).Exclude("description ")
 	if service_object_group_text.Len() == 0 {
-		error_message := "object-group service " + name + " is empty"
-		log.Println("ERROR: ", error_message)
-		return nil, errors.New(error_message)
+		return nil, errors.New("object-group service " + name + " is empty")
 	}
 
 	for _, service_object_group_line := range service_object_group_text {
@@ -402,21 +373,17 @@ func parseServiceObjectGroup(name string) ([]serviceObject, error) {
 		switch fields[0] {
 		case "service-object":
 			if len(fields) < 2 {
-				error_message := "service-object must have at least 2 fields in it. service-object " + name + " is " + strconv.Itoa(len(fields)) + " fields."
-				log.Printf("ERROR: %s", error_message)
-				return nil, errors.New(error_message)
+				return nil, errors.New("service-object must have at least 2 fields in it. service-object " + name + " is " + strconv.Itoa(len(fields)) + " fields.")
 			}
 
 			switch fields[1] {
 			case "object":
 				{
 					if len(fields) != 3 {
-						error_message := "service-object object must have 3 fields in it. service-object object " + name + " is " + strconv.Itoa(len(fields)) + " fields."
-						log.Printf("ERROR: %s", error_message)
-						return nil, errors.New(error_message)
+						return nil, errors.New("service-object object must have 3 fields in it. service-object object " + name + " is " + strconv.Itoa(len(fields)) + " fields.")
 					}
 
-					_so, err := parseServiceObject(fields[2])
+					_so, err := parseServiceObject(sh_run, fields[2])
 					if err != nil {
 						return nil, err
 					}
@@ -432,15 +399,13 @@ func parseServiceObjectGroup(name string) ([]serviceObject, error) {
 				}
 			}
 		case "group-object":
-			_so_slice, err := parseServiceObjectGroup(fields[1])
+			_so_slice, err := parseServiceObjectGroup(sh_run, fields[1])
 			if err != nil {
 				return nil, err
 			}
 			service_object_group = append(service_object_group, _so_slice...)
 		default:
-			error_message := "first keyword in object-group service " + name + " must be \"service-object\" or \"group-object\" (" + service_object_group_line + ")"
-			log.Println("ERROR: ", error_message)
-			return nil, errors.New(error_message)
+			return nil, errors.New("first keyword in object-group service " + name + " must be \"service-object\" or \"group-object\" (" + service_object_group_line + ")")
 		}
 	}
 
@@ -451,12 +416,12 @@ func parseServiceObjectGroup(name string) ([]serviceObject, error) {
 // access-list xxx extended permit --> tcp <-- object-group xxx object-group xxx
 // access-list xxx extended permit --> object xxx <-- object-group xxx object-group xxx
 // access-list xxx extended permit --> object-group xxx <-- object-group xxx object-group xxx
-func getProtocolOrServiceObject(parsing_pos uint, fields []string) (uint, []serviceObject, error) {
+func getProtocolOrServiceObject(sh_run sh_run_pipe.Text, parsing_pos uint, fields []string) (uint, []serviceObject, error) {
 	var service_objects []serviceObject
 
 	switch fields[parsing_pos] {
 	case "object":
-		_service_object, err := parseServiceObject(fields[parsing_pos+1])
+		_service_object, err := parseServiceObject(sh_run, fields[parsing_pos+1])
 		if err != nil {
 			return 0, nil, err
 		}
@@ -467,14 +432,12 @@ func getProtocolOrServiceObject(parsing_pos uint, fields []string) (uint, []serv
 
 	case "object-group":
 		if len(fields) < int(parsing_pos+2) {
-			error_message := "object-group must have at least 2 additional fields in it. object-group is " + strconv.Itoa(len(fields)) + " fields."
-			log.Printf("ERROR: %s", error_message)
-			return 0, nil, errors.New(error_message)
+			return 0, nil, errors.New("object-group must have at least 2 additional fields in it. object-group is " + strconv.Itoa(len(fields)) + " fields.")
 		}
 
 		switch {
-		case isServiceObjectGroup(fields[parsing_pos+1]):
-			_service_objects, err := parseServiceObjectGroup(fields[parsing_pos+1])
+		case isServiceObjectGroup(sh_run, fields[parsing_pos+1]):
+			_service_objects, err := parseServiceObjectGroup(sh_run, fields[parsing_pos+1])
 			if err != nil {
 				return 0, nil, err
 			}
@@ -483,8 +446,8 @@ func getProtocolOrServiceObject(parsing_pos uint, fields []string) (uint, []serv
 			// set parsing position to a next block
 			parsing_pos += 2
 
-		case isProtocolObjectGroup(fields[parsing_pos+1]):
-			_service_object, err := parseProtocolObjectGroup(fields[parsing_pos+1])
+		case isProtocolObjectGroup(sh_run, fields[parsing_pos+1]):
+			_service_object, err := parseProtocolObjectGroup(sh_run, fields[parsing_pos+1])
 			if err != nil {
 				return 0, nil, err
 			}
@@ -494,9 +457,7 @@ func getProtocolOrServiceObject(parsing_pos uint, fields []string) (uint, []serv
 			parsing_pos += 2
 
 		default:
-			error_message := "unknown type of object-group " + fields[parsing_pos+1]
-			log.Printf("ERROR: %s", error_message)
-			return 0, nil, errors.New(error_message)
+			return 0, nil, errors.New("unknown type of object-group " + fields[parsing_pos+1])
 		}
 
 	default:
@@ -524,26 +485,24 @@ func (so *serviceObject) print() {
 	s += fmt.Sprintf("src_port_range: %v ", so.src_port_range)
 	s += fmt.Sprintf("dst_port_range: %v ", so.dst_port_range)
 	s += fmt.Sprintf("icmp: %v ", so.icmp)
-	log.Print(s)
+	slog.Debug(s)
 }
 
 // parse protocol id or service object in the middle and at the end of the ACE.
 // access-list xxx extended permit tcp              object-group xxx --> eq 80 <--       object-group xxx --> eq 80 <--
 // access-list xxx extended permit object xxx       object-group xxx --> range 80 81 <-- object-group xxx --> object-group some-ports <--
 // access-list xxx extended permit object-group xxx object-group xxx                     object-group xxx --> neq 80 <--
-func (so *serviceObject) parseTcpUdpIcmpServicesInTheMiddleOfAnACL(src_dst string, parsing_pos uint, fields []string) (uint, error) {
+func (so *serviceObject) parseTcpUdpIcmpServicesInTheMiddleOfAnACL(sh_run sh_run_pipe.Text, src_dst string, parsing_pos uint, fields []string) (uint, error) {
 	var _pr []port_range
 	var _icmp []icmp_type_code
 	var err error
 
 	if so.proto == nil {
-		error_message := "tcp/udp/icmp service must be preceded by a protocol"
-		log.Println("ERROR: ", error_message)
-		return 0, errors.New(error_message)
+		return 0, errors.New("tcp/udp/icmp service must be preceded by a protocol")
 	}
 
 	switch so.proto[0].Title {
-	case "tcp", "udp":
+	case "tcp", "udp", "sctp":
 		switch fields[parsing_pos] {
 		case "eq", "lt", "gt", "range", "neq":
 			parsing_pos, _pr, err = parsePortRange(parsing_pos, fields, nil)
@@ -559,7 +518,7 @@ func (so *serviceObject) parseTcpUdpIcmpServicesInTheMiddleOfAnACL(src_dst strin
 
 			return parsing_pos, nil
 		case "object-group":
-			_pr, err = parsePortGroup(fields[parsing_pos+1])
+			_pr, err = parsePortGroup(sh_run, fields[parsing_pos+1])
 			if err != nil {
 				return 0, err
 			}
@@ -571,16 +530,12 @@ func (so *serviceObject) parseTcpUdpIcmpServicesInTheMiddleOfAnACL(src_dst strin
 			case "dst":
 				so.dst_port_range = append(so.dst_port_range, _pr...)
 			default:
-				error_message := "tcp/udp service must be src or dst"
-				log.Println("ERROR: ", error_message)
-				return 0, errors.New(error_message)
+				return 0, errors.New("tcp/udp service must be src or dst")
 			}
 
 			return parsing_pos, nil
 		default:
-			error_message := "tcp/udp/icmp service must be eq, lt, gt, range, neq, or object-group (" + fields[parsing_pos] + ")"
-			log.Println("ERROR: ", error_message)
-			return 0, errors.New(error_message)
+			return 0, errors.New("tcp/udp/icmp service must be eq, lt, gt, range, neq, or object-group (" + fields[parsing_pos] + ")")
 		}
 	case "icmp":
 		if src_dst == "dst" {
@@ -593,13 +548,9 @@ func (so *serviceObject) parseTcpUdpIcmpServicesInTheMiddleOfAnACL(src_dst strin
 
 			return parsing_pos, nil
 		} else {
-			error_message := "icmp service must be dst only"
-			log.Printf("ERROR: %s (%v)", error_message, fields)
-			return 0, errors.New(error_message)
+			return 0, fmt.Errorf("icmp service must be dst only (fields: %v)", fields)
 		}
 	default:
-		error_message := "unknonwn so.proto[0] " + so.proto[0].Title
-		log.Println("ERROR: ", error_message)
-		return 0, errors.New(error_message)
+		return 0, errors.New("unknonwn so.proto[0] " + so.proto[0].Title)
 	}
 }
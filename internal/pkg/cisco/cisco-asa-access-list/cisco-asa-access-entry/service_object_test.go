@@ -110,10 +110,10 @@ func Test_isServiceAtAPosition(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := isServiceAtAPosition(tt.args.parsing_pos, tt.args.fields)
+			got, err := isServiceAtAPosition(sh_run, tt.args.parsing_pos, tt.args.fields)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("isServiceAtAPosition() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -176,10 +176,10 @@ func Test_isServiceAtAPositionTCPUDP(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := isServiceAtAPositionTCPUDP(tt.args.name)
+			got, err := isServiceAtAPositionTCPUDP(sh_run, tt.args.name)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("isServiceAtAPositionTCPUDP() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -456,10 +456,10 @@ func Test_parsePortGroup(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parsePortGroup(tt.args.name)
+			got, err := parsePortGroup(sh_run, tt.args.name)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parsePortGroup() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -727,10 +727,10 @@ func Test_parseServiceObject(t *testing.T) {
 			wantErr: true,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseServiceObject(tt.args.name)
+			got, err := parseServiceObject(sh_run, tt.args.name)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseServiceObject() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -828,10 +828,10 @@ func Test_parseServiceObjectGroup(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseServiceObjectGroup(tt.args.name)
+			got, err := parseServiceObjectGroup(sh_run, tt.args.name)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseServiceObjectGroup() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -964,10 +964,10 @@ func Test_getProtocolOrServiceObject(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, err := getProtocolOrServiceObject(tt.args.parsing_pos, tt.args.fields)
+			got, got1, err := getProtocolOrServiceObject(sh_run, tt.args.parsing_pos, tt.args.fields)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getProtocolOrServiceObject() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,82 @@
+package ciscoasaaccessentry
+
+import (
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+func addrCover(a, b utils.AddressObject) bool {
+	return a.Start <= b.Start && b.Finish <= a.Finish
+}
+
+// finish 0 is "port not given", it covers any port
+func portsCover(a, b port_range) bool {
+	if a.finish == 0 {
+		return true
+	}
+	if b.finish == 0 {
+		return false
+	}
+	return a.start <= b.start && b.finish <= a.finish
+}
+
+// true if every flow matching other matches compiled as well
+func (compiled *accessEntryCompiled) covers(other *accessEntryCompiled) bool {
+	if compiled.proto.Id != 4 && !compiled.proto.ExactMatch(other.proto) {
+		return false
+	}
+	if !addrCover(compiled.src_addr_range, other.src_addr_range) || !addrCover(compiled.dst_addr_range, other.dst_addr_range) {
+		return false
+	}
+
+	switch compiled.proto.Id {
+	case 6, 17, 132: // tcp, udp, sctp
+		return portsCover(compiled.src_port_range, other.src_port_range) && portsCover(compiled.dst_port_range, other.dst_port_range)
+	case 1: // icmp
+		if compiled.icmp.icmp_type == -1 {
+			return true
+		}
+		if compiled.icmp.icmp_type != other.icmp.icmp_type {
+			return false
+		}
+		return compiled.icmp.icmp_code == -1 || compiled.icmp.icmp_code == other.icmp.icmp_code
+	}
+	return true
+}
+
+// ACE is shadowed if every its compiled entry is covered by an entry of earlier ACEs, so it never matches.
+// Returns lines of covering ACEs in ACL order, conflicting is set if any of them has another action.
+func (a *AccessEntry) ShadowedBy(earlier []AccessEntry) (lines []string, conflicting bool) {
+	if len(a.compiled) == 0 {
+		return nil, false
+	}
+
+	used := make([]bool, len(earlier))
+	for i := range a.compiled {
+		covered := false
+		for j := range earlier {
+			for k := range earlier[j].compiled {
+				if earlier[j].compiled[k].covers(&a.compiled[i]) {
+					covered = true
+					used[j] = true
+					if earlier[j].compiled[k].action != a.compiled[i].action {
+						conflicting = true
+					}
+					break
+				}
+			}
+			if covered {
+				break
+			}
+		}
+		if !covered {
+			return nil, false
+		}
+	}
+
+	for j := range earlier {
+		if used[j] {
+			lines = append(lines, earlier[j].line)
+		}
+	}
+	return lines, conflicting
+}
@@ -0,0 +1,91 @@
+package ciscoasaaccessentry
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// a flow matching covered entry must match covering one
+func Test_covers_random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	covered := 0
+	for i := 0; i < 200; i++ {
+		aces := randomACL(r, 20)
+		for _, a := range aces {
+			for _, b := range aces {
+				if !a.compiled[0].covers(&b.compiled[0]) {
+					continue
+				}
+				covered++
+				for j := 0; j < 50; j++ {
+					flow := randomFlow(r)
+					match_b, _ := b.compiled[0].MatchFlow(flow)
+					match_a, _ := a.compiled[0].MatchFlow(flow)
+					if match_b && !match_a {
+						t.Fatalf("%v covers %v, but doesn't match %v", a.compiled[0], b.compiled[0], flow)
+					}
+				}
+			}
+		}
+	}
+	if covered == 0 {
+		t.Fatal("no covering entries generated")
+	}
+}
+
+func TestAccessEntry_ShadowedBy(t *testing.T) {
+	tcp := network_entities.Protocols_map["tcp"]
+	ip := network_entities.Protocols_map["ip"]
+	any := utils.AddressObject{Start: 0, Finish: 0xffffffff}
+	host := utils.AddressObject{Start: 0x0a000001, Finish: 0x0a000001}
+	no_icmp := icmp_type_code{icmp_type: -1, icmp_code: -1}
+	https := port_range{start: 443, finish: 443}
+
+	entry := func(line string, compiled ...accessEntryCompiled) AccessEntry {
+		return AccessEntry{line: line, compiled: compiled}
+	}
+	permit_ip_any := entry("permit ip any any", accessEntryCompiled{action: permit, proto: ip, src_addr_range: any, dst_addr_range: any, icmp: no_icmp})
+	permit_https := entry("permit tcp any any eq 443", accessEntryCompiled{action: permit, proto: tcp, src_addr_range: any, dst_addr_range: any, dst_port_range: https, icmp: no_icmp})
+	deny_host_https := entry("deny tcp host 10.0.0.1 any eq 443", accessEntryCompiled{action: deny, proto: tcp, src_addr_range: host, dst_addr_range: any, dst_port_range: https, icmp: no_icmp})
+	permit_host_tcp := entry("permit tcp host 10.0.0.1 any", accessEntryCompiled{action: permit, proto: tcp, src_addr_range: host, dst_addr_range: any, icmp: no_icmp})
+
+	tests := []struct {
+		name            string
+		ace             AccessEntry
+		earlier         []AccessEntry
+		wantLines       []string
+		wantConflicting bool
+	}{
+		{name: "first entry", ace: permit_https},
+		{name: "narrower after wider", ace: permit_https, earlier: []AccessEntry{permit_ip_any}, wantLines: []string{"permit ip any any"}},
+		{name: "deny after permit", ace: deny_host_https, earlier: []AccessEntry{permit_https}, wantLines: []string{"permit tcp any any eq 443"}, wantConflicting: true},
+		{name: "wider after narrower", ace: permit_host_tcp, earlier: []AccessEntry{permit_https}},
+		{
+			name:      "compiled entries covered by different ACEs",
+			ace:       entry("object-group", permit_https.compiled[0], permit_host_tcp.compiled[0]),
+			earlier:   []AccessEntry{permit_host_tcp, permit_https},
+			wantLines: []string{"permit tcp host 10.0.0.1 any", "permit tcp any any eq 443"},
+		},
+		{
+			name:    "one compiled entry not covered",
+			ace:     entry("object-group", permit_https.compiled[0], permit_host_tcp.compiled[0]),
+			earlier: []AccessEntry{permit_https},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, conflicting := tt.ace.ShadowedBy(tt.earlier)
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("ShadowedBy() lines = %v, want %v", lines, tt.wantLines)
+			}
+			if conflicting != tt.wantConflicting {
+				t.Errorf("ShadowedBy() conflicting = %v, want %v", conflicting, tt.wantConflicting)
+			}
+		})
+	}
+}
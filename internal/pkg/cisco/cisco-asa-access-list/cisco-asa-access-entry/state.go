@@ -0,0 +1,283 @@
+package ciscoasaaccessentry
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// aggregates of flowStats saved between runs
+type StatsState struct {
+	Flows      uint64
+	Bytes      uint64
+	First_seen time.Time
+	Last_seen  time.Time
+
+	// --- exact sets are saved as addresses, estimates as hyperloglog registers
+	Src_ips        []uint32 `json:",omitempty"`
+	Dst_ips        []uint32 `json:",omitempty"`
+	Src_ips_sketch []uint8  `json:",omitempty"`
+	Dst_ips_sketch []uint8  `json:",omitempty"`
+
+	Src_ports  []uint16 `json:",omitempty"`
+	Dst_ports  []uint16 `json:",omitempty"`
+	Icmp_types []int    `json:",omitempty"`
+	Icmp_codes []int    `json:",omitempty"`
+}
+
+type BucketState struct {
+	Start time.Time
+	Stats StatsState
+}
+
+// aggregates of a compiled entry saved between runs
+type EntryState struct {
+	Stats StatsState
+	// --- by rate class, zero flows if class has none
+	Rate_stats []StatsState            `json:",omitempty"`
+	Buckets    []BucketState           `json:",omitempty"`
+	Samples    []network_entities.Flow `json:",omitempty"`
+}
+
+// ACE text with spaces normalized, key of the state.
+// ACE keeps its state if moved to another line, edited ACE starts from scratch.
+func StateKey(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+func (b *bitmap) values() []uint16 {
+	var result []uint16
+	for i, word := range b {
+		for bit := 0; word != 0; bit++ {
+			if word&1 != 0 {
+				result = append(result, uint16(i*64+bit))
+			}
+			word >>= 1
+		}
+	}
+	return result
+}
+
+func (s *icmpSet) values() []int {
+	var result []int
+	for i, word := range s {
+		for bit := 0; word != 0; bit++ {
+			if word&1 != 0 {
+				result = append(result, i*64+bit-1)
+			}
+			word >>= 1
+		}
+	}
+	return result
+}
+
+func (s ipSet) values() []uint32 {
+	blocks := make([]uint16, 0, len(s))
+	for block := range s {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+
+	var result []uint32
+	for _, block := range blocks {
+		for _, host := range s[block].values() {
+			result = append(result, uint32(block)<<16|uint32(host))
+		}
+	}
+	return result
+}
+
+func saveIPCounter(counter ipCounter) (ips []uint32, sketch []uint8) {
+	switch c := counter.(type) {
+	case ipSet:
+		return c.values(), nil
+	case ipSketch:
+		return nil, c.sketch.Registers()
+	}
+	return nil, nil
+}
+
+func loadIPCounter(counter ipCounter, ips []uint32, sketch []uint8) error {
+	switch c := counter.(type) {
+	case ipSet:
+		if sketch != nil {
+			return errors.New("state has estimated addresses, exact count requested")
+		}
+		for _, ip := range ips {
+			c.add(ip)
+		}
+	case ipSketch:
+		if ips != nil {
+			return errors.New("state has exact addresses, estimate requested")
+		}
+		if sketch != nil {
+			return c.sketch.Merge(sketch)
+		}
+	}
+	return nil
+}
+
+func (stats *flowStats) saveState() StatsState {
+	state := StatsState{
+		Flows:      stats.flows,
+		Bytes:      stats.bytes,
+		First_seen: stats.first_seen,
+		Last_seen:  stats.last_seen,
+		Src_ports:  stats.src_ports.values(),
+		Dst_ports:  stats.dst_ports.values(),
+		Icmp_types: stats.icmp_types.values(),
+		Icmp_codes: stats.icmp_codes.values(),
+	}
+	state.Src_ips, state.Src_ips_sketch = saveIPCounter(stats.src_ips)
+	state.Dst_ips, state.Dst_ips_sketch = saveIPCounter(stats.dst_ips)
+	return state
+}
+
+func (stats *flowStats) loadState(state StatsState) error {
+	stats.flows = state.Flows
+	stats.bytes = state.Bytes
+	stats.first_seen = state.First_seen
+	stats.last_seen = state.Last_seen
+
+	err := loadIPCounter(stats.src_ips, state.Src_ips, state.Src_ips_sketch)
+	if err != nil {
+		return err
+	}
+	err = loadIPCounter(stats.dst_ips, state.Dst_ips, state.Dst_ips_sketch)
+	if err != nil {
+		return err
+	}
+	for _, value := range state.Src_ports {
+		stats.src_ports.add(value)
+	}
+	for _, value := range state.Dst_ports {
+		stats.dst_ports.add(value)
+	}
+	for _, value := range state.Icmp_types {
+		stats.icmp_types.add(value)
+	}
+	for _, value := range state.Icmp_codes {
+		stats.icmp_codes.add(value)
+	}
+	return nil
+}
+
+func (ace *accessEntryCompiled) saveState() EntryState {
+	state := EntryState{
+		Stats:   ace.stats.saveState(),
+		Samples: ace.samples,
+	}
+	for _, stats := range ace.rate_stats {
+		var stats_state StatsState
+		if stats != nil {
+			stats_state = stats.saveState()
+		}
+		state.Rate_stats = append(state.Rate_stats, stats_state)
+	}
+	for start, stats := range ace.bucket_stats {
+		state.Buckets = append(state.Buckets, BucketState{Start: start, Stats: stats.saveState()})
+	}
+	sort.Slice(state.Buckets, func(i, j int) bool {
+		return state.Buckets[i].Start.Before(state.Buckets[j].Start)
+	})
+	return state
+}
+
+// state is loaded before flows are added, so flows of the run are merged into it
+func (ace *accessEntryCompiled) loadState(state EntryState, stats_options network_entities.StatsOptions) error {
+	var err error
+
+	ace.m.Lock()
+	defer ace.m.Unlock()
+
+	ace.stats, err = ace.newFlowStats(stats_options)
+	if err != nil {
+		return err
+	}
+	err = ace.stats.loadState(state.Stats)
+	if err != nil {
+		return err
+	}
+	ace.rate_stats = [len(rateClasses)]*flowStats{}
+	for i, stats_state := range state.Rate_stats {
+		if i >= len(rateClasses) || stats_state.Flows == 0 {
+			continue
+		}
+		ace.rate_stats[i], err = ace.newFlowStats(stats_options)
+		if err != nil {
+			return err
+		}
+		err = ace.rate_stats[i].loadState(stats_state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if stats_options.Bucket != network_entities.BucketNone {
+		ace.bucket_stats = make(map[time.Time]*flowStats)
+		for _, bucket := range state.Buckets {
+			stats, err := ace.newFlowStats(stats_options)
+			if err != nil {
+				return err
+			}
+			err = stats.loadState(bucket.Stats)
+			if err != nil {
+				return err
+			}
+			ace.bucket_stats[bucket.Start] = stats
+		}
+	}
+
+	ace.samples = nil
+	for _, flow := range state.Samples {
+		// --- protocols are compared by pointer in places, point back to the table
+		if flow.Protocol != nil {
+			if proto, ok := network_entities.Protocols_map[flow.Protocol.Title]; ok {
+				flow.Protocol = proto
+			}
+		}
+		ace.samples = append(ace.samples, flow)
+	}
+	return nil
+}
+
+// states of compiled entries matched flows, keyed by compiled entry
+func (a *AccessEntry) SaveState() map[string]EntryState {
+	states := make(map[string]EntryState)
+	for i := range a.compiled {
+		if a.compiled[i].stats == nil {
+			continue
+		}
+		states[a.compiled[i].String()] = a.compiled[i].saveState()
+	}
+	return states
+}
+
+// restores states saved by SaveState. Compiled entries changed since
+// (ex: object-group edited) start from scratch, their states are returned as dropped.
+func (a *AccessEntry) LoadState(states map[string]EntryState, stats_options network_entities.StatsOptions) (dropped []string, err error) {
+	loaded := make(map[string]bool)
+	for i := range a.compiled {
+		key := a.compiled[i].String()
+		state, ok := states[key]
+		if !ok {
+			continue
+		}
+		err = a.compiled[i].loadState(state, stats_options)
+		if err != nil {
+			return nil, err
+		}
+		loaded[key] = true
+	}
+
+	for key := range states {
+		if !loaded[key] {
+			dropped = append(dropped, key)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped, nil
+}
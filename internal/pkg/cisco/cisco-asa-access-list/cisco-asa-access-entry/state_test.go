@@ -0,0 +1,134 @@
+package ciscoasaaccessentry
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// flows added in two runs with state saved in between give the same result as a single run
+func TestAccessEntry_LoadState(t *testing.T) {
+	tcp := network_entities.Protocols_map["tcp"]
+	icmp := network_entities.Protocols_map["icmp"]
+	net := utils.AddressObject{Start: 0x0a000000, Finish: 0x0a0000ff}
+	any := utils.AddressObject{Start: 0, Finish: 0xffffffff}
+	no_icmp := icmp_type_code{icmp_type: -1, icmp_code: -1}
+
+	newEntry := func() AccessEntry {
+		return AccessEntry{line: "permit tcp 10.0.0.0/24 any", compiled: []accessEntryCompiled{
+			{action: permit, proto: tcp, src_addr_range: net, dst_addr_range: any, icmp: no_icmp, m: &sync.Mutex{}},
+			{action: permit, proto: icmp, src_addr_range: net, dst_addr_range: any, icmp: no_icmp, m: &sync.Mutex{}},
+		}}
+	}
+
+	var flows []network_entities.Flow
+	for i := 0; i < 20; i++ {
+		flows = append(flows, network_entities.Flow{
+			Protocol:  tcp,
+			Src_ip:    0x0a000000 + uint32(i%7),
+			Dst_ip:    0x08080808 + uint32(i%3),
+			Src_port:  uint16(40000 + i),
+			Dst_port:  uint16(443 + i%2),
+			Icmp_type: -1,
+			Icmp_code: -1,
+			Timestamp: time.Date(2024, 1, 1+i/5, i, 0, 0, 0, time.UTC),
+			Accounted: i%4 == 0,
+			Bytes:     uint64(i * 100),
+		})
+	}
+	flows = append(flows, network_entities.Flow{Protocol: icmp, Src_ip: 0x0a000001, Dst_ip: 0x08080808, Icmp_type: 8, Icmp_code: 0})
+
+	tests := []struct {
+		name  string
+		stats network_entities.StatsOptions
+	}{
+		{name: "exact", stats: network_entities.StatsOptions{}},
+		{name: "buckets", stats: network_entities.StatsOptions{Bucket: network_entities.BucketDay}},
+		{name: "approximate", stats: network_entities.StatsOptions{Hll_precision: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			single := newEntry()
+			for _, flow := range flows {
+				if _, err := single.AddFlow(flow, tt.stats); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := single.GetResult()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			first := newEntry()
+			for _, flow := range flows[:10] {
+				if _, err := first.AddFlow(flow, tt.stats); err != nil {
+					t.Fatal(err)
+				}
+			}
+			content, err := json.Marshal(first.SaveState())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var states map[string]EntryState
+			if err := json.Unmarshal(content, &states); err != nil {
+				t.Fatal(err)
+			}
+
+			second := newEntry()
+			dropped, err := second.LoadState(states, tt.stats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(dropped) != 0 {
+				t.Errorf("AccessEntry.LoadState() dropped = %v, want none", dropped)
+			}
+			for _, flow := range flows[10:] {
+				if _, err := second.AddFlow(flow, tt.stats); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := second.GetResult()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("AccessEntry.GetResult() after LoadState = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestAccessEntry_LoadState_changed(t *testing.T) {
+	tcp := network_entities.Protocols_map["tcp"]
+	any := utils.AddressObject{Start: 0, Finish: 0xffffffff}
+	no_icmp := icmp_type_code{icmp_type: -1, icmp_code: -1}
+	entry := func(finish uint32) AccessEntry {
+		return AccessEntry{line: "permit tcp object-group NETS any", compiled: []accessEntryCompiled{
+			{action: permit, proto: tcp, src_addr_range: utils.AddressObject{Start: 0x0a000000, Finish: finish}, dst_addr_range: any, icmp: no_icmp, m: &sync.Mutex{}},
+		}}
+	}
+	flow := network_entities.Flow{Protocol: tcp, Src_ip: 0x0a000001, Dst_ip: 0x08080808, Dst_port: 443, Icmp_type: -1, Icmp_code: -1}
+
+	old := entry(0x0a0000ff)
+	if _, err := old.AddFlow(flow, network_entities.StatsOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// --- object-group edited, compiled entry is not the same
+	edited := entry(0x0a00ffff)
+	dropped, err := edited.LoadState(old.SaveState(), network_entities.StatsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{old.compiled[0].String()}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("AccessEntry.LoadState() dropped = %v, want %v", dropped, want)
+	}
+	if flows := edited.GetFlows(); flows != 0 {
+		t.Errorf("AccessEntry.GetFlows() = %v, want 0", flows)
+	}
+}
@@ -2,7 +2,9 @@ package ciscoasaaccessentry
 
 import (
 	"sync"
+	"time"
 
+	"github.com/ivankuchin/excessive-acl/internal/pkg/hyperloglog"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 )
@@ -40,14 +42,67 @@ type accessEntryCompiled struct {
 	// --- icmp part
 	icmp icmp_type_code
 
-	// --- flows mutex
+	// --- how parts of entry not restricting flows are counted in capacity, see SetCapacityModel
+	capacity_model network_entities.CapacityModel
+
+	// --- flows mutex, allocated when entry is compiled
 	m *sync.Mutex
-	// --- flows matched that acl entry
-	flows []network_entities.Flow
+	// --- aggregates of flows matched that acl entry, nil until first flow
+	stats *flowStats
+	// --- flows at least as busy as every rate class, nil until such flow, see flowRate
+	rate_stats [len(rateClasses)]*flowStats
+	// --- per period aggregates, only if bucket requested
+	bucket_stats map[time.Time]*flowStats
+	// --- first flows matched that acl entry, printed as examples
+	samples []network_entities.Flow
 	// --- count number of icmp flows
 	icmp_flows icmp_type_code
 }
 
+// 65536 bits, one per port or per host in /16
+type bitmap [1024]uint64
+
+// unique ip addresses, exact or estimated
+type ipCounter interface {
+	add(ip uint32)
+	count() uint32
+}
+
+// sparse set of ip addresses, bitmap per /16
+type ipSet map[uint16]*bitmap
+
+// hyperloglog estimate of unique ip addresses, fixed memory
+type ipSketch struct {
+	sketch *hyperloglog.Sketch
+}
+
+// icmp type or code -1..255, -1 is "not given"
+type icmpSet [5]uint64
+
+// unique values and counters of flows, memory doesn't grow with number of flows
+type flowStats struct {
+	flows uint64
+	bytes uint64
+	// --- timestamps of oldest and newest flows, zero if flows have no timestamp
+	first_seen time.Time
+	last_seen  time.Time
+
+	// --- nil if not tracked
+	src_ips ipCounter
+	dst_ips ipCounter
+	// --- 0 if addresses counted exactly
+	hll_precision uint8
+	// --- addresses are summarized to prefixes of that length, 0 counts unique addresses
+	max_prefix_length uint8
+	// --- ports are summarized to ranges of that size, 0 counts unique ports
+	max_port_range uint16
+
+	src_ports  bitmap
+	dst_ports  bitmap
+	icmp_types icmpSet
+	icmp_codes icmpSet
+}
+
 type serviceObject struct {
 	proto          []*network_entities.Protocol
 	src_port_range []port_range
@@ -59,3 +114,26 @@ type AccessEntry struct {
 	line     string
 	compiled []accessEntryCompiled
 }
+
+// node of segment tree, rules cover whole span of the node
+type segmentNode struct {
+	// --- rule ids in ACL order
+	rules []int32
+	// --- index on next dimension, only for nodes with many rules
+	sub *segmentTree
+}
+
+// segment tree over elementary intervals of uint32 ranges,
+// interval i is [boundaries[i], boundaries[i+1])
+type segmentTree struct {
+	boundaries []uint64
+	// --- heap numbering, root is 1. Most nodes are empty, map keeps memory O(rules * log(rules))
+	nodes map[uint32]*segmentNode
+}
+
+// first-match index over compiled entries of an ACL:
+// segment tree on source address, nodes with many rules are indexed on destination address
+type Index struct {
+	entries []*accessEntryCompiled
+	src     *segmentTree
+}
@@ -0,0 +1,35 @@
+package ciscoasaaccesslist
+
+import (
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// decision of the ACL on a flow, Line is empty if no ACE matched (implicit deny)
+type Verdict struct {
+	Acl_name string
+	Line     string
+	Entry    string
+	Permit   bool
+}
+
+func (v Verdict) Implicit() bool {
+	return v.Line == ""
+}
+
+// finds the first ACE matching the flow the way ASA does, statistics are not changed
+func (a *Accesslist) Explain(flow network_entities.Flow) (Verdict, error) {
+	verdict := Verdict{Acl_name: a.Name}
+	for i := range a.aces {
+		entry, permitted, matched, err := a.aces[i].Explain(flow)
+		if err != nil {
+			return verdict, err
+		}
+		if matched {
+			verdict.Line = a.aces[i].Line()
+			verdict.Entry = entry
+			verdict.Permit = permitted
+			return verdict, nil
+		}
+	}
+	return verdict, nil
+}
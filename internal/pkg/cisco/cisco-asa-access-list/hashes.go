@@ -0,0 +1,70 @@
+package ciscoasaaccesslist
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ACE line of "show access-list", entries expanded from object-groups are indented and share line number of their ACE:
+// access-list outside_in line 1 extended permit tcp any host 172.16.16.16 eq ssh (hitcnt=13) 0xd3b8e9a3
+var ace_hash_re = regexp.MustCompile(`^\s*access-list (\S+) line ([0-9]+) (\S+ \S+) .*\s0x([0-9a-fA-F]+)\s*$`)
+
+// ACE named by hash in "show access-list"
+type hashedLine struct {
+	// --- ACE number in ACL, starts from 1, remarks included
+	line int
+	// --- ex: "extended permit", checked against config
+	kind string
+}
+
+// hashes of ACEs per ACL name, see ParseHashes
+type Hashes map[string]map[uint32]hashedLine
+
+// parses "show access-list" output, lines without hash (ex: remarks) are skipped
+func ParseHashes(r io.Reader) (Hashes, error) {
+	hashes := make(Hashes)
+
+	fileScanner := bufio.NewScanner(r)
+	fileScanner.Split(bufio.ScanLines)
+	for fileScanner.Scan() {
+		match := ace_hash_re.FindStringSubmatch(fileScanner.Text())
+		if match == nil {
+			continue
+		}
+		line, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		hash, err := strconv.ParseUint(match[4], 16, 32)
+		if err != nil {
+			continue
+		}
+
+		if hashes[match[1]] == nil {
+			hashes[match[1]] = make(map[uint32]hashedLine)
+		}
+		hashes[match[1]][uint32(hash)] = hashedLine{line: line, kind: match[3]}
+	}
+
+	return hashes, fileScanner.Err()
+}
+
+// flows with ACE hash (106100) are credited to ACE of the hash.
+// Hashes of lines not matching the config (ex: "show access-list" taken before config change) are dropped.
+func (a *Accesslist) SetHashes(hashes Hashes) (dropped int) {
+	a.hashes = make(map[uint32]int)
+	for hash, hashed := range hashes[a.Name] {
+		idx := hashed.line - 1
+		if idx < 0 || idx >= len(a.aces) || !strings.HasPrefix(a.aces[idx].Line(), "access-list "+a.Name+" "+hashed.kind+" ") {
+			slog.Warn("ACE hash doesn't match config, dropped", "acl", a.Name, "line", hashed.line, "hash", strconv.FormatUint(uint64(hash), 16))
+			dropped++
+			continue
+		}
+		a.hashes[hash] = idx
+	}
+	return dropped
+}
@@ -0,0 +1,35 @@
+package ciscoasaaccesslist
+
+import (
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+)
+
+// ACEs shadowed by earlier ACEs of the ACL
+func (a *Accesslist) Shadowed() []Shadowed {
+	var result []Shadowed
+	for i := range a.aces {
+		lines, conflicting := a.aces[i].ShadowedBy(a.aces[:i])
+		if len(lines) > 0 {
+			result = append(result, Shadowed{Line: a.aces[i].Line(), By: lines, Conflicting: conflicting})
+		}
+	}
+	return result
+}
+
+// ACE lines as in the config, remarks included
+func (a *Accesslist) Lines() []string {
+	lines := make([]string, 0, len(a.aces))
+	for i := range a.aces {
+		lines = append(lines, a.aces[i].Line())
+	}
+	return lines
+}
+
+// exposure of every ACE in ACL order, same as GetResults
+func (a *Accesslist) Exposures(sensitive_ports []uint16) []cisco_asa_access_entry.Exposure {
+	exposures := make([]cisco_asa_access_entry.Exposure, 0, len(a.aces))
+	for i := range a.aces {
+		exposures = append(exposures, a.aces[i].Exposure(sensitive_ports))
+	}
+	return exposures
+}
@@ -2,7 +2,7 @@ package ciscoasaaccesslist
 
 import (
 	"fmt"
-	"log"
+	"io"
 
 	cisco_asa_access_group "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-group"
 	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
@@ -10,39 +10,33 @@ import (
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 )
 
-func compileACL(acl_name string) (Accesslist, error) {
+func compileACL(sh_run sh_run_pipe.Text, acl_name string) (Accesslist, error) {
 	var acl Accesslist
 	acl.Name = acl_name
 
-	acl_text := sh_run_pipe.Prefix("access-list " + acl_name)
+	acl_text := sh_run.Prefix("access-list " + acl_name)
 
 	if len(acl_text) == 0 {
-		err := ErrorACLNotFound
-		log.Printf("ERROR: %s %s", err, acl_name)
-		return acl, err
+		return acl, fmt.Errorf("%w (acl: %s)", ErrorACLNotFound, acl_name)
 	}
 
 	for _, ace_text := range acl_text {
-		_ace, err := cisco_asa_access_entry.Parse(ace_text)
+		_ace, err := cisco_asa_access_entry.Parse(sh_run, ace_text)
 		if err != nil {
 			return acl, err
 		}
 		acl.aces = append(acl.aces, _ace)
 	}
+	acl.index = cisco_asa_access_entry.NewIndex(acl.aces)
 
 	return acl, nil
 }
 
-func Parse(in_file string, access_groups []cisco_asa_access_group.Accessgroup) ([]Accesslist, error) {
-	err := sh_run_pipe.Load(in_file)
-	if err != nil {
-		return nil, err
-	}
-
+func Parse(sh_run sh_run_pipe.Text, access_groups []cisco_asa_access_group.Accessgroup) ([]Accesslist, error) {
 	var acls []Accesslist
 
 	for _, access_group := range access_groups {
-		acl, err := compileACL(access_group.Acl_name)
+		acl, err := compileACL(sh_run, access_group.Acl_name)
 		if err != nil {
 			return nil, err
 		}
@@ -53,9 +47,22 @@ func Parse(in_file string, access_groups []cisco_asa_access_group.Accessgroup) (
 	return acls, nil
 }
 
-func (a *Accesslist) AddFlow(flow network_entities.Flow) error {
+func (a *Accesslist) AddFlow(flow network_entities.Flow, stats_options network_entities.StatsOptions) error {
+	// --- message names ACE by hash, first match is used if ACE doesn't match the flow
+	if idx, found := a.hashes[flow.Ace_hash]; found && flow.Ace_hash != 0 {
+		flow_added, err := a.aces[idx].AddFlow(flow, stats_options)
+		if err != nil || flow_added {
+			return err
+		}
+	}
+
+	if a.index != nil {
+		_, err := a.index.AddFlow(flow, stats_options)
+		return err
+	}
+
 	for i := range a.aces {
-		flow_added, err := a.aces[i].AddFlow(flow)
+		flow_added, err := a.aces[i].AddFlow(flow, stats_options)
 		if err != nil {
 			return err
 		}
@@ -67,6 +74,13 @@ func (a *Accesslist) AddFlow(flow network_entities.Flow) error {
 	return nil
 }
 
+// capacity model of every ACE, must be set before flows are added
+func (a *Accesslist) SetCapacityModel(model network_entities.CapacityModel) {
+	for i := range a.aces {
+		a.aces[i].SetCapacityModel(model)
+	}
+}
+
 func (a *Accesslist) Analyze() error {
 	fmt.Println("ACL:", a.Name)
 	for i := range a.aces {
@@ -79,10 +93,34 @@ func (a *Accesslist) Analyze() error {
 	return nil
 }
 
-func (a Accesslist) Print() {
-	fmt.Printf("ACL %s\n", a.Name)
+func (a *Accesslist) GetResults() ([]cisco_asa_access_entry.ACEResult, error) {
+	var results []cisco_asa_access_entry.ACEResult
+	for i := range a.aces {
+		result, err := a.aces[i].GetResult()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ACL summary: number of entries, entries without flows and flows matched
+func (a *Accesslist) Summary() (aces, unused_aces int, flows uint64) {
+	for i := range a.aces {
+		ace_flows := a.aces[i].GetFlows()
+		if ace_flows == 0 {
+			unused_aces++
+		}
+		flows += ace_flows
+	}
+	return len(a.aces), unused_aces, flows
+}
+
+func (a Accesslist) Print(w io.Writer) {
+	fmt.Fprintf(w, "ACL %s\n", a.Name)
 
 	for _, ace := range a.aces {
-		ace.Print()
+		ace.Print(w)
 	}
 }
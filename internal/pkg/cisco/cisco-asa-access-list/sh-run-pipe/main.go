@@ -3,40 +3,41 @@ package sh_run_pipe
 import (
 	"bufio"
 	"errors"
-	"log"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 )
 
-var f_content []string
-
-func Load(in_file string) error {
-	if f_content != nil {
-		f_content = f_content[:0] // clear slice and keep the same capacity
-	}
-
+// returns "show run" content, every device keeps its own
+func Load(in_file string) (Text, error) {
 	readFile, err := os.Open(in_file)
 	if err != nil {
-		log.Println("ERROR:", err)
-		return err
+		return nil, fmt.Errorf("can't open show run: %w", err)
 	}
 	defer readFile.Close()
 
-	fileScanner := bufio.NewScanner(readFile)
+	return Read(readFile)
+}
+
+func Read(r io.Reader) (Text, error) {
+	var f_content Text
+
+	fileScanner := bufio.NewScanner(r)
 	fileScanner.Split(bufio.ScanLines)
 
 	for fileScanner.Scan() {
 		f_content = append(f_content, fileScanner.Text())
 	}
 
-	return nil
+	return f_content, fileScanner.Err()
 }
 
-func Exact(pattern string) Text {
+func (t Text) Exact(pattern string) Text {
 	var result Text
 
-	for _, line := range f_content {
+	for _, line := range t {
 		if strings.Trim(line, " \t") == pattern {
 			result = append(result, line)
 		}
@@ -44,21 +45,10 @@ func Exact(pattern string) Text {
 	return result
 }
 
-func Include(pattern string) Text {
-	var result Text
-
-	for _, line := range f_content {
-		if strings.Contains(line, pattern) {
-			result = append(result, line)
-		}
-	}
-	return result
-}
-
-func Prefix(pattern string) Text {
+func (t Text) Prefix(pattern string) Text {
 	var result Text
 
-	for _, line := range f_content {
+	for _, line := range t {
 		if strings.HasPrefix(line, pattern) {
 			result = append(result, line)
 		}
@@ -91,11 +81,11 @@ func makeIdentation(ident_len uint) string {
 	return result
 }
 
-func section(pattern string, exact bool) Text {
+func (t Text) section(pattern string, exact bool) Text {
 	var result Text
 	ident := ""
 
-	for _, line := range f_content {
+	for _, line := range t {
 		// --- search for pattern
 		if ((exact == false) && strings.Contains(line, pattern)) || ((exact == true) && (strings.Trim(line, " \t") == pattern)) {
 			ident_len, err := getLineIdentation(line)
@@ -121,12 +111,12 @@ func section(pattern string, exact bool) Text {
 	return result
 }
 
-func Section(pattern string) Text {
-	return section(pattern, false)
+func (t Text) Section(pattern string) Text {
+	return t.section(pattern, false)
 }
 
-func SectionExact(pattern string) Text {
-	return section(pattern, true)
+func (t Text) SectionExact(pattern string) Text {
+	return t.section(pattern, true)
 }
 
 func (t Text) Exclude(pattern string) Text {
@@ -160,9 +150,7 @@ func (t Text) Len() uint {
 
 func (t Text) Get(idx uint) (string, error) {
 	if t.Len() < idx {
-		error_message := "Index " + strconv.Itoa(int(idx)) + " requested from text higher than amount of lines in text (" + strconv.Itoa(int(t.Len())) + ")"
-		log.Printf("ERROR: %s\n", error_message)
-		return "", errors.New(error_message)
+		return "", errors.New("Index " + strconv.Itoa(int(idx)) + " requested from text higher than amount of lines in text (" + strconv.Itoa(int(t.Len())) + ")")
 	}
 	return []string(t)[idx], nil
 }
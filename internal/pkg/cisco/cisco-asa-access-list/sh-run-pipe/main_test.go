@@ -25,11 +25,11 @@ func Test_SectionExact(t *testing.T) {
 			},
 		},
 	}
-	Load("testdata/sh_run_test.txt")
+	sh_run, _ := Load("testdata/sh_run_test.txt")
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := SectionExact(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
+			if got := sh_run.SectionExact(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("SectionExact() = %v, want %v", got, tt.want)
 			}
 		})
@@ -60,10 +60,10 @@ func Test_Section(t *testing.T) {
 			},
 		},
 	}
-	// Load("testdata/sh_run_test.txt")
+	sh_run, _ := Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := Section(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
+			if got := sh_run.Section(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("section() = %v, want %v", got, tt.want)
 			}
 		})
@@ -128,10 +128,10 @@ func TestExact(t *testing.T) {
 			},
 		},
 	}
-	Load("testdata/sh_run_test.txt")
+	sh_run, _ := Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := Exact(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
+			if got := sh_run.Exact(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Exact() = %v, want %v", got, tt.want)
 			}
 		})
@@ -159,10 +159,10 @@ func TestInclude(t *testing.T) {
 			},
 		},
 	}
-	Load("testdata/sh_run_test.txt")
+	sh_run, _ := Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := Include(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
+			if got := sh_run.Include(tt.args.pattern); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Include() = %v, want %v", got, tt.want)
 			}
 		})
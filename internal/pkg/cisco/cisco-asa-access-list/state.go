@@ -0,0 +1,54 @@
+package ciscoasaaccesslist
+
+import (
+	"sort"
+
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// states of ACEs matched flows, keyed by ACE text and then by compiled entry
+type State map[string]map[string]cisco_asa_access_entry.EntryState
+
+func (a *Accesslist) SaveState() State {
+	state := make(State)
+	for i := range a.aces {
+		entries := a.aces[i].SaveState()
+		if len(entries) == 0 {
+			continue
+		}
+		state[cisco_asa_access_entry.StateKey(a.aces[i].Line())] = entries
+	}
+	return state
+}
+
+// restores state saved by SaveState, ACEs are matched by text, so state survives reordering.
+// Returns ACEs and compiled entries that are not in the config anymore, their state is dropped.
+func (a *Accesslist) LoadState(state State, stats_options network_entities.StatsOptions) (dropped []string, err error) {
+	loaded := make(map[string]bool)
+	for i := range a.aces {
+		key := cisco_asa_access_entry.StateKey(a.aces[i].Line())
+		entries, ok := state[key]
+		if !ok {
+			continue
+		}
+		dropped_entries, err := a.aces[i].LoadState(entries, stats_options)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range dropped_entries {
+			dropped = append(dropped, key+" / "+entry)
+		}
+		loaded[key] = true
+	}
+
+	var dropped_aces []string
+	for key := range state {
+		if !loaded[key] {
+			dropped_aces = append(dropped_aces, key)
+		}
+	}
+	sort.Strings(dropped_aces)
+
+	return append(dropped, dropped_aces...), nil
+}
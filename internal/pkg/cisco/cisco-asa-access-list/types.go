@@ -9,6 +9,18 @@ import (
 type Accesslist struct {
 	Name string
 	aces []cisco_asa_access_entry.AccessEntry
+	// --- first-match index over compiled entries of aces
+	index *cisco_asa_access_entry.Index
+	// --- ACE hash of "show access-list" -> index in aces, see SetHashes
+	hashes map[uint32]int
 }
 
 var ErrorACLNotFound = errors.New("ACL not found")
+
+// ACE that never matches: earlier ACEs match all its flows
+type Shadowed struct {
+	Line string
+	By   []string
+	// --- one of earlier ACEs has another action (ex: deny after permit)
+	Conflicting bool
+}
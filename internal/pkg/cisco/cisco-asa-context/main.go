@@ -0,0 +1,74 @@
+package ciscoasacontext
+
+import (
+	"fmt"
+	"strings"
+
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+)
+
+// example:
+// context ctx1
+//
+//	allocate-interface GigabitEthernet0/1 inside
+//	config-url disk0:/ctx1.cfg
+func parseContext(section sh_run_pipe.Text) (Context, error) {
+	var context Context
+
+	for _, line := range section {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "context":
+			context.Name = fields[1]
+		case "config-url":
+			context.Config_url = fields[1]
+		}
+	}
+
+	if context.Name == "" {
+		return context, fmt.Errorf("context without name (section: %v)", section)
+	}
+
+	return context, nil
+}
+
+// contexts of the system config in multiple context mode
+func Parse(system sh_run_pipe.Text) ([]Context, error) {
+	var contexts []Context
+
+	var section sh_run_pipe.Text
+	for _, line := range system.Section("context ") {
+		if strings.HasPrefix(line, "context ") {
+			if len(section) > 0 {
+				context, err := parseContext(section)
+				if err != nil {
+					return nil, err
+				}
+				contexts = append(contexts, context)
+			}
+			section = sh_run_pipe.Text{line}
+			continue
+		}
+		// --- skip "admin-context admin" and alike
+		if len(section) > 0 && strings.HasPrefix(line, " ") {
+			section = append(section, line)
+		}
+	}
+	if len(section) > 0 {
+		context, err := parseContext(section)
+		if err != nil {
+			return nil, err
+		}
+		contexts = append(contexts, context)
+	}
+
+	if len(contexts) == 0 {
+		return nil, ErrorNotMultipleContext
+	}
+
+	return contexts, nil
+}
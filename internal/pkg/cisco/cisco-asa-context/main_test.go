@@ -0,0 +1,41 @@
+package ciscoasacontext
+
+import (
+	"reflect"
+	"testing"
+
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+)
+
+func TestParse(t *testing.T) {
+	want := []Context{
+		{Name: "admin", Config_url: "disk0:/admin.cfg"},
+		{Name: "ctx1", Config_url: "disk0:/ctx1.cfg"},
+		{Name: "ctx2", Config_url: "disk0:/ctx2.cfg"},
+	}
+
+	system, err := sh_run_pipe.Load("testdata/system_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(system)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+}
+
+func TestParse_single_context(t *testing.T) {
+	system, err := sh_run_pipe.Load("../cisco-asa-nat/testdata/sh_run_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Parse(system)
+	if err != ErrorNotMultipleContext {
+		t.Errorf("Parse() error = %v, want %v", err, ErrorNotMultipleContext)
+	}
+}
@@ -0,0 +1,11 @@
+package ciscoasacontext
+
+import "errors"
+
+// security context defined in the system configuration
+type Context struct {
+	Name       string
+	Config_url string
+}
+
+var ErrorNotMultipleContext = errors.New("system config doesn't have contexts")
@@ -0,0 +1,236 @@
+package ciscoasanat
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+	sh_ip_route "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/sh-ip-route"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// NAT of ASA before 8.3 names interfaces of nat and global separately: nat (inside) 1 ...
+func isLegacyNat(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 1 && !strings.Contains(fields[1], ",")
+}
+
+// "0" is a short form of 0.0.0.0 in pre-8.3 nat
+func parseLegacyIP(ip_str string) (uint32, error) {
+	if ip_str == "0" {
+		return 0, nil
+	}
+	return utils.ParseIP(ip_str)
+}
+
+// ip and mask to range, mask of host if not given
+func parseLegacyNetwork(ip_str, mask_str string) (utils.AddressObject, error) {
+	ip, err := parseLegacyIP(ip_str)
+	if err != nil {
+		return utils.AddressObject{}, err
+	}
+	mask := ^uint32(0)
+	switch mask_str {
+	case "":
+	case "0":
+		mask = 0
+	default:
+		mask, err = utils.ParseMask(mask_str)
+		if err != nil {
+			return utils.AddressObject{}, err
+		}
+	}
+	return utils.AddressObject{Start: ip & mask, Finish: ip | ^mask}, nil
+}
+
+// value following keyword, empty if keyword not found
+func getKeywordValue(fields []string, keyword string) string {
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == keyword {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// example:
+// static (inside,outside) 123.123.123.10 192.168.0.10 netmask 255.255.255.255
+// static (inside,outside) tcp interface 8080 192.168.0.10 www netmask 255.255.255.255
+// Ports of static PAT aren't translated, mapped port is taken from syslog message.
+// Policy static (access-list instead of real address) is skipped.
+func parseLegacyStatic(line string, iface_addresses map[string]sh_ip_route.IfaceAddress) (natRule, bool, error) {
+	var rule natRule
+	var err error
+
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return rule, false, fmt.Errorf("not enough fields in static (line: %v)", line)
+	}
+
+	rule.real_iface, rule.mapped_iface, err = parseIfaces(fields[1])
+	if err != nil {
+		return rule, false, err
+	}
+	rule.static = true
+
+	mapped_idx, real_idx := 2, 3
+	if fields[2] == "tcp" || fields[2] == "udp" {
+		mapped_idx, real_idx = 3, 5
+	}
+	if len(fields) <= real_idx {
+		return rule, false, fmt.Errorf("not enough fields in static (line: %v)", line)
+	}
+	if fields[real_idx] == "access-list" {
+		slog.Warn("policy static is skipped", "line", line)
+		return rule, false, nil
+	}
+
+	mask := getKeywordValue(fields, "netmask")
+	real_range, err := parseLegacyNetwork(fields[real_idx], mask)
+	if err != nil {
+		return rule, false, fmt.Errorf("can't parse real address of static (line: %v): %w", line, err)
+	}
+	rule.src_real = []utils.AddressObject{real_range}
+
+	if fields[mapped_idx] == "interface" {
+		rule.src_mapped, err = parseAddress(nil, "interface", rule.mapped_iface, iface_addresses)
+		if err != nil {
+			return rule, false, err
+		}
+		return rule, true, nil
+	}
+	mapped_range, err := parseLegacyNetwork(fields[mapped_idx], mask)
+	if err != nil {
+		return rule, false, fmt.Errorf("can't parse mapped address of static (line: %v): %w", line, err)
+	}
+	rule.src_mapped = []utils.AddressObject{mapped_range}
+
+	return rule, true, nil
+}
+
+// real addresses of "nat (inside) 1 192.168.0.0 255.255.255.0", nat id 0 is NAT exemption.
+// Policy nat (access-list instead of real address) is skipped.
+type legacyNat struct {
+	id         int
+	real_iface string
+	src_real   []utils.AddressObject
+}
+
+func parseLegacyNat(line string) (legacyNat, bool, error) {
+	var nat legacyNat
+	var err error
+
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return nat, false, fmt.Errorf("not enough fields in nat (line: %v)", line)
+	}
+
+	nat.real_iface = strings.Trim(fields[1], "()")
+	nat.id, err = strconv.Atoi(fields[2])
+	if err != nil {
+		return nat, false, fmt.Errorf("can't parse nat id (line: %v): %w", line, err)
+	}
+	if fields[3] == "access-list" {
+		slog.Warn("policy nat is skipped", "line", line)
+		return nat, false, nil
+	}
+
+	mask := ""
+	if len(fields) > 4 {
+		mask = fields[4]
+	}
+	real_range, err := parseLegacyNetwork(fields[3], mask)
+	if err != nil {
+		return nat, false, fmt.Errorf("can't parse real address of nat (line: %v): %w", line, err)
+	}
+	nat.src_real = []utils.AddressObject{real_range}
+
+	return nat, true, nil
+}
+
+// example:
+// global (outside) 1 interface
+// global (outside) 1 123.123.123.100-123.123.123.110 netmask 255.255.255.0
+func parseLegacyGlobal(line string, iface_addresses map[string]sh_ip_route.IfaceAddress) (int, string, []utils.AddressObject, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return 0, "", nil, fmt.Errorf("not enough fields in global (line: %v)", line)
+	}
+
+	mapped_iface := strings.Trim(fields[1], "()")
+	id, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("can't parse global id (line: %v): %w", line, err)
+	}
+
+	if fields[3] == "interface" {
+		mapped, err := parseAddress(nil, "interface", mapped_iface, iface_addresses)
+		return id, mapped_iface, mapped, err
+	}
+
+	start, finish, _ := strings.Cut(fields[3], "-")
+	if finish == "" {
+		finish = start
+	}
+	start_ip, err := utils.ParseIP(start)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("can't parse global address (line: %v): %w", line, err)
+	}
+	finish_ip, err := utils.ParseIP(finish)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("can't parse global address (line: %v): %w", line, err)
+	}
+
+	return id, mapped_iface, []utils.AddressObject{{Start: start_ip, Finish: finish_ip}}, nil
+}
+
+// ASA before 8.3 evaluates NAT exemption (nat 0), then static, then dynamic nat with global of the same id
+func parseLegacy(sh_run sh_run_pipe.Text, iface_addresses map[string]sh_ip_route.IfaceAddress) ([]natRule, error) {
+	var exempt, static, dynamic []natRule
+
+	for _, line := range sh_run.Prefix("static (") {
+		rule, ok, err := parseLegacyStatic(line, iface_addresses)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			static = append(static, rule)
+		}
+	}
+
+	var nats []legacyNat
+	for _, line := range sh_run.Prefix("nat (") {
+		if !isLegacyNat(line) {
+			continue
+		}
+		nat, ok, err := parseLegacyNat(line)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if nat.id == 0 {
+			// --- exempted addresses are seen as they are behind every interface
+			exempt = append(exempt, natRule{real_iface: nat.real_iface, mapped_iface: "any", static: true, src_real: nat.src_real, src_mapped: nat.src_real})
+			continue
+		}
+		nats = append(nats, nat)
+	}
+
+	for _, line := range sh_run.Prefix("global (") {
+		id, mapped_iface, mapped, err := parseLegacyGlobal(line, iface_addresses)
+		if err != nil {
+			return nil, err
+		}
+		for _, nat := range nats {
+			if nat.id == id && nat.real_iface != mapped_iface {
+				dynamic = append(dynamic, natRule{real_iface: nat.real_iface, mapped_iface: mapped_iface, src_real: nat.src_real, src_mapped: mapped})
+			}
+		}
+	}
+
+	return append(append(exempt, static...), dynamic...), nil
+}
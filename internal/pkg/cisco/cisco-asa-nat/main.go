@@ -0,0 +1,275 @@
+package ciscoasanat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+	sh_ip_route "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/sh-ip-route"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+func ParseAddressForm(name string) (AddressForm, error) {
+	switch name {
+	case "real":
+		return Real, nil
+	case "mapped":
+		return Mapped, nil
+	case "auto":
+		return Auto, nil
+	default:
+		return Real, fmt.Errorf("%w (acl_addresses: %s)", ErrorUnknownAddressForm, name)
+	}
+}
+
+// address form of the config, Auto is detected
+func (form AddressForm) Resolve(sh_run sh_run_pipe.Text) AddressForm {
+	if form == Auto {
+		return detectAddressForm(sh_run)
+	}
+	return form
+}
+
+// ASA Version 9.15(1)1
+func detectAddressForm(sh_run sh_run_pipe.Text) AddressForm {
+	version_text := sh_run.Prefix("ASA Version ")
+	if version_text.Len() == 0 {
+		return Real
+	}
+
+	line, err := version_text.Get(0)
+	if err != nil {
+		return Real
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return Real
+	}
+
+	major_minor := strings.SplitN(fields[2], ".", 2)
+	if len(major_minor) != 2 {
+		return Real
+	}
+	major, err := strconv.Atoi(major_minor[0])
+	if err != nil {
+		return Real
+	}
+	minor, err := strconv.Atoi(strings.SplitN(major_minor[1], "(", 2)[0])
+	if err != nil {
+		return Real
+	}
+
+	if major < 8 || (major == 8 && minor < 3) {
+		return Mapped
+	}
+	return Real
+}
+
+// input format: (inside,outside)
+func parseIfaces(ifaces string) (string, string, error) {
+	ifaces_split := strings.Split(strings.Trim(ifaces, "()"), ",")
+	if len(ifaces_split) != 2 {
+		return "", "", fmt.Errorf("can't parse nat interfaces (ifaces: %v)", ifaces)
+	}
+	return ifaces_split[0], ifaces_split[1], nil
+}
+
+func parseStatic(static_dynamic string) (bool, error) {
+	switch static_dynamic {
+	case "static":
+		return true, nil
+	case "dynamic":
+		return false, nil
+	default:
+		return false, fmt.Errorf("nat must be static or dynamic (static_dynamic: %v)", static_dynamic)
+	}
+}
+
+func parseAddress(sh_run sh_run_pipe.Text, name, iface string, iface_addresses map[string]sh_ip_route.IfaceAddress) ([]utils.AddressObject, error) {
+	switch name {
+	case "any":
+		return []utils.AddressObject{{Start: 0, Finish: 0xffffffff}}, nil
+	case "interface":
+		iface_address, ok := iface_addresses[iface]
+		if !ok {
+			return nil, fmt.Errorf("nat to interface without ip address (iface: %v)", iface)
+		}
+		return []utils.AddressObject{{Start: iface_address.Ip, Finish: iface_address.Ip}}, nil
+	}
+
+	// --- object NAT allows ip address as a mapped address
+	if ip, err := utils.ParseIP(name); err == nil {
+		return []utils.AddressObject{{Start: ip, Finish: ip}}, nil
+	}
+
+	return cisco_asa_access_entry.GetAddressObjectsByName(sh_run, name)
+}
+
+// example:
+// object network WEB
+//
+//	nat (inside,outside) static 123.123.123.10
+//	nat (inside,outside) dynamic interface
+func parseObjectNat(sh_run sh_run_pipe.Text, object_name, line string, iface_addresses map[string]sh_ip_route.IfaceAddress) (natRule, error) {
+	var rule natRule
+	var err error
+
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return rule, fmt.Errorf("not enough fields in object nat (line: %v)", line)
+	}
+
+	rule.real_iface, rule.mapped_iface, err = parseIfaces(fields[1])
+	if err != nil {
+		return rule, err
+	}
+	rule.static, err = parseStatic(fields[2])
+	if err != nil {
+		return rule, err
+	}
+	rule.src_real, err = cisco_asa_access_entry.GetAddressObjectsByName(sh_run, object_name)
+	if err != nil {
+		return rule, err
+	}
+	mapped := fields[3]
+	if mapped == "pat-pool" && len(fields) > 4 {
+		mapped = fields[4]
+	}
+	rule.src_mapped, err = parseAddress(sh_run, mapped, rule.mapped_iface, iface_addresses)
+	if err != nil {
+		return rule, err
+	}
+
+	return rule, nil
+}
+
+// example:
+// nat (inside,outside) source static REAL MAPPED destination static MAPPED-DST REAL-DST
+// nat (inside,outside) after-auto source dynamic any interface
+func parseTwiceNat(sh_run sh_run_pipe.Text, line string, iface_addresses map[string]sh_ip_route.IfaceAddress) (natRule, bool, error) {
+	var rule natRule
+	var err error
+	after_auto := false
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return rule, after_auto, fmt.Errorf("not enough fields in twice nat (line: %v)", line)
+	}
+
+	rule.real_iface, rule.mapped_iface, err = parseIfaces(fields[1])
+	if err != nil {
+		return rule, after_auto, err
+	}
+
+	for i := 2; i < len(fields); i++ {
+		switch fields[i] {
+		case "after-auto":
+			after_auto = true
+		case "source":
+			if len(fields) < i+4 {
+				return rule, after_auto, fmt.Errorf("not enough fields in twice nat source (line: %v)", line)
+			}
+			rule.static, err = parseStatic(fields[i+1])
+			if err != nil {
+				return rule, after_auto, err
+			}
+			rule.src_real, err = parseAddress(sh_run, fields[i+2], rule.real_iface, iface_addresses)
+			if err != nil {
+				return rule, after_auto, err
+			}
+			mapped := fields[i+3]
+			if mapped == "pat-pool" && len(fields) > i+4 {
+				mapped = fields[i+4]
+				i++
+			}
+			rule.src_mapped, err = parseAddress(sh_run, mapped, rule.mapped_iface, iface_addresses)
+			if err != nil {
+				return rule, after_auto, err
+			}
+			i += 3
+		case "destination":
+			// --- destination static MAPPED REAL
+			if len(fields) < i+4 {
+				return rule, after_auto, fmt.Errorf("not enough fields in twice nat destination (line: %v)", line)
+			}
+			rule.dst_mapped, err = parseAddress(sh_run, fields[i+2], rule.real_iface, iface_addresses)
+			if err != nil {
+				return rule, after_auto, err
+			}
+			rule.dst_real, err = parseAddress(sh_run, fields[i+3], rule.mapped_iface, iface_addresses)
+			if err != nil {
+				return rule, after_auto, err
+			}
+			i += 3
+		}
+	}
+
+	if rule.src_real == nil {
+		return rule, after_auto, fmt.Errorf("source not found in twice nat (line: %v)", line)
+	}
+
+	return rule, after_auto, nil
+}
+
+// ASA evaluates manual NAT, then object NAT (static first), then manual NAT after-auto.
+// Configs before ASA 8.3 have static, nat and global instead, see parseLegacy.
+func Parse(sh_run sh_run_pipe.Text) (NatRules, error) {
+	var nat_rules NatRules
+	var object_static, object_dynamic, after_auto []natRule
+
+	iface_addresses, err := sh_ip_route.FindIfaceAddresses(sh_run)
+	if err != nil {
+		return nat_rules, err
+	}
+
+	for _, line := range sh_run.Prefix("nat (") {
+		if isLegacyNat(line) {
+			continue
+		}
+		rule, is_after_auto, err := parseTwiceNat(sh_run, line, iface_addresses)
+		if err != nil {
+			return nat_rules, err
+		}
+		if is_after_auto {
+			after_auto = append(after_auto, rule)
+		} else {
+			nat_rules.rules = append(nat_rules.rules, rule)
+		}
+	}
+
+	var object_name string
+	for _, line := range sh_run.Section("object network ") {
+		if strings.HasPrefix(line, "object network ") {
+			object_name = strings.TrimPrefix(line, "object network ")
+			object_name = strings.TrimSpace(object_name)
+			continue
+		}
+		if !strings.HasPrefix(strings.TrimSpace(line), "nat (") {
+			continue
+		}
+
+		rule, err := parseObjectNat(sh_run, object_name, line, iface_addresses)
+		if err != nil {
+			return nat_rules, err
+		}
+		if rule.static {
+			object_static = append(object_static, rule)
+		} else {
+			object_dynamic = append(object_dynamic, rule)
+		}
+	}
+
+	nat_rules.rules = append(nat_rules.rules, object_static...)
+	nat_rules.rules = append(nat_rules.rules, object_dynamic...)
+	nat_rules.rules = append(nat_rules.rules, after_auto...)
+
+	legacy, err := parseLegacy(sh_run, iface_addresses)
+	if err != nil {
+		return nat_rules, err
+	}
+	nat_rules.rules = append(nat_rules.rules, legacy...)
+
+	return nat_rules, nil
+}
@@ -0,0 +1,215 @@
+package ciscoasanat
+
+import (
+	"testing"
+
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+)
+
+func TestNatRules_MappedIP(t *testing.T) {
+	type args struct {
+		ip          uint32
+		iface       string
+		other_iface string
+		// --- host is a destination of the flow
+		destination bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    uint32
+		want_ok bool
+	}{
+		{
+			name:    "object static nat",
+			args:    args{ip: 0xc0a8000a, iface: "inside", other_iface: "outside"},
+			want:    0x7b7b7b0a,
+			want_ok: true,
+		},
+		{
+			name:    "object dynamic pat to interface",
+			args:    args{ip: 0xc0a80005, iface: "inside", other_iface: "outside"},
+			want:    0x7b7b7b01,
+			want_ok: true,
+		},
+		{
+			name:    "twice nat source range",
+			args:    args{ip: 0xc0a80016, iface: "inside", other_iface: "outside"},
+			want:    0x7b7b7b16,
+			want_ok: true,
+		},
+		{
+			name:    "twice nat destination",
+			args:    args{ip: 0x0a0a0a0a, iface: "outside", other_iface: "inside"},
+			want:    0xc0a8640a,
+			want_ok: true,
+		},
+		{
+			name:    "twice nat destination of flow",
+			args:    args{ip: 0x0a0a0a0a, iface: "outside", other_iface: "inside", destination: true},
+			want:    0xc0a8640a,
+			want_ok: true,
+		},
+		{
+			name:    "object static nat of flow destination",
+			args:    args{ip: 0xc0a8000a, iface: "inside", other_iface: "outside", destination: true},
+			want:    0x7b7b7b0a,
+			want_ok: true,
+		},
+		{
+			name:    "no dynamic pat to interface of flow destination",
+			args:    args{ip: 0xc0a80005, iface: "inside", other_iface: "outside", destination: true},
+			want:    0,
+			want_ok: false,
+		},
+		{
+			name:    "no nat in reverse direction",
+			args:    args{ip: 0xc0a8000a, iface: "outside", other_iface: "inside"},
+			want:    0,
+			want_ok: false,
+		},
+	}
+
+	sh_run, err := sh_run_pipe.Load("testdata/sh_run_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nat_rules, err := Parse(sh_run)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappedIP := nat_rules.MappedIP
+			if tt.args.destination {
+				mappedIP = nat_rules.MappedDstIP
+			}
+			got, got_ok := mappedIP(tt.args.ip, tt.args.iface, tt.args.other_iface)
+			if got != tt.want || got_ok != tt.want_ok {
+				t.Errorf("MappedIP() = 0x%x, %v, want 0x%x, %v", got, got_ok, tt.want, tt.want_ok)
+			}
+		})
+	}
+}
+
+func TestNatRules_MappedIP_legacy(t *testing.T) {
+	type args struct {
+		ip          uint32
+		iface       string
+		other_iface string
+		// --- host is a destination of the flow
+		destination bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    uint32
+		want_ok bool
+	}{
+		{
+			name:    "static host",
+			args:    args{ip: 0xc0a8000a, iface: "inside", other_iface: "outside"},
+			want:    0x7b7b7b0a,
+			want_ok: true,
+		},
+		{
+			name:    "static network",
+			args:    args{ip: 0xc0a80025, iface: "inside", other_iface: "outside"},
+			want:    0x7b7b7b25,
+			want_ok: true,
+		},
+		{
+			name:    "static pat to interface",
+			args:    args{ip: 0xc0a8000b, iface: "inside", other_iface: "outside"},
+			want:    0x7b7b7b01,
+			want_ok: true,
+		},
+		{
+			name:    "nat exemption",
+			args:    args{ip: 0xc0a80045, iface: "inside", other_iface: "outside"},
+			want:    0xc0a80045,
+			want_ok: true,
+		},
+		{
+			name:    "dynamic pat to interface of global",
+			args:    args{ip: 0xc0a80005, iface: "inside", other_iface: "outside"},
+			want:    0x7b7b7b01,
+			want_ok: true,
+		},
+		{
+			name:    "dynamic pool can't be predicted",
+			args:    args{ip: 0xc0a80085, iface: "inside", other_iface: "outside"},
+			want:    0,
+			want_ok: false,
+		},
+		{
+			name:    "dynamic pool of the same size can't be predicted",
+			args:    args{ip: 0xc0a80105, iface: "inside", other_iface: "outside"},
+			want:    0,
+			want_ok: false,
+		},
+		{
+			name:    "static of flow destination",
+			args:    args{ip: 0xc0a80025, iface: "inside", other_iface: "outside", destination: true},
+			want:    0x7b7b7b25,
+			want_ok: true,
+		},
+		{
+			name:    "no dynamic pat of flow destination",
+			args:    args{ip: 0xc0a80005, iface: "inside", other_iface: "outside", destination: true},
+			want:    0,
+			want_ok: false,
+		},
+		{
+			name:    "policy nat skipped",
+			args:    args{ip: 0xc0a800c8, iface: "inside", other_iface: "outside"},
+			want:    0,
+			want_ok: false,
+		},
+	}
+
+	sh_run, err := sh_run_pipe.Load("testdata/sh_run_legacy_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nat_rules, err := Parse(sh_run)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappedIP := nat_rules.MappedIP
+			if tt.args.destination {
+				mappedIP = nat_rules.MappedDstIP
+			}
+			got, got_ok := mappedIP(tt.args.ip, tt.args.iface, tt.args.other_iface)
+			if got != tt.want || got_ok != tt.want_ok {
+				t.Errorf("MappedIP() = 0x%x, %v, want 0x%x, %v", got, got_ok, tt.want, tt.want_ok)
+			}
+		})
+	}
+}
+
+func Test_detectAddressForm(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want AddressForm
+	}{
+		{name: "ASA 9.15", file: "testdata/sh_run_test.txt", want: Real},
+		{name: "ASA 8.2", file: "testdata/sh_run_legacy_test.txt", want: Mapped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sh_run, err := sh_run_pipe.Load(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := detectAddressForm(sh_run); got != tt.want {
+				t.Errorf("detectAddressForm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package ciscoasanat
+
+import (
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+func isIfaceMatch(rule_iface, iface string) bool {
+	return rule_iface == "any" || rule_iface == iface
+}
+
+func size(ranges []utils.AddressObject) uint64 {
+	var result uint64
+	for _, r := range ranges {
+		result += uint64(r.Finish) - uint64(r.Start) + 1
+	}
+	return result
+}
+
+// maps ip from real ranges to mapped ranges.
+// Static ranges of the same size are mapped one to one, single mapped address is PAT.
+// Dynamic pools can't be predicted, even of the same size as real ranges.
+func translate(ip uint32, real, mapped []utils.AddressObject, static bool) (uint32, bool) {
+	var offset uint64
+	found := false
+	for _, r := range real {
+		if r.Start <= ip && ip <= r.Finish {
+			offset += uint64(ip - r.Start)
+			found = true
+			break
+		}
+		offset += uint64(r.Finish) - uint64(r.Start) + 1
+	}
+	if !found {
+		return 0, false
+	}
+
+	mapped_size := size(mapped)
+	switch {
+	case mapped_size == 1:
+		return mapped[0].Start, true
+	case static && mapped_size == size(real):
+		for _, m := range mapped {
+			m_size := uint64(m.Finish) - uint64(m.Start) + 1
+			if offset < m_size {
+				return m.Start + uint32(offset), true
+			}
+			offset -= m_size
+		}
+	}
+
+	return 0, false
+}
+
+// returns address of the host located behind iface as it seen behind other_iface,
+// host is a source of the flow
+func (nr NatRules) MappedIP(ip uint32, iface, other_iface string) (uint32, bool) {
+	return nr.mappedIP(ip, iface, other_iface, false)
+}
+
+// same as MappedIP, host is a destination of the flow.
+// Dynamic NAT and PAT translate flows initiated by the host only, so only static rules apply.
+func (nr NatRules) MappedDstIP(ip uint32, iface, other_iface string) (uint32, bool) {
+	return nr.mappedIP(ip, iface, other_iface, true)
+}
+
+func (nr NatRules) mappedIP(ip uint32, iface, other_iface string, static_only bool) (uint32, bool) {
+	for _, rule := range nr.rules {
+		if static_only && !rule.static {
+			continue
+		}
+		// --- host is a source of the rule
+		if isIfaceMatch(rule.real_iface, iface) && isIfaceMatch(rule.mapped_iface, other_iface) {
+			if mapped_ip, ok := translate(ip, rule.src_real, rule.src_mapped, rule.static); ok {
+				return mapped_ip, true
+			}
+		}
+		// --- host is a destination of twice NAT, destination part is always static
+		if rule.dst_real != nil && isIfaceMatch(rule.mapped_iface, iface) && isIfaceMatch(rule.real_iface, other_iface) {
+			if mapped_ip, ok := translate(ip, rule.dst_real, rule.dst_mapped, true); ok {
+				return mapped_ip, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// fills mapped addresses not reported by syslog message
+func (nr NatRules) FillMapped(flow network_entities.Flow) network_entities.Flow {
+	if flow.Src_mapped_ip == 0 {
+		if mapped_ip, ok := nr.MappedIP(flow.Src_ip, flow.Src_iface, flow.Dst_iface); ok {
+			flow.Src_mapped_ip = mapped_ip
+			flow.Src_mapped_port = flow.Src_port
+		}
+	}
+	if flow.Dst_mapped_ip == 0 {
+		if mapped_ip, ok := nr.MappedDstIP(flow.Dst_ip, flow.Dst_iface, flow.Src_iface); ok {
+			flow.Dst_mapped_ip = mapped_ip
+			flow.Dst_mapped_port = flow.Dst_port
+		}
+	}
+	return flow
+}
@@ -0,0 +1,35 @@
+package ciscoasanat
+
+import (
+	"errors"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// address form ACLs are written against
+type AddressForm int
+
+const (
+	// --- ASA 8.3 and later, ACL uses real addresses
+	Real AddressForm = iota
+	// --- ASA before 8.3, ACL uses addresses as they seen on the interface ACL applied to
+	Mapped
+	// --- detected by "ASA Version" of the config, see Resolve
+	Auto
+)
+
+type natRule struct {
+	real_iface, mapped_iface string
+	static                   bool
+
+	src_real, src_mapped []utils.AddressObject
+	// --- twice NAT only
+	dst_real, dst_mapped []utils.AddressObject
+}
+
+// rules are kept in the order ASA evaluates them
+type NatRules struct {
+	rules []natRule
+}
+
+var ErrorUnknownAddressForm = errors.New("unknown ACL address form")
@@ -0,0 +1,120 @@
+package sh_ip_route
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// connected and local routes of interfaces with nameif and ip address
+func getConnectedRoutes(sh_run sh_run_pipe.Text) ([]routingEntry, error) {
+	var routing_entries []routingEntry
+
+	iface_addresses, err := FindIfaceAddresses(sh_run)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- map order is random, keep routes stable
+	iface_names := make([]string, 0, len(iface_addresses))
+	for iface_name := range iface_addresses {
+		iface_names = append(iface_names, iface_name)
+	}
+	sort.Strings(iface_names)
+
+	for _, iface_name := range iface_names {
+		iface_address := iface_addresses[iface_name]
+		routing_entries = append(routing_entries,
+			routingEntry{prefix: iface_address.Subnet, iface: iface_name, code: "C"},
+			routingEntry{prefix: utils.AddressObject{Start: iface_address.Ip, Finish: iface_address.Ip}, iface: iface_name, code: "L"},
+		)
+	}
+
+	return routing_entries, nil
+}
+
+// example:
+// route outside 0.0.0.0 0.0.0.0 123.123.123.2 1
+// route inside 10.0.0.0 255.0.0.0 192.168.0.2 10 track 1
+func parseStaticRoute(line string, ifaces map[string]string) (routingEntry, error) {
+	var routing_entry routingEntry
+
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return routing_entry, fmt.Errorf("not enough fields in static route (line: %v)", line)
+	}
+	if !isIface(ifaces, fields[1]) {
+		return routing_entry, fmt.Errorf("static route to unknown interface (line: %v)", line)
+	}
+
+	_, _prefix, err := utils.ParseSubnet(2, fields)
+	if err != nil {
+		return routing_entry, err
+	}
+	next_hop, err := utils.ParseIP(fields[4])
+	if err != nil {
+		return routing_entry, err
+	}
+
+	routing_entry = routingEntry{prefix: _prefix, iface: fields[1], next_hop: next_hop, code: "S", distance: 1}
+	if len(fields) > 5 {
+		if distance, err := strconv.ParseUint(fields[5], 10, 32); err == nil {
+			routing_entry.distance = uint32(distance)
+		}
+	}
+	if _prefix.Start == 0 && _prefix.Finish == 0xffffffff {
+		routing_entry.code = "S*"
+	}
+
+	return routing_entry, nil
+}
+
+// static routes with the best distance, others are floating (backup) routes
+func bestStaticRoutes(routing_entries []routingEntry) []routingEntry {
+	best := make(map[utils.AddressObject]uint32)
+	for _, re := range routing_entries {
+		if distance, ok := best[re.prefix]; !ok || re.distance < distance {
+			best[re.prefix] = re.distance
+		}
+	}
+
+	var result []routingEntry
+	for _, re := range routing_entries {
+		if re.distance == best[re.prefix] {
+			result = append(result, re)
+		}
+	}
+	return result
+}
+
+// routing table derived from show run if show route is not available:
+// connected subnets of interfaces and static routes. Dynamic routing is not known.
+func FitConfig(sh_run sh_run_pipe.Text) (RoutingTable, error) {
+	ifaces, err := findAllIfaceNames(sh_run)
+	if err != nil {
+		return RoutingTable{}, err
+	}
+
+	routing_entries, err := getConnectedRoutes(sh_run)
+	if err != nil {
+		return RoutingTable{}, err
+	}
+
+	var static_routes []routingEntry
+	for _, line := range sh_run.Prefix("route ") {
+		routing_entry, err := parseStaticRoute(line, ifaces)
+		if err != nil {
+			slog.Warn("static route skipped", "line", line, "err", err)
+			continue
+		}
+		static_routes = append(static_routes, routing_entry)
+	}
+	routing_entries = append(routing_entries, bestStaticRoutes(static_routes)...)
+
+	return newRoutingTable(routing_entries)
+}
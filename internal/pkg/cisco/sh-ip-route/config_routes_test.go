@@ -0,0 +1,46 @@
+package sh_ip_route
+
+import (
+	"reflect"
+	"testing"
+
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+)
+
+func TestFitConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		ip      uint32
+		want    []string
+		wantErr bool
+	}{
+		{name: "connected", ip: 0xc0a8000a, want: []string{"inside"}},
+		{name: "interface address", ip: 0xac100001, want: []string{"dmz"}},
+		{name: "default route", ip: 0x08080808, want: []string{"outside"}},
+		{name: "floating route is not used", ip: 0x0a020304, want: []string{"inside"}},
+		{name: "more specific route", ip: 0x0a010203, want: []string{"dmz"}},
+		{name: "ecmp", ip: 0x14010101, want: []string{"inside", "dmz"}},
+	}
+
+	sh_run, err := sh_run_pipe.Load("testdata/sh_run_routes_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, err := FitConfig(sh_run)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rt.GetIfaces(tt.ip)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RoutingTable.GetIfaces() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RoutingTable.GetIfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,37 +2,78 @@ package sh_ip_route
 
 import (
 	"errors"
-	"log"
+	"fmt"
 	"strings"
 
 	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 )
 
-var ifaces map[string]string
-
-func isIface(iface_name string) bool {
+func isIface(ifaces map[string]string, iface_name string) bool {
 	_, ok := ifaces[iface_name]
 	return ok
 }
 
-func findAllIfaceNames() (map[string]string, error) {
+func findAllIfaceNames(sh_run sh_run_pipe.Text) (map[string]string, error) {
+	ifaces := make(map[string]string)
 
-	iface_candidates := sh_run_pipe.Include("nameif").Exclude("no nameif")
+	iface_candidates := sh_run.Include("nameif").Exclude("no nameif")
 	if len(iface_candidates) == 0 {
-		error_message := "ERROR: no nameif found"
-		log.Println(error_message)
-		return nil, errors.New(error_message)
+		return nil, errors.New("no nameif found")
 	}
 
 	for _, iface_candidate := range iface_candidates {
 		fields := strings.Fields(iface_candidate)
 		if len(fields) < 2 {
-			error_message := "ERROR: can't parse nameif"
-			log.Println(error_message, ": ", iface_candidate)
-			return nil, errors.New(error_message)
+			return nil, fmt.Errorf("can't parse nameif (iface_candidate: %v)", iface_candidate)
 		}
 		ifaces[strings.Fields(iface_candidate)[1]] = ""
 	}
 
 	return ifaces, nil
 }
+
+// example:
+// interface GigabitEthernet0/0
+//
+//	nameif inside
+//	ip address 192.168.0.1 255.255.255.0 standby 192.168.0.2
+func FindIfaceAddresses(sh_run sh_run_pipe.Text) (map[string]IfaceAddress, error) {
+	result := make(map[string]IfaceAddress)
+
+	var iface_name string
+	var iface_address *IfaceAddress
+	save := func() {
+		if iface_name != "" && iface_address != nil {
+			result[iface_name] = *iface_address
+		}
+		iface_name, iface_address = "", nil
+	}
+
+	for _, line := range sh_run.Section("interface ") {
+		if !strings.HasPrefix(line, " ") {
+			save()
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 2 && fields[0] == "nameif":
+			iface_name = fields[1]
+		case len(fields) >= 4 && fields[0] == "ip" && fields[1] == "address":
+			_, subnet, err := utils.ParseSubnet(2, fields)
+			if err != nil {
+				// --- "ip address dhcp" and alike
+				continue
+			}
+			ip, err := utils.ParseIP(fields[2])
+			if err != nil {
+				return nil, err
+			}
+			iface_address = &IfaceAddress{Ip: ip, Subnet: subnet}
+		}
+	}
+	save()
+
+	return result, nil
+}
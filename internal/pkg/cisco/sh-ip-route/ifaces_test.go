@@ -22,10 +22,10 @@ func Test_findAllIfaceNames(t *testing.T) {
 			wantErr: false,
 		},
 	}
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := findAllIfaceNames()
+			got, err := findAllIfaceNames(sh_run)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("findAllIfaceNames() error = %v, wantErr %v", err, tt.wantErr)
 				return
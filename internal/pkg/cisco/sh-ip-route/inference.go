@@ -2,48 +2,52 @@ package sh_ip_route
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 )
 
-func (re *routingEntry) getIface(ip uint32) (string, error) {
-	if re.prefix.Start <= ip && ip <= re.prefix.Finish {
-		for _, child := range re.children {
-			if child.prefix.Start <= ip && ip <= child.prefix.Finish {
-				iface, err := child.getIface(ip)
-				if err != nil {
-					return "", err
-				}
-
-				if iface == "" {
-					return child.iface, nil
-				} else {
-					return iface, nil
-				}
-			}
+// returns interfaces of the longest prefix matching ip, ECMP paths may lead to several interfaces
+func (rt *RoutingTable) GetIfaces(ip uint32) ([]string, error) {
+	routes := rt.lookup(ip)
+	if len(routes) == 0 {
+		return nil, errors.New("no interface found for ip " + utils.IpToString(ip))
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, re := range routes {
+		if !seen[re.iface] {
+			seen[re.iface] = true
+			result = append(result, re.iface)
 		}
-		return re.iface, nil
 	}
 
-	return "", nil
+	return result, nil
 }
 
+// returns interface of the longest prefix matching ip, first path if ECMP leads to several interfaces
 func (rt *RoutingTable) GetIface(ip uint32) (string, error) {
-	for _, re := range rt.entry {
-		if re.parent == nil {
-			iface, err := re.getIface(ip)
-			if err != nil {
-				return "", err
-			}
-			if iface == "" {
-				error_msg := "ERROR: no interface found for ip " + utils.IpToString(ip)
-				fmt.Println(error_msg)
-				return "", errors.New(error_msg)
-			} else {
-				return iface, nil
-			}
+	ifaces, err := rt.GetIfaces(ip)
+	if err != nil {
+		return "", err
+	}
+
+	return ifaces[0], nil
+}
+
+// interfaces of the default route (0.0.0.0/0) in the global routing table, internet-facing as a rule
+func (rt *RoutingTable) DefaultRouteIfaces() []string {
+	if rt.root == nil {
+		return nil
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, re := range rt.root.routes {
+		if !seen[re.iface] {
+			seen[re.iface] = true
+			result = append(result, re.iface)
 		}
 	}
-	return "", nil
+	return result
 }
@@ -1,6 +1,7 @@
 package sh_ip_route
 
 import (
+	"reflect"
 	"testing"
 
 	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
@@ -11,8 +12,8 @@ func TestRoutingTable_GetIface(t *testing.T) {
 		ip uint32
 	}
 
-	sh_run_pipe.Load("testdata/sh_run_test.txt")
-	_rt, _ := Fit("testdata/sh_ip_route_test.txt")
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
+	_rt, _ := Fit(sh_run, "testdata/sh_ip_route_test.txt")
 
 	tests := []struct {
 		name    string
@@ -81,3 +82,27 @@ func TestRoutingTable_GetIface(t *testing.T) {
 		})
 	}
 }
+
+func TestRoutingTable_DefaultRouteIfaces(t *testing.T) {
+	sh_run, _ := sh_run_pipe.Load("testdata/sh_run_test.txt")
+	from_route, _ := Fit(sh_run, "testdata/sh_ip_route_test.txt")
+	sh_run_routes, _ := sh_run_pipe.Load("testdata/sh_run_routes_test.txt")
+	from_config, _ := FitConfig(sh_run_routes)
+
+	tests := []struct {
+		name string
+		rt   *RoutingTable
+		want []string
+	}{
+		{name: "show route", rt: &from_route, want: []string{"outside"}},
+		{name: "config", rt: &from_config, want: []string{"outside"}},
+		{name: "empty", rt: &RoutingTable{}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rt.DefaultRouteIfaces(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RoutingTable.DefaultRouteIfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,24 +2,16 @@ package sh_ip_route
 
 import (
 	"bufio"
-	"log"
+	"fmt"
+	"io"
 	"os"
 	"strings"
-)
-
-func init() {
-	ifaces = make(map[string]string)
-}
 
-func readFile(in_file string) ([]string, error) {
-	readFile, err := os.Open(in_file)
-	if err != nil {
-		log.Println("ERROR:", err)
-		return nil, err
-	}
-	defer readFile.Close()
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+)
 
-	fileScanner := bufio.NewScanner(readFile)
+func readLines(r io.Reader) ([]string, error) {
+	fileScanner := bufio.NewScanner(r)
 	fileScanner.Split(bufio.ScanLines)
 
 	var f_content []string
@@ -27,7 +19,7 @@ func readFile(in_file string) ([]string, error) {
 		f_content = append(f_content, fileScanner.Text())
 	}
 
-	return f_content, nil
+	return f_content, fileScanner.Err()
 }
 
 func cutoffHeader(f_content []string) []string {
@@ -41,21 +33,33 @@ func cutoffHeader(f_content []string) []string {
 	return f_content
 }
 
-func Fit(fname string) (RoutingTable, error) {
+// sh_run is used to find interface names of the device
+func Fit(sh_run sh_run_pipe.Text, fname string) (RoutingTable, error) {
+	readFile, err := os.Open(fname)
+	if err != nil {
+		return RoutingTable{}, fmt.Errorf("can't open routing table: %w", err)
+	}
+	defer readFile.Close()
+
+	return FitReader(sh_run, readFile)
+}
+
+// same as Fit, "show route" is read from r
+func FitReader(sh_run sh_run_pipe.Text, r io.Reader) (RoutingTable, error) {
 	var routing_table RoutingTable
 
-	f_content, err := readFile(fname)
+	f_content, err := readLines(r)
 	if err != nil {
 		return routing_table, err
 	}
 	f_content = cutoffHeader(f_content)
 
-	ifaces, err = findAllIfaceNames()
+	ifaces, err := findAllIfaceNames(sh_run)
 	if err != nil {
 		return routing_table, err
 	}
 
-	routing_table, err = parseRoutingTable(f_content)
+	routing_table, err = parseRoutingTable(f_content, ifaces)
 	if err != nil {
 		return routing_table, err
 	}
@@ -1,177 +1,199 @@
 package sh_ip_route
 
 import (
+	"errors"
 	"fmt"
-	"sort"
+	"log/slog"
+	"net/netip"
+	"strconv"
 	"strings"
 
 	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 )
 
-func isRecursiveRoute(fields []string) bool {
-	for _, field := range fields {
-		if field == "via" {
-			return true
-		}
+// codes from "show route" legend, "*" (candidate default) is stripped before lookup
+var routeCodes = map[string]bool{
+	"L": true, "C": true, "S": true, "R": true, "M": true, "B": true,
+	"D": true, "EX": true, "O": true, "IA": true, "N1": true, "N2": true,
+	"E1": true, "E2": true, "V": true, "i": true, "su": true, "L1": true,
+	"L2": true, "ia": true, "U": true, "o": true, "P": true, "+": true,
+	"SI": true,
+}
+
+func isRouteCode(field string) bool {
+	return routeCodes[strings.TrimSuffix(field, "*")]
+}
+
+func isIPv4(field string) bool {
+	ip, err := netip.ParseAddr(field)
+	return err == nil && ip.Is4()
+}
+
+// example: [110/20]
+func parseDistanceMetric(field string) (uint32, uint32, error) {
+	distance_metric := strings.Split(strings.Trim(field, "[]"), "/")
+	if len(distance_metric) != 2 {
+		return 0, 0, fmt.Errorf("can't parse administrative distance and metric (field: %v)", field)
 	}
-	return false
+	distance, err := strconv.ParseUint(distance_metric[0], 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	metric, err := strconv.ParseUint(distance_metric[1], 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(distance), uint32(metric), nil
 }
 
-func parseNextHop(fields []string) (uint32, error) {
-	for i, field := range fields {
-		if field == "via" {
-			ips := strings.Split(fields[i+1], ",")
-			if len(ips) == 0 {
-				error_message := "ERROR: can't split next hops by comma"
-				fmt.Printf("%v: %v\n", error_message, fields)
-				return 0, fmt.Errorf(error_message)
+// path part of the route, after the prefix or on a continuation line:
+// [1/0] via 123.123.123.2, outside
+// [200/0] via 192.168.0.2, 00:07:01
+// is directly connected, inside
+// connected by VPN (advertised), outside
+func parsePath(fields []string, ifaces map[string]string, re *routingEntry) error {
+	for i := 0; i < len(fields); i++ {
+		field := strings.TrimSuffix(fields[i], ",")
+		switch {
+		case strings.HasPrefix(field, "["):
+			distance, metric, err := parseDistanceMetric(field)
+			if err != nil {
+				return err
 			}
-			next_hop, err := utils.ParseIP(ips[0])
+			re.distance, re.metric = distance, metric
+		case field == "via" && i+1 < len(fields):
+			next_hop, err := utils.ParseIP(strings.TrimSuffix(fields[i+1], ","))
 			if err != nil {
-				return 0, err
+				return err
 			}
-			return next_hop, nil
+			re.next_hop = next_hop
+			i++
+		case isIface(ifaces, field):
+			re.iface = field
 		}
 	}
-	error_message := "ERROR: via not found in the recursive route"
-	fmt.Printf("%v: %v\n", error_message, fields)
-	return 0, fmt.Errorf(error_message)
+
+	if re.iface == "" && re.next_hop == 0 {
+		// --- caller prints the whole line
+		return errors.New("route has neither interface nor next hop")
+	}
+	return nil
+}
+
+// VRF section starts with "Routing Table: <vrf>" or with "show route vrf <vrf>" command
+func parseVRF(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if strings.HasPrefix(line, "Routing Table:") && len(fields) > 2 {
+		if fields[2] == "default" || fields[2] == "global" {
+			return "", true
+		}
+		return fields[2], true
+	}
+	for i := range fields {
+		if fields[i] == "route" && i+2 < len(fields) && fields[i+1] == "vrf" {
+			return fields[i+2], true
+		}
+	}
+	return "", false
 }
 
-func parseRoutingEntry(f_content []string) ([]routingEntry, error) {
+// parses routes of "show route", every path of ECMP route is a separate entry.
+// Path may be on the continuation line, it belongs to the prefix above:
+// O        10.1.0.0 255.255.0.0 [110/20] via 10.0.0.1, 0:01:02, inside
+//
+//	[110/20] via 10.0.0.2, 0:01:02, inside
+func parseRoutingEntry(f_content []string, ifaces map[string]string) ([]routingEntry, error) {
 	var routing_entries []routingEntry
+	var last routingEntry
+	has_last := false
+	vrf := ""
 
 	for _, line := range f_content {
-		fields := strings.Fields(line)
-		if len(fields) > 2 {
-			switch {
-			case isIface(fields[len(fields)-1]):
-				var routing_entry routingEntry
-
-				_, _prefix, err := utils.ParseSubnet(1, fields)
-				if err != nil {
-					return nil, err
-				}
-				routing_entry.prefix = _prefix
-				routing_entry.iface = fields[len(fields)-1]
-
-				routing_entries = append(routing_entries, routing_entry)
-			case isRecursiveRoute(fields):
-				var routing_entry routingEntry
-
-				_, _prefix, err := utils.ParseSubnet(1, fields)
-				if err != nil {
-					return nil, err
-				}
-
-				next_hop, err := parseNextHop(fields)
-				if err != nil {
-					return nil, err
-				}
-				routing_entry.prefix = _prefix
-				routing_entry.next_hop = next_hop
-
-				routing_entries = append(routing_entries, routing_entry)
-			default:
-				error_message := "ERROR: can't parse routing entry"
-				fmt.Printf("%v: %v\n", error_message, line)
-				// return nil, fmt.Errorf("%v: %v", error_message, line)
-			}
+		if _vrf, ok := parseVRF(line); ok {
+			vrf = _vrf
+			has_last = false
+			continue
 		}
-	}
-
-	return routing_entries, nil
-}
 
-func (rt *RoutingTable) BuildTree() error {
-	sort.Slice(rt.entry, func(i, j int) bool {
-		subnet_i := rt.entry[i].prefix.Finish - rt.entry[i].prefix.Start
-		subnet_j := rt.entry[j].prefix.Finish - rt.entry[j].prefix.Start
-		return subnet_i > subnet_j
-	})
-
-	// print routing table
-	// fmt.Println("--- Routing table")
-	// for _, re := range rt.entry {
-	// 	fmt.Println(&re)
-	// }
-
-	for i, _ := range rt.entry {
-		for j := i - 1; j >= 0; j-- {
-			_child := &rt.entry[i]
-			_parent := &rt.entry[j]
-			if _child.prefix.Start >= _parent.prefix.Start && _child.prefix.Finish <= _parent.prefix.Finish {
-				_child.parent = _parent
-				_parent.children = append(_parent.children, _child)
-				break
-			}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
 		}
-	}
 
-	return nil
-}
+		var path_fields []string
+		switch {
+		case isRouteCode(fields[0]):
+			// --- code may take several fields: "O E2", "D EX", "S*"
+			ip_pos := 1
+			for ip_pos < len(fields) && ip_pos < 4 && !isIPv4(fields[ip_pos]) {
+				ip_pos++
+			}
+			if ip_pos >= len(fields) || !isIPv4(fields[ip_pos]) {
+				// --- legend of codes
+				continue
+			}
 
-func (rt *RoutingTable) fixUnknownIfaces() error {
-	for i, _ := range rt.entry {
-		if rt.entry[i].iface == "" {
-			nh := rt.entry[i].next_hop
-			iface, err := rt.GetIface(nh)
+			next_pos, _prefix, err := utils.ParseSubnet(uint(ip_pos), fields)
 			if err != nil {
-				return err
+				return nil, err
+			}
+			last = routingEntry{
+				prefix: _prefix,
+				code:   strings.Join(fields[:ip_pos], " "),
+				vrf:    vrf,
 			}
-			rt.entry[i].iface = iface
+			has_last = true
+			path_fields = fields[next_pos:]
+			if len(path_fields) == 0 {
+				// --- long prefix, path is on the next line
+				continue
+			}
+		case has_last && (strings.HasPrefix(fields[0], "[") || fields[0] == "via" || fields[0] == "is"):
+			path_fields = fields
+		default:
+			has_last = false
+			continue
 		}
-	}
-
-	return nil
-}
 
-func (rt *RoutingTable) PrintTree() {
-	fmt.Println("--- Routing table")
-	for _, re := range rt.entry {
-		if re.parent == nil {
-			re.printTree()
+		routing_entry := last
+		err := parsePath(path_fields, ifaces, &routing_entry)
+		if err != nil {
+			slog.Warn("routing entry skipped", "line", line, "err", err)
+			continue
 		}
+		routing_entries = append(routing_entries, routing_entry)
 	}
-}
 
-func (re *routingEntry) printTree() {
-	if len(re.children) == 0 {
-		return
-	}
+	return routing_entries, nil
+}
 
-	fmt.Println("----")
-	if re.parent == nil {
-		fmt.Print("root ")
+func (re *routingEntry) String() string {
+	vrf := ""
+	if re.vrf != "" {
+		vrf = "vrf " + re.vrf + " "
 	}
-	fmt.Println("parent: ", re)
-	for _, child := range re.children {
-		fmt.Println("child:", child)
+	if re.next_hop == 0 {
+		return fmt.Sprintf("%v%v %v-%v [%v/%v] %v", vrf, re.code, utils.IpToString(re.prefix.Start), utils.IpToString(re.prefix.Finish), re.distance, re.metric, re.iface)
 	}
 
-	for _, child := range re.children {
-		child.printTree()
-	}
+	return fmt.Sprintf("%v%v %v-%v [%v/%v] via %v %v", vrf, re.code, utils.IpToString(re.prefix.Start), utils.IpToString(re.prefix.Finish), re.distance, re.metric, utils.IpToString(re.next_hop), re.iface)
 }
 
-func (re *routingEntry) String() string {
-	if re.next_hop == 0 {
-		return fmt.Sprintf("%v-%v %v", utils.IpToString(re.prefix.Start), utils.IpToString(re.prefix.Finish), re.iface)
+func parseRoutingTable(f_content []string, ifaces map[string]string) (RoutingTable, error) {
+	_re, err := parseRoutingEntry(f_content, ifaces)
+	if err != nil {
+		return RoutingTable{}, err
 	}
 
-	return fmt.Sprintf("%v-%v via %v %v", utils.IpToString(re.prefix.Start), utils.IpToString(re.prefix.Finish), utils.IpToString(re.next_hop), re.iface)
+	return newRoutingTable(_re)
 }
 
-func parseRoutingTable(f_content []string) (RoutingTable, error) {
+// builds lookup trie over routes, recursive routes get interfaces
+func newRoutingTable(entries []routingEntry) (RoutingTable, error) {
 	var routing_table RoutingTable
+	routing_table.entry = append(routing_table.entry, entries...)
 
-	_re, err := parseRoutingEntry(f_content)
-	if err != nil {
-		return routing_table, err
-	}
-	routing_table.entry = append(routing_table.entry, _re...)
-
-	err = routing_table.BuildTree()
+	err := routing_table.BuildTree()
 	if err != nil {
 		return routing_table, err
 	}
@@ -31,6 +31,7 @@ func Test_parseRoutingEntryStaticAndConnected(t *testing.T) {
 						Finish: 0x0a0b0cff,
 					},
 					iface: "inside",
+					code:  "C",
 				},
 			},
 			wantErr: false,
@@ -50,13 +51,17 @@ func Test_parseRoutingEntryStaticAndConnected(t *testing.T) {
 						Finish: 0x0a0b0cff,
 					},
 					iface: "inside",
+					code:  "C",
 				},
 				{
 					prefix: utils.AddressObject{
 						Start:  0x0,
 						Finish: 0xffffffff,
 					},
-					iface: "outside",
+					iface:    "outside",
+					next_hop: 0x7b7b7b02,
+					code:     "S*",
+					distance: 1,
 				},
 			},
 			wantErr: false,
@@ -74,20 +79,140 @@ func Test_parseRoutingEntryStaticAndConnected(t *testing.T) {
 						Start:  0x0,
 						Finish: 0xffffffff,
 					},
-					iface: "outside",
+					iface:    "outside",
+					next_hop: 0x7b7b7b02,
+					code:     "S*",
+					distance: 1,
 				},
 			},
 			wantErr: false,
 		},
+		{
+			name: "ecmp on continuation line",
+			args: args{
+				f_content: []string{
+					"O        10.1.0.0 255.255.0.0 [110/20] via 10.0.0.1, 0:01:02, inside",
+					"                             [110/20] via 10.0.0.2, 0:01:02, outside",
+				},
+			},
+			want: []routingEntry{
+				{
+					prefix:   utils.AddressObject{Start: 0x0a010000, Finish: 0x0a01ffff},
+					iface:    "inside",
+					next_hop: 0x0a000001,
+					code:     "O",
+					distance: 110,
+					metric:   20,
+				},
+				{
+					prefix:   utils.AddressObject{Start: 0x0a010000, Finish: 0x0a01ffff},
+					iface:    "outside",
+					next_hop: 0x0a000002,
+					code:     "O",
+					distance: 110,
+					metric:   20,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "two-field code, path on the next line",
+			args: args{
+				f_content: []string{
+					"O E2     10.2.0.0 255.255.0.0",
+					"           [110/20] via 10.0.0.1, 1d02h, inside",
+				},
+			},
+			want: []routingEntry{
+				{
+					prefix:   utils.AddressObject{Start: 0x0a020000, Finish: 0x0a02ffff},
+					iface:    "inside",
+					next_hop: 0x0a000001,
+					code:     "O E2",
+					distance: 110,
+					metric:   20,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "local, vpn and recursive bgp",
+			args: args{
+				f_content: []string{
+					"L        123.123.123.1 255.255.255.255 is directly connected, outside",
+					"V        10.5.5.0 255.255.255.0 connected by VPN (advertised), outside",
+					"B        10.10.9.0 255.255.255.0 [200/0] via 192.168.0.2, 00:07:01",
+				},
+			},
+			want: []routingEntry{
+				{
+					prefix: utils.AddressObject{Start: 0x7b7b7b01, Finish: 0x7b7b7b01},
+					iface:  "outside",
+					code:   "L",
+				},
+				{
+					prefix: utils.AddressObject{Start: 0x0a050500, Finish: 0x0a0505ff},
+					iface:  "outside",
+					code:   "V",
+				},
+				{
+					prefix:   utils.AddressObject{Start: 0x0a0a0900, Finish: 0x0a0a09ff},
+					next_hop: 0xc0a80002,
+					code:     "B",
+					distance: 200,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "vrf",
+			args: args{
+				f_content: []string{
+					"C        10.11.12.0 255.255.255.0 is directly connected, inside",
+					"",
+					"ciscoasa# show route vrf red",
+					"Gateway of last resort is not set",
+					"",
+					"S        10.11.12.0 255.255.255.0 [1/0] via 10.0.0.1, outside",
+				},
+			},
+			want: []routingEntry{
+				{
+					prefix: utils.AddressObject{Start: 0x0a0b0c00, Finish: 0x0a0b0cff},
+					iface:  "inside",
+					code:   "C",
+				},
+				{
+					prefix:   utils.AddressObject{Start: 0x0a0b0c00, Finish: 0x0a0b0cff},
+					iface:    "outside",
+					next_hop: 0x0a000001,
+					code:     "S",
+					distance: 1,
+					vrf:      "red",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "legend of codes",
+			args: args{
+				f_content: []string{
+					"       D - EIGRP, EX - EIGRP external, O - OSPF, IA - OSPF inter area",
+					"       i - IS-IS, su - IS-IS summary, L1 - IS-IS level-1, L2 - IS-IS level-2",
+				},
+			},
+			want:    nil,
+			wantErr: false,
+		},
 	}
 
-	ifaces = make(map[string]string)
+	ifaces := make(map[string]string)
 	ifaces["inside"] = ""
 	ifaces["outside"] = ""
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseRoutingEntry(tt.args.f_content)
+			got, err := parseRoutingEntry(tt.args.f_content, ifaces)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseRoutingEntryStaticAndConnected() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,191 @@
+package sh_ip_route
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// next hop may be reached via another recursive route, stop at this depth (routing loop)
+const maxRecursion = 8
+
+func prefixLen(prefix utils.AddressObject) (int, error) {
+	size := uint64(prefix.Finish) - uint64(prefix.Start) + 1
+	if bits.OnesCount64(size) != 1 || uint64(prefix.Start)%size != 0 {
+		return 0, fmt.Errorf("routing prefix is not a subnet (start: %v, finish: %v)", utils.IpToString(prefix.Start), utils.IpToString(prefix.Finish))
+	}
+	return 32 - bits.TrailingZeros64(size), nil
+}
+
+func bitAt(ip uint32, depth int) int {
+	return int(ip>>(31-depth)) & 1
+}
+
+// trie of the global routing table or of the VRF
+func (rt *RoutingTable) getRoot(vrf string) *trieNode {
+	if vrf == "" {
+		return rt.root
+	}
+	return rt.vrf_roots[vrf]
+}
+
+func (rt *RoutingTable) insert(re *routingEntry) error {
+	length, err := prefixLen(re.prefix)
+	if err != nil {
+		return err
+	}
+
+	if rt.root == nil {
+		rt.root = &trieNode{}
+	}
+	if rt.vrf_roots == nil {
+		rt.vrf_roots = make(map[string]*trieNode)
+	}
+	node := rt.getRoot(re.vrf)
+	if node == nil {
+		node = &trieNode{}
+		rt.vrf_roots[re.vrf] = node
+	}
+	for depth := 0; depth < length; depth++ {
+		bit := bitAt(re.prefix.Start, depth)
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.routes = append(node.routes, re)
+
+	return nil
+}
+
+// builds trie over rt.entry, same prefix listed several times is ECMP
+func (rt *RoutingTable) BuildTree() error {
+	rt.root = &trieNode{}
+	rt.vrf_roots = make(map[string]*trieNode)
+	for i := range rt.entry {
+		err := rt.insert(&rt.entry[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// returns paths of the longest prefix covering ip in the global routing table,
+// VRFs are looked up in name order if global table has no route
+func (rt *RoutingTable) lookup(ip uint32) []*routingEntry {
+	result := rt.root.lookup(ip)
+	if len(result) > 0 {
+		return result
+	}
+
+	vrfs := make([]string, 0, len(rt.vrf_roots))
+	for vrf := range rt.vrf_roots {
+		vrfs = append(vrfs, vrf)
+	}
+	sort.Strings(vrfs)
+	for _, vrf := range vrfs {
+		result = rt.vrf_roots[vrf].lookup(ip)
+		if len(result) > 0 {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// returns paths of the longest prefix covering ip in the trie, nil if no route
+func (root *trieNode) lookup(ip uint32) []*routingEntry {
+	var result []*routingEntry
+
+	node := root
+	for depth := 0; node != nil; depth++ {
+		if len(node.routes) > 0 {
+			result = node.routes
+		}
+		if depth == 32 {
+			break
+		}
+		node = node.children[bitAt(ip, depth)]
+	}
+
+	return result
+}
+
+func (rt *RoutingTable) resolveIface(re *routingEntry, depth int) (string, error) {
+	if re.iface != "" {
+		return re.iface, nil
+	}
+	if depth >= maxRecursion {
+		return "", fmt.Errorf("next hop recursion is too deep (re: %v)", re)
+	}
+
+	// --- next hop is resolved in the VRF of the route
+	for _, next := range rt.getRoot(re.vrf).lookup(re.next_hop) {
+		if next == re {
+			continue
+		}
+		iface, err := rt.resolveIface(next, depth+1)
+		if err != nil {
+			return "", err
+		}
+		if iface != "" {
+			return iface, nil
+		}
+	}
+
+	return "", fmt.Errorf("can't resolve next hop (re: %v)", re)
+}
+
+// recursive routes get interface of the route to their next hop
+func (rt *RoutingTable) fixUnknownIfaces() error {
+	var ifaces []string
+	for i := range rt.entry {
+		iface, err := rt.resolveIface(&rt.entry[i], 0)
+		if err != nil {
+			return err
+		}
+		ifaces = append(ifaces, iface)
+	}
+
+	// --- assign after all resolved, resolution must not depend on entries order
+	for i := range rt.entry {
+		rt.entry[i].iface = ifaces[i]
+	}
+
+	return nil
+}
+
+func (rt *RoutingTable) PrintTree(w io.Writer) {
+	fmt.Fprintln(w, "--- Routing table")
+	rt.root.printTree(w, 0)
+
+	vrfs := make([]string, 0, len(rt.vrf_roots))
+	for vrf := range rt.vrf_roots {
+		vrfs = append(vrfs, vrf)
+	}
+	sort.Strings(vrfs)
+	for _, vrf := range vrfs {
+		fmt.Fprintln(w, "--- Routing table vrf", vrf)
+		rt.vrf_roots[vrf].printTree(w, 0)
+	}
+}
+
+func (node *trieNode) printTree(w io.Writer, indent int) {
+	if node == nil {
+		return
+	}
+
+	if len(node.routes) > 0 {
+		for _, re := range node.routes {
+			fmt.Fprintf(w, "%*s%v\n", indent*2, "", re)
+		}
+		indent++
+	}
+	node.children[0].printTree(w, indent)
+	node.children[1].printTree(w, indent)
+}
@@ -0,0 +1,159 @@
+package sh_ip_route
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func newTestRoutingTable(t *testing.T, f_content []string) (RoutingTable, error) {
+	ifaces := map[string]string{"inside": "", "outside": "", "dmz": ""}
+	return parseRoutingTable(f_content, ifaces)
+}
+
+func TestRoutingTable_GetIfaces(t *testing.T) {
+	f_content := []string{
+		"S*       0.0.0.0 0.0.0.0 [1/0] via 123.123.123.2, outside",
+		"C        123.123.123.0 255.255.255.0 is directly connected, outside",
+		"C        192.168.0.0 255.255.255.0 is directly connected, inside",
+		"C        172.16.0.0 255.255.255.0 is directly connected, dmz",
+		// --- overlapping prefixes listed from specific to summary
+		"S        10.1.1.0 255.255.255.128 [1/0] via 172.16.0.2, dmz",
+		"S        10.1.0.0 255.255.0.0 [1/0] via 192.168.0.2, inside",
+		"S        10.1.1.0 255.255.255.0 [1/0] via 123.123.123.3, outside",
+		// --- ECMP
+		"S        20.0.0.0 255.0.0.0 [1/0] via 192.168.0.2, inside",
+		"S        20.0.0.0 255.0.0.0 [1/0] via 172.16.0.2, dmz",
+		// --- recursive chain: 30/8 -> 40.0.0.1 -> 172.16.0.2 (dmz)
+		"B        30.0.0.0 255.0.0.0 [200/0] via 40.0.0.1, 00:07:01",
+		"B        40.0.0.0 255.255.255.0 [200/0] via 172.16.0.2, 00:07:01",
+	}
+
+	tests := []struct {
+		name    string
+		ip      uint32
+		want    []string
+		wantErr bool
+	}{
+		{name: "default route", ip: 0x08080808, want: []string{"outside"}},
+		{name: "most specific of overlapping /25", ip: 0x0a010105, want: []string{"dmz"}},
+		{name: "most specific of overlapping /24", ip: 0x0a0101f0, want: []string{"outside"}},
+		{name: "summary /16", ip: 0x0a010205, want: []string{"inside"}},
+		{name: "ecmp", ip: 0x14010101, want: []string{"inside", "dmz"}},
+		{name: "recursive next hop", ip: 0x1e010101, want: []string{"dmz"}},
+	}
+
+	rt, err := newTestRoutingTable(t, f_content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rt.GetIfaces(tt.ip)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RoutingTable.GetIfaces() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RoutingTable.GetIfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutingTable_GetIfaces_vrf(t *testing.T) {
+	rt, err := newTestRoutingTable(t, []string{
+		"C        10.0.0.0 255.0.0.0 is directly connected, inside",
+		"Routing Table: red",
+		"C        10.0.0.0 255.255.255.0 is directly connected, outside",
+		"B        172.20.0.0 255.255.0.0 [200/0] via 10.0.0.1, 00:07:01",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ip   uint32
+		want []string
+	}{
+		{name: "global table first", ip: 0x0a000005, want: []string{"inside"}},
+		{name: "next hop resolved in vrf", ip: 0xac140101, want: []string{"outside"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rt.GetIfaces(tt.ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RoutingTable.GetIfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseRoutingTable_unresolved(t *testing.T) {
+	tests := []struct {
+		name      string
+		f_content []string
+		wantErr   bool
+	}{
+		{
+			name: "next hop without route",
+			f_content: []string{
+				"B        30.0.0.0 255.0.0.0 [200/0] via 40.0.0.1, 00:07:01",
+			},
+			wantErr: true,
+		},
+		{
+			name: "routing loop",
+			f_content: []string{
+				"B        30.0.0.0 255.0.0.0 [200/0] via 40.0.0.1, 00:07:01",
+				"B        40.0.0.0 255.0.0.0 [200/0] via 30.0.0.1, 00:07:01",
+			},
+			wantErr: true,
+		},
+		{
+			name: "no default route, lookup of unknown ip fails later",
+			f_content: []string{
+				"C        192.168.0.0 255.255.255.0 is directly connected, inside",
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestRoutingTable(t, tt.f_content)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRoutingTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoutingTable_GetIface_concurrent(t *testing.T) {
+	rt, err := newTestRoutingTable(t, []string{
+		"S*       0.0.0.0 0.0.0.0 [1/0] via 123.123.123.2, outside",
+		"C        192.168.0.0 255.255.255.0 is directly connected, inside",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				iface, err := rt.GetIface(0xc0a80000 + uint32(j%256))
+				if err != nil || iface != "inside" {
+					t.Errorf("RoutingTable.GetIface() = %v, %v, want inside", iface, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -2,14 +2,37 @@ package sh_ip_route
 
 import "github.com/ivankuchin/excessive-acl/internal/pkg/utils"
 
+// single path to the prefix, ECMP prefix has an entry per path
 type routingEntry struct {
 	prefix   utils.AddressObject
 	iface    string
 	next_hop uint32
-	parent   *routingEntry
-	children []*routingEntry
+	// --- as listed in show route: "S*", "O E2", "C", "L", ...
+	code     string
+	distance uint32
+	metric   uint32
+	// --- empty for the global routing table
+	vrf string
 }
 
+// node of binary trie, level N keeps prefixes of length N
+type trieNode struct {
+	children [2]*trieNode
+	// --- paths to the prefix ending at this node, more than one is ECMP
+	routes []*routingEntry
+}
+
+// trie is not modified after Fit, concurrent lookups are safe
 type RoutingTable struct {
 	entry []routingEntry
+	// --- global routing table
+	root *trieNode
+	// --- routing tables of VRFs by name
+	vrf_roots map[string]*trieNode
+}
+
+// address configured on a named interface
+type IfaceAddress struct {
+	Ip     uint32
+	Subnet utils.AddressObject
 }
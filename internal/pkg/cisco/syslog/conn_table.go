@@ -0,0 +1,63 @@
+package syslog
+
+import (
+	"sort"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+func newConnTable() *connTable {
+	return &connTable{pending: make(map[string]pendingConn)}
+}
+
+// returns flows ready to be matched against ACLs
+func (ct *connTable) add(ev event) []network_entities.Flow {
+	switch ev.kind {
+	case eventFlow:
+		return []network_entities.Flow{ev.flow}
+	case eventBuild:
+		var result []network_entities.Flow
+		// --- connection number reused without teardown seen, previous flow is complete
+		if prev, ok := ct.pending[ev.conn_key]; ok {
+			result = append(result, prev.flow)
+		}
+		ct.seq++
+		ct.pending[ev.conn_key] = pendingConn{seq: ct.seq, flow: ev.flow}
+		return result
+	case eventTeardown:
+		conn, ok := ct.pending[ev.conn_key]
+		if !ok {
+			// --- connection built before the log started, direction is unknown
+			return nil
+		}
+		delete(ct.pending, ev.conn_key)
+
+		if ev.flow.Accounted {
+			conn.flow.Accounted = true
+			conn.flow.Bytes = ev.flow.Bytes
+			conn.flow.Duration = ev.flow.Duration
+		}
+		return []network_entities.Flow{conn.flow}
+	default:
+		return nil
+	}
+}
+
+// returns connections without teardown in the order they were built
+func (ct *connTable) flush() []network_entities.Flow {
+	conns := make([]pendingConn, 0, len(ct.pending))
+	for _, conn := range ct.pending {
+		conns = append(conns, conn)
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].seq < conns[j].seq
+	})
+
+	result := make([]network_entities.Flow, 0, len(conns))
+	for _, conn := range conns {
+		result = append(result, conn.flow)
+	}
+	ct.pending = make(map[string]pendingConn)
+
+	return result
+}
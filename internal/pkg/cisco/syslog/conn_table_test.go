@@ -0,0 +1,88 @@
+package syslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+func Test_connTable_add(t *testing.T) {
+	tcp := &network_entities.Protocol{Title: "tcp", Id: 6}
+	flow1 := network_entities.Flow{Protocol: tcp, Src_ip: 0x0a0a0a0a, Dst_ip: 0x96969696, Dst_port: 22, Icmp_type: -1, Icmp_code: -1}
+	flow2 := network_entities.Flow{Protocol: tcp, Src_ip: 0x0a0a0a0b, Dst_ip: 0x96969696, Dst_port: 22, Icmp_type: -1, Icmp_code: -1}
+
+	accounted := flow1
+	accounted.Accounted = true
+	accounted.Bytes = 3214
+	accounted.Duration = time.Minute
+
+	tests := []struct {
+		name   string
+		events []event
+		want   []network_entities.Flow
+		flush  []network_entities.Flow
+	}{
+		{
+			name: "build and teardown",
+			events: []event{
+				{kind: eventBuild, conn_key: "conn 54", flow: flow1},
+				{kind: eventTeardown, conn_key: "conn 54", flow: network_entities.Flow{Accounted: true, Bytes: 3214, Duration: time.Minute}},
+			},
+			want:  []network_entities.Flow{accounted},
+			flush: []network_entities.Flow{},
+		},
+		{
+			name: "teardown without build",
+			events: []event{
+				{kind: eventTeardown, conn_key: "conn 54", flow: network_entities.Flow{Accounted: true, Bytes: 3214, Duration: time.Minute}},
+			},
+			want:  nil,
+			flush: []network_entities.Flow{},
+		},
+		{
+			name: "build without teardown",
+			events: []event{
+				{kind: eventBuild, conn_key: "conn 55", flow: flow2},
+				{kind: eventBuild, conn_key: "conn 54", flow: flow1},
+			},
+			want:  nil,
+			flush: []network_entities.Flow{flow2, flow1},
+		},
+		{
+			name: "connection number reused",
+			events: []event{
+				{kind: eventBuild, conn_key: "conn 54", flow: flow1},
+				{kind: eventBuild, conn_key: "conn 54", flow: flow2},
+			},
+			want:  []network_entities.Flow{flow1},
+			flush: []network_entities.Flow{flow2},
+		},
+		{
+			name: "icmp teardown without accounting",
+			events: []event{
+				{kind: eventBuild, conn_key: "icmp 1.1.1.1/0 2.2.2.2/1 2.2.2.2/1", flow: flow1},
+				{kind: eventTeardown, conn_key: "icmp 1.1.1.1/0 2.2.2.2/1 2.2.2.2/1"},
+			},
+			want:  []network_entities.Flow{flow1},
+			flush: []network_entities.Flow{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := newConnTable()
+
+			var got []network_entities.Flow
+			for _, ev := range tt.events {
+				got = append(got, ct.add(ev)...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("connTable.add() = %v, want %v", got, tt.want)
+			}
+			if flush := ct.flush(); !reflect.DeepEqual(flush, tt.flush) {
+				t.Errorf("connTable.flush() = %v, want %v", flush, tt.flush)
+			}
+		})
+	}
+}
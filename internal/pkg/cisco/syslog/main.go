@@ -3,48 +3,227 @@ package syslog
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"sync"
+	"time"
 
 	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 )
 
-func load(app_ctx app_context.AppContext, in_file string) error {
-	readFile, err := os.Open(in_file)
-	if err != nil {
-		fmt.Println("ERROR:", err)
-		return err
+const batchSize = 1024
+
+// streamed syslog may pause, lines read so far are parsed at least that often
+const flushInterval = time.Second
+
+func sendFlows(app_ctx app_context.AppContext, flows []network_entities.Flow) {
+	for _, flow := range flows {
+		if flow.Protocol == nil {
+			continue
+		}
+		if !app_ctx.IsInTimeWindow(flow) {
+			continue
+		}
+
+		app_ctx.Flows <- flow
 	}
+}
 
-	fileScanner := bufio.NewScanner(readFile)
-	fileScanner.Split(bufio.ScanLines)
+func readBatches(fileScanner *bufio.Scanner, batches chan<- lineBatch) {
+	defer close(batches)
 
-	go func() {
-		defer close(app_ctx.Flows)
-		defer readFile.Close()
+	batch := lineBatch{first_line: 1, lines: make([]string, 0, batchSize)}
+	for fileScanner.Scan() {
+		batch.lines = append(batch.lines, fileScanner.Text())
+		if len(batch.lines) < batchSize {
+			continue
+		}
+
+		batches <- batch
+		batch = batch.next()
+	}
+
+	if len(batch.lines) > 0 {
+		batches <- batch
+	}
+}
+
+// same as readBatches, but partial batch is sent every flushInterval:
+// lines of a quiet stream don't wait for the batch to fill up
+func readStreamBatches(fileScanner *bufio.Scanner, batches chan<- lineBatch) {
+	defer close(batches)
 
+	lines := make(chan string, batchSize)
+	go func() {
+		defer close(lines)
 		for fileScanner.Scan() {
-			record := fileScanner.Text()
+			lines <- fileScanner.Text()
+		}
+	}()
 
-			flow, err := parseRecord(record, app_ctx)
-			if err != nil {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := lineBatch{first_line: 1, lines: make([]string, 0, batchSize)}
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if len(batch.lines) > 0 {
+					batches <- batch
+				}
 				return
 			}
-			if flow.Protocol == nil {
+			batch.lines = append(batch.lines, line)
+			if len(batch.lines) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch.lines) == 0 {
+				continue
+			}
+		}
+
+		batches <- batch
+		batch = batch.next()
+	}
+}
+
+// lines that can't be parsed are counted and skipped, one broken line doesn't stop the rest of syslog
+func parseBatches(app_ctx app_context.AppContext, batches <-chan lineBatch, results chan<- eventBatch) {
+	for batch := range batches {
+		result := eventBatch{seq: batch.seq, events: make([]event, 0, len(batch.lines))}
+		for i, record := range batch.lines {
+			app_ctx.Parser_stats.AddLine()
+			ev, err := parseRecord(record, app_ctx)
+			if err != nil {
+				app_ctx.Parser_stats.AddError()
+				slog.Warn("syslog line skipped", "line", batch.first_line+uint64(i), "err", err)
+				if result.skipped == 0 {
+					result.err = fmt.Errorf("line %d: %w", batch.first_line+uint64(i), err)
+				}
+				result.skipped++
 				continue
 			}
+			result.events = append(result.events, ev)
+		}
+
+		results <- result
+	}
+}
+
+// feeds connection table in file order, batches may come from workers in any order.
+// Returns lines skipped, nil if every line is parsed.
+func collectBatches(app_ctx app_context.AppContext, results <-chan eventBatch) *SkippedLinesError {
+	var skipped SkippedLinesError
+	conns := newConnTable()
+	pending := make(map[uint64]eventBatch)
+	var next uint64
+
+	for result := range results {
+		pending[result.seq] = result
+
+		for {
+			batch, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			for _, ev := range batch.events {
+				sendFlows(app_ctx, conns.add(ev))
+			}
+			if skipped.Lines == 0 {
+				skipped.First = batch.err
+			}
+			skipped.Lines += batch.skipped
+		}
+	}
+
+	// --- connections without teardown are analyzed as well
+	sendFlows(app_ctx, conns.flush())
+
+	if skipped.Lines == 0 {
+		return nil
+	}
+	return &skipped
+}
+
+// r is closed after the last line is read, stream is read by readStreamBatches.
+// wait returns error of reading or *SkippedLinesError, it must be called after app_ctx.Flows is closed.
+func load(app_ctx app_context.AppContext, r io.ReadCloser, num_workers int, stream bool) (wait func() error) {
+	if num_workers < 1 {
+		num_workers = 1
+	}
+
+	fileScanner := bufio.NewScanner(r)
+	fileScanner.Split(bufio.ScanLines)
 
-			app_ctx.Flows <- flow
+	batches := make(chan lineBatch, num_workers)
+	results := make(chan eventBatch, num_workers)
+	var read_err error
+	var skipped *SkippedLinesError
+
+	go func() {
+		defer r.Close()
+		if stream {
+			readStreamBatches(fileScanner, batches)
+		} else {
+			readBatches(fileScanner, batches)
 		}
 	}()
 
-	return nil
+	var wg sync.WaitGroup
+	for i := 0; i < num_workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parseBatches(app_ctx, batches, results)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(app_ctx.Flows)
+		skipped = collectBatches(app_ctx, results)
+		// --- scanner is done: batches are closed before the last result
+		read_err = fileScanner.Err()
+	}()
+
+	return func() error {
+		if read_err != nil {
+			return fmt.Errorf("can't read syslog: %w", read_err)
+		}
+		if skipped != nil {
+			return skipped
+		}
+		return nil
+	}
 }
 
-func Fit(app_ctx app_context.AppContext, in_file string) error {
-	err := load(app_ctx, in_file)
+// parses syslog in num_workers goroutines, flows are sent to app_ctx.Flows in file order.
+// wait returns error of reading or *SkippedLinesError, it must be called after app_ctx.Flows is closed.
+func Fit(app_ctx app_context.AppContext, in_file string, num_workers int) (wait func() error, err error) {
+	// --- streamed syslog, ex: tail -F syslog | excessive-acl serve -s -
+	if in_file == "-" {
+		return load(app_ctx, os.Stdin, num_workers, true), nil
+	}
+
+	readFile, err := os.Open(in_file)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("can't open syslog: %w", err)
 	}
 
-	return nil
+	return load(app_ctx, readFile, num_workers, false), nil
+}
+
+// same as Fit, syslog is read from r. r is closed when read.
+func FitReader(app_ctx app_context.AppContext, r io.ReadCloser, num_workers int) (wait func() error) {
+	return load(app_ctx, r, num_workers, false)
 }
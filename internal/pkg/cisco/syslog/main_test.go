@@ -0,0 +1,151 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// connections overlap batch boundaries, teardown of connection N comes after build of N+1
+func writeSyslog(t *testing.T, conns int) string {
+	var sb strings.Builder
+	for i := 0; i < conns; i++ {
+		fmt.Fprintf(&sb, "%%ASA-6-302013: Built inbound TCP connection %d for outside:150.150.150.150/%d (150.150.150.150/%d) to dmz:172.16.16.16/22 (123.123.123.10/22)\n", i, 1024+i, 1024+i)
+		if i > 0 {
+			fmt.Fprintf(&sb, "%%ASA-6-302014: Teardown TCP connection %d for outside:150.150.150.150/%d to dmz:172.16.16.16/22 duration 0:00:01 bytes %d TCP FINs\n", i-1, 1024+i-1, i-1)
+		}
+	}
+
+	in_file := filepath.Join(t.TempDir(), "syslog")
+	err := os.WriteFile(in_file, []byte(sb.String()), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return in_file
+}
+
+func collect(t *testing.T, in_file string, num_workers int) []network_entities.Flow {
+	app_ctx := app_context.AppContext{Flows: make(chan network_entities.Flow, 100)}
+	wait, err := Fit(app_ctx, in_file, num_workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var flows []network_entities.Flow
+	for flow := range app_ctx.Flows {
+		flows = append(flows, flow)
+	}
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	return flows
+}
+
+func TestFit(t *testing.T) {
+	const conns = 3 * batchSize
+	in_file := writeSyslog(t, conns)
+
+	want := collect(t, in_file, 1)
+	if len(want) != conns {
+		t.Fatalf("Fit() returned %d flows, want %d", len(want), conns)
+	}
+	for i, flow := range want {
+		if !flow.Accounted && i != conns-1 {
+			t.Fatalf("Fit() flow %d has no teardown: %v", i, flow)
+		}
+	}
+
+	for _, num_workers := range []int{2, 4, 8} {
+		t.Run(fmt.Sprintf("%d workers", num_workers), func(t *testing.T) {
+			if got := collect(t, in_file, num_workers); !reflect.DeepEqual(got, want) {
+				t.Errorf("Fit() with %d workers differs from single worker", num_workers)
+			}
+		})
+	}
+}
+
+func Test_load_stream(t *testing.T) {
+	lines := "garbage without message tag\n" +
+		"%ASA-6-302013: Built inbound TCP connection 1 for outside:150.150.150.150/1024 (150.150.150.150/1024) to dmz:172.16.16.16/22 (123.123.123.10/22)\n" +
+		"%ASA-6-302014: Teardown TCP connection 1 for outside:150.150.150.150/1024 to dmz:172.16.16.16/22 duration 0:00:01 bytes 100 TCP FINs\n"
+	app_ctx := app_context.AppContext{
+		Flows:        make(chan network_entities.Flow, 100),
+		Parser_stats: app_context.NewParserStats(),
+	}
+
+	wait := load(app_ctx, io.NopCloser(strings.NewReader(lines)), 2, true)
+
+	var flows []network_entities.Flow
+	for flow := range app_ctx.Flows {
+		flows = append(flows, flow)
+	}
+	if len(flows) != 1 || !flows[0].Accounted || flows[0].Bytes != 100 {
+		t.Errorf("load() flows = %v, want single accounted flow of 100 bytes", flows)
+	}
+	if read, failed, _ := app_ctx.Parser_stats.Get(); read != 3 || failed != 1 {
+		t.Errorf("load() lines read %d, parse errors %d, want 3 and 1", read, failed)
+	}
+	var skipped *SkippedLinesError
+	if err := wait(); !errors.As(err, &skipped) || skipped.Lines != 1 {
+		t.Errorf("load() error = %v, want 1 skipped line", err)
+	}
+}
+
+func Test_load_errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        string
+		want_flows   int
+		want_skipped uint64
+		wantErr      bool
+	}{
+		{
+			name:       "all lines parsed",
+			lines:      "%ASA-4-106023: Deny tcp src outside:1.1.1.1/1024 dst inside:2.2.2.2/22 by access-group \"outside_in\" [0x0, 0x0]\n",
+			want_flows: 1,
+		},
+		{
+			name:         "broken line in the middle",
+			lines:        "%ASA-4-106023: Deny tcp src outside:1.1.1.1/1024 dst inside:2.2.2.2/22 by access-group \"outside_in\" [0x0, 0x0]\nbroken\n%ASA-4-106023: Deny tcp src outside:1.1.1.1/1025 dst inside:2.2.2.2/22 by access-group \"outside_in\" [0x0, 0x0]\n",
+			want_flows:   2,
+			want_skipped: 1,
+			wantErr:      true,
+		},
+		{
+			name:    "line longer than scanner buffer",
+			lines:   strings.Repeat("x", bufio.MaxScanTokenSize+1) + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app_ctx := app_context.AppContext{Flows: make(chan network_entities.Flow, 100)}
+			wait := FitReader(app_ctx, io.NopCloser(strings.NewReader(tt.lines)), 2)
+
+			flows := 0
+			for range app_ctx.Flows {
+				flows++
+			}
+			err := wait()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FitReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if flows != tt.want_flows {
+				t.Errorf("FitReader() flows = %d, want %d", flows, tt.want_flows)
+			}
+			var skipped *SkippedLinesError
+			if errors.As(err, &skipped) != (tt.want_skipped > 0) || (skipped != nil && skipped.Lines != tt.want_skipped) {
+				t.Errorf("FitReader() error = %v, want %d skipped lines", err, tt.want_skipped)
+			}
+		})
+	}
+}
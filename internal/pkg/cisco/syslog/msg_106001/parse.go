@@ -0,0 +1,66 @@
+package msg106001
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	sh_ip_route "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/sh-ip-route"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// input format: ip/port
+func ParseIPPort(ip_port string) (uint32, uint16, error) {
+	ip_port_split := strings.Split(ip_port, "/")
+	if len(ip_port_split) != 2 {
+		return 0, 0, fmt.Errorf("can't parse ip_port (ip_port: %v)", ip_port)
+	}
+	ip, err := utils.ParseIP(ip_port_split[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	port, err := strconv.ParseUint(ip_port_split[1], 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't parse port in ip_port (ip_port: %v)", ip_port)
+	}
+	return ip, uint16(port), nil
+}
+
+// both messages have the same layout
+// example:
+// %ASA-2-106001: Inbound TCP connection denied from 10.1.1.1/1234 to 10.2.2.2/80 flags SYN on interface outside
+// %ASA-6-106015: Deny TCP (no connection) from 10.1.1.1/1234 to 10.2.2.2/80 flags RST ACK on interface inside
+func Parse(fields []string, routing_table sh_ip_route.RoutingTable) (network_entities.Flow, error) {
+	fl := network_entities.Flow{Icmp_code: -1, Icmp_type: -1}
+
+	// fmt.Printf("106001: %v\n", fields)
+
+	if len(fields) < 11 || fields[len(fields)-2] != "interface" {
+		return fl, fmt.Errorf("can't parse syslog message 106001/106015 (fields: %v)", fields)
+	}
+
+	proto, err := network_entities.GetProtoByName(strings.ToLower(fields[2]))
+	if err != nil {
+		return fl, err
+	}
+	fl.Protocol = proto[0]
+
+	fl.Src_ip, fl.Src_port, err = ParseIPPort(fields[6])
+	if err != nil {
+		return fl, err
+	}
+	fl.Dst_ip, fl.Dst_port, err = ParseIPPort(fields[8])
+	if err != nil {
+		return fl, err
+	}
+
+	// --- packet received on the interface, egress interface found by ip
+	fl.Src_iface = fields[len(fields)-1]
+	fl.Dst_iface, err = routing_table.GetIface(fl.Dst_ip)
+	if err != nil {
+		return fl, err
+	}
+
+	return fl, nil
+}
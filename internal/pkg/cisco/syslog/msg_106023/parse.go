@@ -1,7 +1,6 @@
 package msg106023
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -18,9 +17,7 @@ func parseIfaceIP(iface_ip string) (string, uint32, error) {
 
 	iface_ip_split := strings.Split(iface_ip, ":")
 	if len(iface_ip_split) != 2 {
-		error_message := "ERROR: can't parse iface_ip"
-		fmt.Printf("%s (%s)\n", error_message, iface_ip)
-		return iface, ip, errors.New(error_message)
+		return iface, ip, fmt.Errorf("can't parse iface_ip (iface_ip: %v)", iface_ip)
 	}
 	iface = iface_ip_split[0]
 	ip, err = utils.ParseIP(iface_ip_split[1])
@@ -39,9 +36,7 @@ func ParseIfaceIPPort(iface_ip_port string) (string, uint32, uint16, error) {
 
 	iface_ip_port_split := strings.Split(iface_ip_port, "/")
 	if len(iface_ip_port_split) != 2 {
-		error_message := "ERROR: can't parse iface_ip_port"
-		fmt.Printf("%s (%s)\n", error_message, iface_ip_port)
-		return iface, ip, port, errors.New(error_message)
+		return iface, ip, port, fmt.Errorf("can't parse iface_ip_port (iface_ip_port: %v)", iface_ip_port)
 	}
 	iface, ip, err = parseIfaceIP(iface_ip_port_split[0])
 	if err != nil {
@@ -49,9 +44,7 @@ func ParseIfaceIPPort(iface_ip_port string) (string, uint32, uint16, error) {
 	}
 	_port, err := strconv.ParseUint(iface_ip_port_split[1], 10, 16)
 	if err != nil {
-		error_message := "ERROR: can't parse port in iface_ip_port"
-		fmt.Printf("%s (%s)\n", error_message, iface_ip_port)
-		return iface, ip, port, errors.New(error_message)
+		return iface, ip, port, fmt.Errorf("can't parse port in iface_ip_port (iface_ip_port: %v)", iface_ip_port)
 	}
 	return iface, ip, uint16(_port), nil
 }
@@ -65,9 +58,7 @@ func Parse(fields []string) (network_entities.Flow, error) {
 	// fmt.Printf("106023: %v\n", fields)
 
 	if len(fields) < 11 {
-		error_message := "ERROR: can't parse syslog message 106023"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, errors.New(error_message)
+		return fl, fmt.Errorf("can't parse syslog message 106023 (fields: %v)", fields)
 	}
 
 	proto, err := network_entities.GetProtoByName(strings.ToLower(fields[2]))
@@ -90,15 +81,11 @@ func Parse(fields []string) (network_entities.Flow, error) {
 		}
 		fl.Icmp_type, err = strconv.Atoi(fields[8][:len(fields[8])-1])
 		if err != nil {
-			error_message := "ERROR: can't parse icmp type in a syslog message 106023"
-			fmt.Printf("%s (%s)\n", error_message, fields)
-			return fl, errors.New(error_message)
+			return fl, fmt.Errorf("can't parse icmp type in a syslog message 106023 (fields: %v)", fields)
 		}
 		fl.Icmp_code, err = strconv.Atoi(fields[10][:len(fields[10])-1])
 		if err != nil {
-			error_message := "ERROR: can't parse icmp code in a syslog message 106023"
-			fmt.Printf("%s (%s)\n", error_message, fields)
-			return fl, errors.New(error_message)
+			return fl, fmt.Errorf("can't parse icmp code in a syslog message 106023 (fields: %v)", fields)
 		}
 	case "tcp", "udp":
 		fl.Src_iface, fl.Src_ip, fl.Src_port, err = ParseIfaceIPPort(fields[4])
@@ -110,9 +97,7 @@ func Parse(fields []string) (network_entities.Flow, error) {
 			return fl, err
 		}
 	default:
-		error_message := "ERROR: unknown protocol in a syslog message 106023"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, errors.New(error_message)
+		return fl, fmt.Errorf("unknown protocol in a syslog message 106023 (fields: %v)", fields)
 	}
 
 	return fl, nil
@@ -0,0 +1,103 @@
+package msg106100
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// iface/ip(port), user identity may be glued to the end
+var iface_ip_port_re = regexp.MustCompile(`^([^/\s]+)/([0-9.]+)\(([0-9]+)\)`)
+
+// input format: iface/ip(port)
+func parseIfaceIPPort(iface_ip_port string) (string, uint32, uint16, error) {
+	match := iface_ip_port_re.FindStringSubmatch(iface_ip_port)
+	if match == nil {
+		return "", 0, 0, fmt.Errorf("can't parse iface/ip(port) (iface_ip_port: %v)", iface_ip_port)
+	}
+
+	ip, err := utils.ParseIP(match[2])
+	if err != nil {
+		return "", 0, 0, err
+	}
+	port, err := strconv.ParseUint(match[3], 10, 16)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("can't parse port in iface/ip(port) (iface_ip_port: %v)", iface_ip_port)
+	}
+
+	return match[1], ip, uint16(port), nil
+}
+
+// input format: [0x6643b58b,
+func parseHash(hash string) (uint32, error) {
+	hash = strings.Trim(hash, "[],")
+	result, err := strconv.ParseUint(strings.TrimPrefix(hash, "0x"), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse ACE hash (hash: %v)", hash)
+	}
+	return uint32(result), nil
+}
+
+// example:
+// %ASA-6-106100: access-list inside_in permitted tcp inside/10.1.1.1(1234) -> outside/8.8.8.8(443) hit-cnt 1 first hit [0x6643b58b, 0x0]
+// %ASA-6-106100: access-list outside_in denied icmp outside/1.1.1.1(8) -> inside/10.1.1.1(0) hit-cnt 1 first hit [0xd3b8e9a3, 0x0]
+// %ASA-6-106100: access-list acl_ID {permitted | denied | est-allowed} protocol interface/source_address(source_port) (idfw_user, sg_info) -> interface/dest_address(dest_port) (idfw_user, sg_info) hit-cnt number ({first hit | number -second interval}) hash codes
+// for icmp source port is a type and destination port is a code
+func Parse(fields []string) (network_entities.Flow, error) {
+	fl := network_entities.Flow{Icmp_code: -1, Icmp_type: -1}
+
+	// fmt.Printf("106100: %v\n", fields)
+
+	if len(fields) < 7 {
+		return fl, fmt.Errorf("can't parse syslog message 106100 (fields: %v)", fields)
+	}
+
+	proto, err := network_entities.GetProtoByName(strings.ToLower(fields[4]))
+	if err != nil {
+		return fl, err
+	}
+	fl.Protocol = proto[0]
+	fl.Acl_name = fields[2]
+
+	var addresses []int
+	for i := 5; i < len(fields); i++ {
+		if iface_ip_port_re.MatchString(fields[i]) {
+			addresses = append(addresses, i)
+		}
+		if strings.HasPrefix(fields[i], "[0x") {
+			fl.Ace_hash, err = parseHash(fields[i])
+			if err != nil {
+				return fl, err
+			}
+			break
+		}
+	}
+	if len(addresses) < 2 {
+		return fl, fmt.Errorf("can't parse syslog message 106100, source or destination not found (fields: %v)", fields)
+	}
+
+	var src_port, dst_port uint16
+	fl.Src_iface, fl.Src_ip, src_port, err = parseIfaceIPPort(fields[addresses[0]])
+	if err != nil {
+		return fl, err
+	}
+	fl.Dst_iface, fl.Dst_ip, dst_port, err = parseIfaceIPPort(fields[addresses[1]])
+	if err != nil {
+		return fl, err
+	}
+
+	switch fl.Protocol.Title {
+	case "icmp":
+		fl.Icmp_type = int(src_port)
+		fl.Icmp_code = int(dst_port)
+	default:
+		fl.Src_port = src_port
+		fl.Dst_port = dst_port
+	}
+
+	return fl, nil
+}
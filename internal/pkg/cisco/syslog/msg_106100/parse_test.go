@@ -0,0 +1,83 @@
+package msg106100
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+func TestParse(t *testing.T) {
+	type args struct {
+		fields []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    network_entities.Flow
+		wantErr bool
+	}{
+		{
+			name: "tcp permitted",
+			args: args{
+				fields: strings.Fields("%ASA-6-106100: access-list inside_in permitted tcp inside/10.1.1.1(1234) -> outside/8.8.8.8(443) hit-cnt 1 first hit [0x6643b58b, 0x0]"),
+			},
+			want: network_entities.Flow{
+				Src_iface: "inside",
+				Src_ip:    0x0a010101,
+				Src_port:  1234,
+				Dst_iface: "outside",
+				Dst_ip:    0x08080808,
+				Dst_port:  443,
+				Protocol:  &network_entities.Protocol{Title: "tcp", Id: 6},
+				Icmp_type: -1,
+				Icmp_code: -1,
+				Acl_name:  "inside_in",
+				Ace_hash:  0x6643b58b,
+			},
+			wantErr: false,
+		},
+		{
+			name: "icmp denied with user",
+			args: args{
+				fields: strings.Fields("%ASA-6-106100: access-list outside_in denied icmp outside/1.1.1.1(8)(LOCAL\\user) -> inside/10.1.1.1(0) hit-cnt 1 first hit [0xd3b8e9a3, 0x0]"),
+			},
+			want: network_entities.Flow{
+				Src_iface: "outside",
+				Src_ip:    0x01010101,
+				Dst_iface: "inside",
+				Dst_ip:    0x0a010101,
+				Protocol:  &network_entities.Protocol{Title: "icmp", Id: 1},
+				Icmp_type: 8,
+				Icmp_code: 0,
+				Acl_name:  "outside_in",
+				Ace_hash:  0xd3b8e9a3,
+			},
+			wantErr: false,
+		},
+		{
+			name: "no destination",
+			args: args{
+				fields: strings.Fields("%ASA-6-106100: access-list inside_in permitted tcp inside/10.1.1.1(1234) hit-cnt 1 first hit [0x6643b58b, 0x0]"),
+			},
+			want:    network_entities.Flow{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.args.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
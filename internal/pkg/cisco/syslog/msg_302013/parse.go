@@ -1,10 +1,10 @@
 package msg302013
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 
+	msg106001 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_106001"
 	msg106023 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_106023"
 	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
 )
@@ -14,15 +14,14 @@ import (
 // %ASA-6-302013: Built outbound TCP connection 56 for outside:150.150.150.150/22 (150.150.150.150/22) to dmz:172.16.16.16/51624 (123.123.123.10/51624)
 // %ASA-6-302013: Built {inbound|outbound} TCP connection number for interface :real-address /real-port (mapped-address/mapped-port ) [(idfw_user )] to interface :real-address /real-port (mapped-address/mapped-port ) [(idfw_user )] [(user )]
 // %ASA-6-302015: Built {inbound|outbound} UDP connection number for interface :real_address /real_port (mapped_address/mapped_port ) [(idfw_user )] to interface :real_address /real_port (mapped_address/mapped_port ) [(idfw_user )] [(user )]
+// %ASA-6-302035: Built {inbound|outbound} SCTP connection conn_id for outside_ifc :outside_addr /outside_port (mapped_outside_addr /mapped_outside_port ) [(idfw_user )] to inside_ifc :inside_addr /inside_port (mapped_inside_addr /mapped_inside_port ) [(idfw_user )]
 func Parse(fields []string) (network_entities.Flow, error) {
 	fl := network_entities.Flow{Icmp_code: -1, Icmp_type: -1}
 
 	// fmt.Printf("302013: %v\n", fields)
 
-	if len(fields) < 11 {
-		error_message := "ERROR: can't parse syslog message 302013/302015"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, errors.New(error_message)
+	if len(fields) < 12 {
+		return fl, fmt.Errorf("can't parse syslog message 302013/302015/302035 (fields: %v)", fields)
 	}
 
 	proto, err := network_entities.GetProtoByName(strings.ToLower(fields[3]))
@@ -41,9 +40,7 @@ func Parse(fields []string) (network_entities.Flow, error) {
 		src_idx = 10
 		dst_idx = 7
 	default:
-		error_message := "ERROR: can't parse syslog message 302013/302015, inbound/outbound not found"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, errors.New(error_message)
+		return fl, fmt.Errorf("can't parse syslog message 302013/302015/302035, inbound/outbound not found (fields: %v)", fields)
 	}
 
 	fl.Src_iface, fl.Src_ip, fl.Src_port, err = msg106023.ParseIfaceIPPort(fields[src_idx])
@@ -55,5 +52,26 @@ func Parse(fields []string) (network_entities.Flow, error) {
 		return fl, err
 	}
 
+	// --- mapped addresses follow real ones in parentheses
+	fl.Src_mapped_ip, fl.Src_mapped_port, err = msg106001.ParseIPPort(strings.Trim(fields[src_idx+1], "()"))
+	if err != nil {
+		return fl, err
+	}
+	fl.Dst_mapped_ip, fl.Dst_mapped_port, err = msg106001.ParseIPPort(strings.Trim(fields[dst_idx+1], "()"))
+	if err != nil {
+		return fl, err
+	}
+
 	return fl, nil
 }
+
+// returns connection number of a built connection, it is used to pair the message with a teardown
+// example:
+// %ASA-6-302013: Built inbound TCP connection 54 for outside:150.150.150.150/57346 (150.150.150.150/57346) to dmz:172.16.16.16/22 (123.123.123.10/22)
+func ConnKey(fields []string) (string, error) {
+	if len(fields) < 6 {
+		return "", fmt.Errorf("can't find connection number in syslog message 302013/302015/302035 (fields: %v)", fields)
+	}
+
+	return fields[5], nil
+}
@@ -33,6 +33,11 @@ func TestParse(t *testing.T) {
 				Protocol:  &network_entities.Protocol{Title: "tcp", Id: 6},
 				Icmp_type: -1,
 				Icmp_code: -1,
+
+				Src_mapped_ip:   0x96969696,
+				Src_mapped_port: 57346,
+				Dst_mapped_ip:   0x7b7b7b0a,
+				Dst_mapped_port: 22,
 			},
 			wantErr: false,
 		},
@@ -51,6 +56,11 @@ func TestParse(t *testing.T) {
 				Protocol:  &network_entities.Protocol{Title: "udp", Id: 17},
 				Icmp_type: -1,
 				Icmp_code: -1,
+
+				Src_mapped_ip:   0x7b7b7b0a,
+				Src_mapped_port: 7346,
+				Dst_mapped_ip:   0x96969696,
+				Dst_mapped_port: 2,
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,68 @@
+package msg302014
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// input format: hh:mm:ss
+func parseDuration(duration string) (time.Duration, error) {
+	duration_split := strings.Split(duration, ":")
+	if len(duration_split) != 3 {
+		return 0, fmt.Errorf("can't parse connection duration (duration: %v)", duration)
+	}
+
+	var result time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		value, err := strconv.ParseUint(duration_split[i], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("can't parse connection duration (duration: %v)", duration)
+		}
+		result += time.Duration(value) * unit
+	}
+
+	return result, nil
+}
+
+// example:
+// %ASA-6-302014: Teardown TCP connection 54 for outside:150.150.150.150/57346 to dmz:172.16.16.16/22 duration 0:12:02 bytes 3214 FIN Timeout from dmz
+// %ASA-6-302014: Teardown TCP connection id for interface :real-address /real-port [(idfw_user )] to interface :real-address /real-port [(idfw_user )] duration hh:mm:ss bytes bytes [reason [from teardown-initiator]] [(user )]
+// %ASA-6-302016: Teardown UDP connection number for interface :real-address /real-port [(idfw_user )] to interface :real-address /real-port [(idfw_user )] duration hh:mm:ss bytes bytes [(user )]
+// %ASA-6-302036: Teardown SCTP connection conn_id for outside_ifc :outside_addr /outside_port [(idfw_user )] to inside_ifc :inside_addr /inside_port [(idfw_user )] duration time bytes bytes reason [(idfw_user )]
+func Parse(fields []string) (conn_key string, duration time.Duration, bytes uint64, err error) {
+	// fmt.Printf("302014: %v\n", fields)
+
+	if len(fields) < 5 {
+		return conn_key, duration, bytes, fmt.Errorf("can't parse syslog message 302014/302016/302036 (fields: %v)", fields)
+	}
+
+	conn_key = fields[4]
+
+	// user identities may be inserted after addresses, so keywords are used to find values
+	duration_found, bytes_found := false, false
+	for i := 5; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "duration":
+			duration, err = parseDuration(fields[i+1])
+			if err != nil {
+				return conn_key, duration, bytes, err
+			}
+			duration_found = true
+		case "bytes":
+			bytes, err = strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return conn_key, duration, bytes, fmt.Errorf("can't parse bytes in syslog message 302014/302016/302036 (fields: %v)", fields)
+			}
+			bytes_found = true
+		}
+	}
+
+	if !duration_found || !bytes_found {
+		return conn_key, duration, bytes, fmt.Errorf("can't parse syslog message 302014/302016/302036, duration or bytes not found (fields: %v)", fields)
+	}
+
+	return conn_key, duration, bytes, nil
+}
@@ -0,0 +1,71 @@
+package msg302014
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	type args struct {
+		fields []string
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantConnKey  string
+		wantDuration time.Duration
+		wantBytes    uint64
+		wantErr      bool
+	}{
+		{
+			name: "tcp",
+			args: args{
+				fields: strings.Fields("%ASA-6-302014: Teardown TCP connection 54 for outside:150.150.150.150/57346 to dmz:172.16.16.16/22 duration 0:12:02 bytes 3214 FIN Timeout from dmz"),
+			},
+			wantConnKey:  "54",
+			wantDuration: 12*time.Minute + 2*time.Second,
+			wantBytes:    3214,
+			wantErr:      false,
+		},
+		{
+			name: "udp with user",
+			args: args{
+				fields: strings.Fields("%ASA-6-302016: Teardown UDP connection 55 for outside:8.8.8.8/53 (LOCAL\\user) to inside:10.1.1.1/51000 duration 25:00:01 bytes 0"),
+			},
+			wantConnKey:  "55",
+			wantDuration: 25*time.Hour + time.Second,
+			wantBytes:    0,
+			wantErr:      false,
+		},
+		{
+			name: "no bytes",
+			args: args{
+				fields: strings.Fields("%ASA-6-302014: Teardown TCP connection 54 for outside:150.150.150.150/57346 to dmz:172.16.16.16/22 duration 0:12:02"),
+			},
+			wantConnKey: "54",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn_key, duration, bytes, err := Parse(tt.args.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if conn_key != tt.wantConnKey {
+				t.Errorf("Parse() conn_key = %v, want %v", conn_key, tt.wantConnKey)
+			}
+			if duration != tt.wantDuration {
+				t.Errorf("Parse() duration = %v, want %v", duration, tt.wantDuration)
+			}
+			if bytes != tt.wantBytes {
+				t.Errorf("Parse() bytes = %v, want %v", bytes, tt.wantBytes)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package msg302020
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -19,9 +18,7 @@ func parseIPPort(iface_ip_port string) (uint32, uint16, error) {
 
 	iface_ip_port_split := strings.Split(iface_ip_port, "/")
 	if len(iface_ip_port_split) != 2 {
-		error_message := "ERROR: can't parse iface_ip_port"
-		fmt.Printf("%s (%s)\n", error_message, iface_ip_port)
-		return ip, port, errors.New(error_message)
+		return ip, port, fmt.Errorf("can't parse iface_ip_port (iface_ip_port: %v)", iface_ip_port)
 	}
 	ip, err = utils.ParseIP(iface_ip_port_split[0])
 	if err != nil {
@@ -29,9 +26,7 @@ func parseIPPort(iface_ip_port string) (uint32, uint16, error) {
 	}
 	_port, err := strconv.ParseUint(iface_ip_port_split[1], 10, 16)
 	if err != nil {
-		error_message := "ERROR: can't parse port in iface_ip_port"
-		fmt.Printf("%s (%s)\n", error_message, iface_ip_port)
-		return ip, port, errors.New(error_message)
+		return ip, port, fmt.Errorf("can't parse port in iface_ip_port (iface_ip_port: %v)", iface_ip_port)
 	}
 	return ip, uint16(_port), nil
 }
@@ -45,9 +40,7 @@ func Parse(fields []string, routing_table sh_ip_route.RoutingTable) (network_ent
 	// fmt.Printf("302020: %v\n", fields)
 
 	if len(fields) < 16 {
-		error_message := "ERROR: can't parse syslog message 302020"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, errors.New(error_message)
+		return fl, fmt.Errorf("can't parse syslog message 302020 (fields: %v)", fields)
 	}
 
 	proto, err := network_entities.GetProtoByName(strings.ToLower(fields[3]))
@@ -57,18 +50,22 @@ func Parse(fields []string, routing_table sh_ip_route.RoutingTable) (network_ent
 
 	fl.Protocol = proto[0]
 
+	// --- gaddr is a mapped address of laddr, faddr is seen the same from both sides
 	var src_idx, dst_idx int
+	var src_mapped_idx, dst_mapped_idx int
 	switch strings.ToLower(fields[2]) {
 	case "inbound":
 		src_idx = 7
 		dst_idx = 11
+		src_mapped_idx = 7
+		dst_mapped_idx = 9
 	case "outbound":
 		src_idx = 11
 		dst_idx = 7
+		src_mapped_idx = 9
+		dst_mapped_idx = 7
 	default:
-		error_message := "ERROR: can't parse syslog message 302020, inbound/outbound not found"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, errors.New(error_message)
+		return fl, fmt.Errorf("can't parse syslog message 302020, inbound/outbound not found (fields: %v)", fields)
 	}
 
 	fl.Src_ip, fl.Src_port, err = parseIPPort(fields[src_idx])
@@ -79,6 +76,14 @@ func Parse(fields []string, routing_table sh_ip_route.RoutingTable) (network_ent
 	if err != nil {
 		return fl, err
 	}
+	fl.Src_mapped_ip, fl.Src_mapped_port, err = parseIPPort(fields[src_mapped_idx])
+	if err != nil {
+		return fl, err
+	}
+	fl.Dst_mapped_ip, fl.Dst_mapped_port, err = parseIPPort(fields[dst_mapped_idx])
+	if err != nil {
+		return fl, err
+	}
 
 	// find iface by ip
 	fl.Src_iface, err = routing_table.GetIface(fl.Src_ip)
@@ -93,17 +98,24 @@ func Parse(fields []string, routing_table sh_ip_route.RoutingTable) (network_ent
 	// parse icmp type and code
 	fl.Icmp_type, err = strconv.Atoi(fields[13])
 	if err != nil {
-		error_message := "ERROR: can't parse icmp type in syslog message 302020"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, err
+		return fl, fmt.Errorf("can't parse icmp type in syslog message 302020 (fields: %v): %w", fields, err)
 	}
 
 	fl.Icmp_code, err = strconv.Atoi(fields[15])
 	if err != nil {
-		error_message := "ERROR: can't parse icmp code in syslog message 302020"
-		fmt.Printf("%s (%s)\n", error_message, fields)
-		return fl, err
+		return fl, fmt.Errorf("can't parse icmp code in syslog message 302020 (fields: %v): %w", fields, err)
 	}
 
 	return fl, nil
 }
+
+// ICMP messages don't have connection number, addresses are used to pair the message with a teardown
+// example:
+// %ASA-6-302020: Built outbound ICMP connection for faddr 10.10.10.10/0 gaddr 10.10.9.9/17411 laddr 10.10.9.9/17411 type 8 code 0
+func ConnKey(fields []string) (string, error) {
+	if len(fields) < 12 {
+		return "", fmt.Errorf("can't find addresses in syslog message 302020 (fields: %v)", fields)
+	}
+
+	return fields[7] + " " + fields[9] + " " + fields[11], nil
+}
@@ -0,0 +1,16 @@
+package msg302021
+
+import "fmt"
+
+// ICMP teardown carries neither duration nor bytes, only addresses to pair it with 302020
+// example:
+// %ASA-6-302021: Teardown ICMP connection for faddr 10.10.10.10/0 gaddr 10.10.9.9/17411 laddr 10.10.9.9/17411 type 8 code 0
+func Parse(fields []string) (string, error) {
+	// fmt.Printf("302021: %v\n", fields)
+
+	if len(fields) < 11 {
+		return "", fmt.Errorf("can't parse syslog message 302021 (fields: %v)", fields)
+	}
+
+	return fields[6] + " " + fields[8] + " " + fields[10], nil
+}
@@ -0,0 +1,69 @@
+package msg710003
+
+import (
+	"fmt"
+	"strings"
+
+	msg106001 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_106001"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// input format: iface:ip/service, service name is looked up in ports of the protocol
+func parseIfaceIPService(iface_ip_service string, proto *network_entities.Protocol) (string, uint32, uint16, error) {
+	iface_ip, service, found := strings.Cut(iface_ip_service, "/")
+	if !found {
+		return "", 0, 0, fmt.Errorf("can't parse iface_ip_service (iface_ip_service: %v)", iface_ip_service)
+	}
+	iface, ip_str, found := strings.Cut(iface_ip, ":")
+	if !found {
+		return "", 0, 0, fmt.Errorf("can't parse iface_ip_service (iface_ip_service: %v)", iface_ip_service)
+	}
+
+	ip, err := utils.ParseIP(ip_str)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	getPort := network_entities.GetTcpPortFromString
+	if proto.Title == "udp" {
+		getPort = network_entities.GetUdpPortFromString
+	}
+	port, err := getPort(service)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	return iface, ip, uint16(port), nil
+}
+
+// to-the-box connection denied by control-plane ACL
+// example:
+// %ASA-3-710003: TCP access denied by ACL from 10.1.1.1/1234 to outside:123.123.123.1/22
+// %ASA-3-710003: {TCP|UDP} access denied by ACL from source_IP/source_port to interface_name:dest_IP/service
+func Parse(fields []string) (network_entities.Flow, error) {
+	fl := network_entities.Flow{Icmp_code: -1, Icmp_type: -1, To_box: true}
+
+	// fmt.Printf("710003: %v\n", fields)
+
+	if len(fields) < 10 {
+		return fl, fmt.Errorf("can't parse syslog message 710003 (fields: %v)", fields)
+	}
+
+	proto, err := network_entities.GetProtoByName(strings.ToLower(fields[1]))
+	if err != nil {
+		return fl, err
+	}
+	fl.Protocol = proto[0]
+
+	fl.Src_ip, fl.Src_port, err = msg106001.ParseIPPort(fields[7])
+	if err != nil {
+		return fl, err
+	}
+	fl.Dst_iface, fl.Dst_ip, fl.Dst_port, err = parseIfaceIPService(fields[9], fl.Protocol)
+	if err != nil {
+		return fl, err
+	}
+	fl.Src_iface = fl.Dst_iface
+
+	return fl, nil
+}
@@ -0,0 +1,125 @@
+package msg710003
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+func TestParse(t *testing.T) {
+	type args struct {
+		fields []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    network_entities.Flow
+		wantErr bool
+	}{
+		{
+			name: "tcp",
+			args: args{
+				fields: strings.Fields("%ASA-3-710003: TCP access denied by ACL from 10.1.1.1/1234 to outside:123.123.123.1/22"),
+			},
+			want: network_entities.Flow{
+				Src_iface: "outside",
+				Src_ip:    0x0a010101,
+				Src_port:  1234,
+				Dst_iface: "outside",
+				Dst_ip:    0x7b7b7b01,
+				Dst_port:  22,
+				Protocol:  &network_entities.Protocol{Title: "tcp", Id: 6},
+				Icmp_type: -1,
+				Icmp_code: -1,
+				To_box:    true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "named service",
+			args: args{
+				fields: strings.Fields("%ASA-3-710003: TCP access denied by ACL from 10.1.1.1/1234 to outside:123.123.123.1/telnet"),
+			},
+			want: network_entities.Flow{
+				Src_iface: "outside",
+				Src_ip:    0x0a010101,
+				Src_port:  1234,
+				Dst_iface: "outside",
+				Dst_ip:    0x7b7b7b01,
+				Dst_port:  23,
+				Protocol:  &network_entities.Protocol{Title: "tcp", Id: 6},
+				Icmp_type: -1,
+				Icmp_code: -1,
+				To_box:    true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "named udp service",
+			args: args{
+				fields: strings.Fields("%ASA-3-710003: UDP access denied by ACL from 10.1.1.1/1234 to outside:123.123.123.1/syslog"),
+			},
+			want: network_entities.Flow{
+				Src_iface: "outside",
+				Src_ip:    0x0a010101,
+				Src_port:  1234,
+				Dst_iface: "outside",
+				Dst_ip:    0x7b7b7b01,
+				Dst_port:  514,
+				Protocol:  &network_entities.Protocol{Title: "udp", Id: 17},
+				Icmp_type: -1,
+				Icmp_code: -1,
+				To_box:    true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "udp name of port tcp names differently",
+			args: args{
+				fields: strings.Fields("%ASA-3-710003: UDP access denied by ACL from 10.1.1.1/1234 to outside:123.123.123.1/who"),
+			},
+			want: network_entities.Flow{
+				Src_iface: "outside",
+				Src_ip:    0x0a010101,
+				Src_port:  1234,
+				Dst_iface: "outside",
+				Dst_ip:    0x7b7b7b01,
+				Dst_port:  513,
+				Protocol:  &network_entities.Protocol{Title: "udp", Id: 17},
+				Icmp_type: -1,
+				Icmp_code: -1,
+				To_box:    true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "tcp only name in udp",
+			args: args{
+				fields: strings.Fields("%ASA-3-710003: UDP access denied by ACL from 10.1.1.1/1234 to outside:123.123.123.1/telnet"),
+			},
+			want: network_entities.Flow{
+				Src_ip:    0x0a010101,
+				Src_port:  1234,
+				Protocol:  &network_entities.Protocol{Title: "udp", Id: 17},
+				Icmp_type: -1,
+				Icmp_code: -1,
+				To_box:    true,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.args.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
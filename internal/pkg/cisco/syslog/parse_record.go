@@ -1,40 +1,128 @@
 package syslog
 
 import (
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	msg106001 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_106001"
 	msg106023 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_106023"
+	msg106100 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_106100"
 	msg302013 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_302013"
+	msg302014 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_302014"
 	msg302020 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_302020"
-	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	msg302021 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_302021"
+	msg710003 "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog/msg_710003"
 )
 
-func parseRecord(record string, app_ctx app_context.AppContext) (network_entities.Flow, error) {
-	var fl network_entities.Flow
-	if len(record) == 0 {
-		return fl, nil
+// connection numbers and ICMP addresses are kept apart in the connection table
+const (
+	connPrefix = "conn "
+	icmpPrefix = "icmp "
+)
+
+// "logging device-id" puts device-id right in front of the message:
+// Oct 19 2023 10:11:12 ctx1 : %ASA-6-302013: ...
+func getDeviceId(header []string) string {
+	for i := len(header) - 1; i >= 0; i-- {
+		device_id := strings.TrimSuffix(header[i], ":")
+		if device_id != "" {
+			return device_id
+		}
+	}
+	return ""
+}
+
+func isOwnMessage(header []string, device_ids []string) bool {
+	if len(device_ids) == 0 {
+		return true
 	}
 
+	device_id := getDeviceId(header)
+	for _, id := range device_ids {
+		if id == device_id {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRecord(record string, app_ctx app_context.AppContext) (event, error) {
+	var ev event
+
 	fields1 := strings.Fields(record)
+	if len(fields1) == 0 {
+		return ev, nil
+	}
+
+	// --- syslog server and "logging timestamp" put header in front of the message
+	msg_idx := 0
+	for i, field := range fields1 {
+		if strings.HasPrefix(field, "%ASA-") {
+			msg_idx = i
+			break
+		}
+	}
+	header := fields1[:msg_idx]
+	fields1 = fields1[msg_idx:]
+
+	if !isOwnMessage(header, app_ctx.Device_ids) {
+		return ev, nil
+	}
+
 	fields2 := strings.Split(fields1[0], "-")
 
-	if len(fields2) < 2 {
-		error_message := "ERROR: can't parse record "
-		fmt.Printf("%s (%s)\n", error_message, record)
-		return fl, errors.New(error_message)
+	if len(fields2) < 3 {
+		return ev, fmt.Errorf("can't parse record (record: %v)", record)
 	}
 
+	var err error
 	switch fields2[2] {
-	case "302013:", "302015:":
-		return msg302013.Parse(fields1)
+	case "302013:", "302015:", "302035:":
+		ev.kind = eventBuild
+		ev.flow, err = msg302013.Parse(fields1)
+		if err != nil {
+			return ev, err
+		}
+		ev.conn_key, err = msg302013.ConnKey(fields1)
+		ev.conn_key = connPrefix + ev.conn_key
+	case "302014:", "302016:", "302036:":
+		ev.kind = eventTeardown
+		ev.flow.Accounted = true
+		ev.conn_key, ev.flow.Duration, ev.flow.Bytes, err = msg302014.Parse(fields1)
+		ev.conn_key = connPrefix + ev.conn_key
 	case "302020:":
-		return msg302020.Parse(fields1, app_ctx.Routing_table)
+		ev.kind = eventBuild
+		ev.flow, err = msg302020.Parse(fields1, app_ctx.Routing_table)
+		if err != nil {
+			return ev, err
+		}
+		ev.conn_key, err = msg302020.ConnKey(fields1)
+		ev.conn_key = icmpPrefix + ev.conn_key
+	case "302021:":
+		ev.kind = eventTeardown
+		ev.conn_key, err = msg302021.Parse(fields1)
+		ev.conn_key = icmpPrefix + ev.conn_key
 	case "106023:":
-		return msg106023.Parse(fields1)
+		ev.kind = eventFlow
+		ev.flow, err = msg106023.Parse(fields1)
+	case "106100:":
+		ev.kind = eventFlow
+		ev.flow, err = msg106100.Parse(fields1)
+	case "106001:", "106015:":
+		ev.kind = eventFlow
+		ev.flow, err = msg106001.Parse(fields1, app_ctx.Routing_table)
+	case "710003:":
+		ev.kind = eventFlow
+		ev.flow, err = msg710003.Parse(fields1)
 	default:
-		return fl, nil
+		app_ctx.Parser_stats.AddSkipped(strings.TrimSuffix(fields2[2], ":"))
+	}
+
+	if timestamp, ok := parseTimestamp(header, time.Now()); ok {
+		ev.flow.Timestamp = timestamp
 	}
+
+	return ev, err
 }
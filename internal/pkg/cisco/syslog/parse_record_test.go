@@ -0,0 +1,75 @@
+package syslog
+
+import (
+	"strings"
+	"testing"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+)
+
+func Test_isOwnMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		device_ids []string
+		want       bool
+	}{
+		{
+			name:       "single context",
+			header:     "Oct 19 2023 10:11:12:",
+			device_ids: nil,
+			want:       true,
+		},
+		{
+			name:       "asa timestamp and context name",
+			header:     "Oct 19 2023 10:11:12 ctx1 :",
+			device_ids: []string{"ctx1"},
+			want:       true,
+		},
+		{
+			name:       "syslog server",
+			header:     "<166>1 2023-10-19T10:11:12Z 10.0.0.1 ctx1:",
+			device_ids: []string{"fw-ctx1", "ctx1"},
+			want:       true,
+		},
+		{
+			name:       "other context",
+			header:     "Oct 19 2023 10:11:12 ctx2 :",
+			device_ids: []string{"ctx1"},
+			want:       false,
+		},
+		{
+			name:       "no device-id",
+			header:     "",
+			device_ids: []string{"ctx1"},
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOwnMessage(strings.Fields(tt.header), tt.device_ids); got != tt.want {
+				t.Errorf("isOwnMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseRecord_empty(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     string
+		device_ids []string
+	}{
+		{name: "empty line", record: ""},
+		{name: "whitespace only line", record: " \t "},
+		{name: "whitespace only line of context", record: " \t ", device_ids: []string{"ctx1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := parseRecord(tt.record, app_context.AppContext{Device_ids: tt.device_ids})
+			if err != nil || ev.kind != eventNone {
+				t.Errorf("parseRecord() = %v, %v, want eventNone, nil", ev.kind, err)
+			}
+		})
+	}
+}
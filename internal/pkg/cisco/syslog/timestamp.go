@@ -0,0 +1,54 @@
+package syslog
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// --- logging timestamp on ASA: "Oct 19 2023 10:11:12:"
+	asaLayout = "Jan 2 2006 15:04:05"
+	// --- BSD syslog server: "Oct 19 10:11:12", no year
+	bsdLayout = "Jan 2 15:04:05"
+)
+
+func joinFields(fields []string, from, count int) (string, bool) {
+	if from+count > len(fields) {
+		return "", false
+	}
+
+	tokens := make([]string, 0, count)
+	for _, field := range fields[from : from+count] {
+		tokens = append(tokens, strings.TrimSuffix(field, ":"))
+	}
+	return strings.Join(tokens, " "), true
+}
+
+// looks for a timestamp in front of %ASA message, servers and ASA itself use different formats.
+// now is used to guess the year of BSD syslog timestamp.
+func parseTimestamp(header []string, now time.Time) (time.Time, bool) {
+	for i := range header {
+		if timestamp, err := time.Parse(time.RFC3339Nano, strings.TrimSuffix(header[i], ":")); err == nil {
+			return timestamp, true
+		}
+
+		if str, ok := joinFields(header, i, 4); ok {
+			if timestamp, err := time.Parse(asaLayout, str); err == nil {
+				return timestamp, true
+			}
+		}
+
+		if str, ok := joinFields(header, i, 3); ok {
+			if timestamp, err := time.Parse(bsdLayout, str); err == nil {
+				timestamp = timestamp.AddDate(now.Year(), 0, 0)
+				// --- log from December read in January
+				if timestamp.After(now.AddDate(0, 0, 1)) {
+					timestamp = timestamp.AddDate(-1, 0, 0)
+				}
+				return timestamp, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,62 @@
+package syslog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseTimestamp(t *testing.T) {
+	now := time.Date(2023, time.October, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header string
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "logging timestamp",
+			header: "Oct 19 2023 10:11:12:",
+			want:   time.Date(2023, time.October, 19, 10, 11, 12, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "bsd syslog server",
+			header: "Oct  9 10:11:12 asa1",
+			want:   time.Date(2023, time.October, 9, 10, 11, 12, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "bsd syslog server, previous year",
+			header: "Dec 31 23:59:59 asa1",
+			want:   time.Date(2022, time.December, 31, 23, 59, 59, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "rfc5424 server",
+			header: "<166>1 2023-10-19T10:11:12.5Z asa1",
+			want:   time.Date(2023, time.October, 19, 10, 11, 12, 500000000, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "server and logging timestamp, server wins",
+			header: "Oct 19 10:11:13 asa1 Oct 19 2023 10:11:12:",
+			want:   time.Date(2023, time.October, 19, 10, 11, 13, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "no timestamp",
+			header: "",
+			want:   time.Time{},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotOk := parseTimestamp(strings.Fields(tt.header), now)
+			if !got.Equal(tt.want) || gotOk != tt.wantOk {
+				t.Errorf("parseTimestamp() = %v, %v, want %v, %v", got, gotOk, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package syslog
+
+import (
+	"fmt"
+
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+type eventKind int
+
+const (
+	eventNone eventKind = iota
+	// --- message describes a complete flow (ex: denied connection)
+	eventFlow
+	// --- connection built, flow is waiting for a teardown
+	eventBuild
+	// --- connection teardown, flow carries accounting only
+	eventTeardown
+)
+
+type event struct {
+	kind     eventKind
+	conn_key string
+	flow     network_entities.Flow
+}
+
+type pendingConn struct {
+	seq  uint64
+	flow network_entities.Flow
+}
+
+// pairs "Built" and "Teardown" messages of the same connection
+type connTable struct {
+	seq     uint64
+	pending map[string]pendingConn
+}
+
+// lines are parsed in batches by several workers, seq restores file order
+type lineBatch struct {
+	seq uint64
+	// --- number of the first line in syslog, starts from 1
+	first_line uint64
+	lines      []string
+}
+
+type eventBatch struct {
+	seq    uint64
+	events []event
+	// --- lines that can't be parsed, err is the first of them
+	skipped uint64
+	err     error
+}
+
+// lines that can't be parsed are skipped, flows of the rest of syslog are analyzed
+type SkippedLinesError struct {
+	Lines uint64
+	// --- error of the first skipped line
+	First error
+}
+
+func (b lineBatch) next() lineBatch {
+	return lineBatch{seq: b.seq + 1, first_line: b.first_line + uint64(len(b.lines)), lines: make([]string, 0, batchSize)}
+}
+
+func (e *SkippedLinesError) Error() string {
+	return fmt.Sprintf("%d syslog lines can't be parsed, first at %v", e.Lines, e.First)
+}
+
+func (e *SkippedLinesError) Unwrap() error {
+	return e.First
+}
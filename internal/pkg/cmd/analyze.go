@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	"github.com/spf13/cobra"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "matches syslog against ACLs and prints utilization of every ACE",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyze(opts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+func printSummary(app_ctxs []app_context.AppContext) {
+	fmt.Println("--- Summary")
+	for _, app_ctx := range app_ctxs {
+		fmt.Println("DEVICE:", app_ctx.Name)
+		for i := range app_ctx.Access_lists {
+			aces, unused_aces, flows := app_ctx.Access_lists[i].Summary()
+			fmt.Printf("\tACL: %s, ACEs: %d, ACEs without flows: %d, # of flows: %d\n", app_ctx.Access_lists[i].Name, aces, unused_aces, flows)
+		}
+	}
+	fmt.Println("=== Summary")
+}
+
+func runAnalyze(o options) error {
+	devices, err := getCheckedDevices(o, true)
+	if err != nil {
+		return err
+	}
+	multi_device := isMultiDevice(o, devices)
+
+	app_ctxs, err := analyzeDevices(o, devices, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	t0 := time.Now()
+	fmt.Println("--- Analysis")
+	for _, app_ctx := range app_ctxs {
+		if multi_device {
+			fmt.Println("DEVICE:", app_ctx.Name)
+		}
+		for _, acl := range app_ctx.Access_lists {
+			err := acl.Analyze()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	t1 := time.Since(t0)
+	fmt.Printf("=== Analysis (%v sec)\n", t1.Seconds())
+
+	if multi_device {
+		printSummary(app_ctxs)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// files of a single firewall
+type Device struct {
+	// --- empty name is taken from "hostname" in show run
+	Name   string
+	Sh_run string
+	// --- empty routing table is derived from show run
+	Sh_route string
+	Syslog   string
+	// --- "show access-list" with ACE hashes, optional
+	Sh_acl string
+	// --- security context, syslog is shared with other contexts
+	Context bool
+}
+
+// example: asa1:asa1_sh_run.txt:asa1_sh_route.txt:asa1_syslog.log[:asa1_sh_access_list.txt]
+// sh_route may be empty: asa1:asa1_sh_run.txt::asa1_syslog.log
+func parseDevice(str string) (Device, error) {
+	var device Device
+
+	fields := strings.Split(strings.TrimSpace(str), ":")
+	if len(fields) != 4 && len(fields) != 5 {
+		return device, fmt.Errorf("device must be name:sh_run:sh_route:syslog[:sh_access_list] (device: %v)", str)
+	}
+	for i, field := range fields {
+		if field == "" && i != 2 && i != 4 {
+			return device, fmt.Errorf("empty field in device (device: %v)", str)
+		}
+	}
+
+	device.Name, device.Sh_run, device.Sh_route, device.Syslog = fields[0], fields[1], fields[2], fields[3]
+	if len(fields) == 5 {
+		device.Sh_acl = fields[4]
+	}
+	return device, nil
+}
+
+// example: ctx1:ctx1_sh_run.txt:ctx1_sh_route.txt or ctx1:ctx1_sh_run.txt
+func parseContext(str string, syslog string) (Device, error) {
+	var device Device
+
+	fields := strings.Split(strings.TrimSpace(str), ":")
+	if len(fields) < 2 || len(fields) > 3 || fields[0] == "" || fields[1] == "" {
+		return device, fmt.Errorf("context must be name:sh_run[:sh_route] (context: %v)", str)
+	}
+
+	device.Name, device.Sh_run = fields[0], fields[1]
+	if len(fields) == 3 {
+		device.Sh_route = fields[2]
+	}
+	device.Syslog = syslog
+	device.Context = true
+	return device, nil
+}
+
+// contexts of multiple context ASA, all of them share syslog from -s
+func getContexts(o options, require_syslog bool) ([]Device, error) {
+	var devices []Device
+
+	if (require_syslog && o.Syslog == "") || len(o.Contexts) == 0 {
+		return nil, errors.New("--system requires -s and --context")
+	}
+	if o.Sh_run != "" || o.Sh_route != "" || o.Sh_acl != "" || len(o.Devices) > 0 || o.Devices_file != "" {
+		return nil, errors.New("--system can't be combined with -r, -i, --sh-access-list, --device or --devices-file")
+	}
+
+	names := make(map[string]bool)
+	for _, str := range o.Contexts {
+		device, err := parseContext(str, o.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		if names[device.Name] {
+			return nil, fmt.Errorf("duplicate context name (context: %v)", device.Name)
+		}
+		names[device.Name] = true
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+// one device per line in --device format, empty lines and # comments are skipped
+func parseDevicesFile(in_file string) ([]Device, error) {
+	var devices []Device
+
+	readFile, err := os.Open(in_file)
+	if err != nil {
+		return nil, err
+	}
+	defer readFile.Close()
+
+	fileScanner := bufio.NewScanner(readFile)
+	fileScanner.Split(bufio.ScanLines)
+
+	for fileScanner.Scan() {
+		line := strings.TrimSpace(fileScanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		device, err := parseDevice(line)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, fileScanner.Err()
+}
+
+// contexts from --system and --context, devices from --device and --devices-file,
+// or a single device from -r/-i/-s. Syslog may be omitted by subcommands working on configs only.
+func getDevices(o options, require_syslog bool) ([]Device, error) {
+	if o.System != "" {
+		return getContexts(o, require_syslog)
+	}
+	if len(o.Contexts) > 0 {
+		return nil, errors.New("--context requires --system")
+	}
+
+	var devices []Device
+
+	for _, str := range o.Devices {
+		device, err := parseDevice(str)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	if o.Devices_file != "" {
+		file_devices, err := parseDevicesFile(o.Devices_file)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, file_devices...)
+	}
+
+	if len(devices) == 0 {
+		if o.Sh_run == "" || (require_syslog && o.Syslog == "") {
+			if !require_syslog {
+				return nil, errors.New("-r is required if --device or --devices-file not given")
+			}
+			return nil, errors.New("-r and -s are required if --device or --devices-file not given")
+		}
+		return []Device{{Sh_run: o.Sh_run, Sh_route: o.Sh_route, Syslog: o.Syslog, Sh_acl: o.Sh_acl}}, nil
+	}
+
+	if o.Sh_run != "" || o.Sh_route != "" || o.Syslog != "" || o.Sh_acl != "" {
+		return nil, errors.New("-r, -i, -s and --sh-access-list can't be combined with --device or --devices-file")
+	}
+
+	names := make(map[string]bool)
+	for _, device := range devices {
+		if names[device.Name] {
+			return nil, fmt.Errorf("duplicate device name (device: %v)", device.Name)
+		}
+		names[device.Name] = true
+	}
+
+	return devices, nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseDevice(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		want    Device
+		wantErr bool
+	}{
+		{
+			name: "device",
+			str:  "asa1:sh_run.txt:sh_route.txt:syslog.log",
+			want: Device{Name: "asa1", Sh_run: "sh_run.txt", Sh_route: "sh_route.txt", Syslog: "syslog.log"},
+		},
+		{
+			name: "routing table from show run",
+			str:  "asa1:sh_run.txt::syslog.log",
+			want: Device{Name: "asa1", Sh_run: "sh_run.txt", Syslog: "syslog.log"},
+		},
+		{
+			name: "show access-list",
+			str:  "asa1:sh_run.txt::syslog.log:sh_acl.txt",
+			want: Device{Name: "asa1", Sh_run: "sh_run.txt", Syslog: "syslog.log", Sh_acl: "sh_acl.txt"},
+		},
+		{
+			name:    "not enough fields",
+			str:     "asa1:sh_run.txt:sh_route.txt",
+			wantErr: true,
+		},
+		{
+			name:    "empty field",
+			str:     "asa1::sh_route.txt:syslog.log",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDevice(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDevice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDevice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDevicesFile(t *testing.T) {
+	want := []Device{
+		{Name: "asa1", Sh_run: "asa1/sh_run.txt", Sh_route: "asa1/sh_route.txt", Syslog: "asa1/syslog.log"},
+		{Name: "asa2", Sh_run: "asa2/sh_run.txt", Sh_route: "asa2/sh_route.txt", Syslog: "asa2/syslog.log"},
+	}
+
+	got, err := parseDevicesFile("testdata/devices.txt")
+	if err != nil {
+		t.Fatalf("parseDevicesFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDevicesFile() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		want    Device
+		wantErr bool
+	}{
+		{
+			name: "context",
+			str:  "ctx1:sh_run.txt:sh_route.txt",
+			want: Device{Name: "ctx1", Sh_run: "sh_run.txt", Sh_route: "sh_route.txt", Syslog: "syslog.log", Context: true},
+		},
+		{
+			name: "routing table from show run",
+			str:  "ctx1:sh_run.txt",
+			want: Device{Name: "ctx1", Sh_run: "sh_run.txt", Syslog: "syslog.log", Context: true},
+		},
+		{
+			name:    "syslog given",
+			str:     "ctx1:sh_run.txt:sh_route.txt:syslog.log",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseContext(tt.str, "syslog.log")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	acl_match "github.com/ivankuchin/excessive-acl/internal/pkg/acl_match"
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// flows printed per moved flow group
+const maxMoveSamples = 3
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "lists ACEs added, removed and modified between two configs or two saved results",
+	Long: `lists ACEs added, removed and modified between two configs or two saved results.
+<old> and <new> are either "show run" files or results saved by "report --format json" (.json).
+ACEs are identified by their text, ACE is modified if the text is the same but it compiles to other ranges (ex: object-group changed).
+Configs are compared by capacity. If syslog is given (-s), it is matched against both configs:
+utilization before and after is printed, as well as flows decided by another ACE or by the implicit deny in the new config.
+Examples:
+  excessive-acl diff sh_run.old sh_run.new -s syslog
+  excessive-acl diff before.json after.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDiff(opts, args[0], args[1])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+// flows decided by one ACE in old config and by another one in new config
+type flowMove struct {
+	old_ace string
+	new_ace string
+	flows   uint64
+	samples []network_entities.Flow
+}
+
+// numbers of ACE summed over its compiled entries
+type aceTotals struct {
+	capacity       uint
+	flows          uint64
+	flows_capacity uint
+	unique_src_ips uint64
+	unique_dst_ips uint64
+}
+
+func getACETotals(result cisco_asa_access_entry.ACEResult) aceTotals {
+	var totals aceTotals
+	for _, entry := range result.Entries {
+		// --- capacity of any to any may be the max uint already, see capacity model
+		if totals.capacity > math.MaxUint-entry.Capacity {
+			totals.capacity = math.MaxUint
+		} else {
+			totals.capacity += entry.Capacity
+		}
+		totals.flows += entry.Flows
+		if totals.flows_capacity > math.MaxUint-entry.Flows_capacity {
+			totals.flows_capacity = math.MaxUint
+		} else {
+			totals.flows_capacity += entry.Flows_capacity
+		}
+		totals.unique_src_ips += uint64(entry.Unique_src_ips)
+		totals.unique_dst_ips += uint64(entry.Unique_dst_ips)
+	}
+	return totals
+}
+
+func (t aceTotals) utilization() float64 {
+	if t.capacity == 0 {
+		return 0
+	}
+	return float64(t.flows_capacity) / float64(t.capacity) * 100.0
+}
+
+func (t aceTotals) String(with_flows bool) string {
+	if !with_flows {
+		return fmt.Sprintf("capacity: 0x%x", t.capacity)
+	}
+	return fmt.Sprintf("capacity: 0x%x, # of flows: %d, ACE capacity utilization(%%): %.3f", t.capacity, t.flows, t.utilization())
+}
+
+func compiledEntries(result cisco_asa_access_entry.ACEResult) []string {
+	entries := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		entries = append(entries, entry.Entry)
+	}
+	return entries
+}
+
+// lines of a missing in b, in order of a
+func missingLines(a, b []string) []string {
+	in_b := make(map[string]bool, len(b))
+	for _, line := range b {
+		in_b[line] = true
+	}
+	var result []string
+	for _, line := range a {
+		if !in_b[line] {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// ACEs removed, modified and added, remarks are listed without numbers
+func diffACL(old_aces, new_aces []cisco_asa_access_entry.ACEResult, with_flows bool) []string {
+	index := func(aces []cisco_asa_access_entry.ACEResult) map[string]cisco_asa_access_entry.ACEResult {
+		result := make(map[string]cisco_asa_access_entry.ACEResult, len(aces))
+		for _, ace := range aces {
+			result[cisco_asa_access_entry.StateKey(ace.Line)] = ace
+		}
+		return result
+	}
+	old_index, new_index := index(old_aces), index(new_aces)
+
+	describe := func(sign string, ace cisco_asa_access_entry.ACEResult) string {
+		line := fmt.Sprintf("\t%s %s", sign, cisco_asa_access_entry.StateKey(ace.Line))
+		if len(ace.Entries) == 0 {
+			return line
+		}
+		return fmt.Sprintf("%s (%s)", line, getACETotals(ace).String(with_flows))
+	}
+
+	var lines []string
+	for _, old_ace := range old_aces {
+		new_ace, found := new_index[cisco_asa_access_entry.StateKey(old_ace.Line)]
+		switch {
+		case !found:
+			lines = append(lines, describe("-", old_ace))
+		case !slices.Equal(compiledEntries(old_ace), compiledEntries(new_ace)):
+			lines = append(lines, fmt.Sprintf("\t~ %s", cisco_asa_access_entry.StateKey(old_ace.Line)))
+			lines = append(lines, fmt.Sprintf("\t\tbefore: %s", getACETotals(old_ace).String(with_flows)))
+			lines = append(lines, fmt.Sprintf("\t\tafter:  %s", getACETotals(new_ace).String(with_flows)))
+		}
+	}
+	for _, new_ace := range new_aces {
+		if _, found := old_index[cisco_asa_access_entry.StateKey(new_ace.Line)]; !found {
+			lines = append(lines, describe("+", new_ace))
+		}
+	}
+	return lines
+}
+
+func printDeviceDiff(old_device, new_device deviceReport, with_flows bool) {
+	acl_names := func(device deviceReport) ([]string, map[string]aclReport) {
+		var names []string
+		acls := make(map[string]aclReport)
+		for _, acl := range device.Access_lists {
+			names = append(names, acl.Name)
+			acls[acl.Name] = acl
+		}
+		return names, acls
+	}
+	old_names, old_acls := acl_names(old_device)
+	new_names, new_acls := acl_names(new_device)
+
+	names := append(old_names, missingLines(new_names, old_names)...)
+	for _, name := range names {
+		old_acl, in_old := old_acls[name]
+		new_acl, in_new := new_acls[name]
+		lines := diffACL(old_acl.Results, new_acl.Results, with_flows)
+
+		switch {
+		case !in_new:
+			fmt.Printf("ACL: %s (removed)\n", name)
+		case !in_old:
+			fmt.Printf("ACL: %s (added)\n", name)
+		case len(lines) == 0:
+			continue
+		default:
+			fmt.Printf("ACL: %s\n", name)
+		}
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+	}
+}
+
+// devices are paired by name, single device on both sides is compared regardless of name
+func printDiff(old_devices, new_devices []deviceReport, with_flows bool) {
+	if len(old_devices) == 1 && len(new_devices) == 1 {
+		printDeviceDiff(old_devices[0], new_devices[0], with_flows)
+		return
+	}
+
+	new_by_name := make(map[string]deviceReport)
+	for _, device := range new_devices {
+		new_by_name[device.Name] = device
+	}
+	old_by_name := make(map[string]bool)
+	for _, old_device := range old_devices {
+		old_by_name[old_device.Name] = true
+		new_device, found := new_by_name[old_device.Name]
+		if !found {
+			fmt.Println("DEVICE:", old_device.Name, "(removed)")
+			continue
+		}
+		fmt.Println("DEVICE:", old_device.Name)
+		printDeviceDiff(old_device, new_device, with_flows)
+	}
+	for _, new_device := range new_devices {
+		if !old_by_name[new_device.Name] {
+			fmt.Println("DEVICE:", new_device.Name, "(added)")
+		}
+	}
+}
+
+// ACE made the final decision on the flow, outbound ACL is checked only if inbound one permitted it
+func decidingACE(explanation acl_match.Explanation) string {
+	verdict := explanation.Inbound
+	if explanation.Outbound != nil {
+		verdict = explanation.Outbound
+	}
+	switch {
+	case verdict == nil:
+		return "no ACL applied"
+	case verdict.Implicit():
+		return "implicit deny of " + verdict.Acl_name
+	default:
+		return cisco_asa_access_entry.StateKey(verdict.Line)
+	}
+}
+
+// matches syslog against both configs, flows are taken as interfaces of the old config see them.
+// Returns flows decided by another ACE in the new config, most flows first.
+func matchSyslogBoth(old_ctx, new_ctx app_context.AppContext, syslog_file string, num_goroutines int) ([]*flowMove, error) {
+	old_ctx.Flows = make(chan network_entities.Flow, 100)
+	wait, err := syslog.Fit(old_ctx, syslog_file, num_goroutines)
+	if err != nil {
+		return nil, err
+	}
+
+	var m sync.Mutex
+	moves := make(map[[2]string]*flowMove)
+
+	errs, _ := errgroup.WithContext(context.TODO())
+	for i := 0; i < num_goroutines; i++ {
+		errs.Go(func() error {
+			for flow := range old_ctx.Flows {
+				for _, app_ctx := range []app_context.AppContext{old_ctx, new_ctx} {
+					err := acl_match.MatchFlow(flow, app_ctx)
+					if err != nil {
+						return err
+					}
+				}
+
+				old_explanation, err := acl_match.Explain(flow, old_ctx)
+				if err != nil {
+					return err
+				}
+				new_explanation, err := acl_match.Explain(flow, new_ctx)
+				if err != nil {
+					return err
+				}
+				key := [2]string{decidingACE(old_explanation), decidingACE(new_explanation)}
+				if key[0] == key[1] {
+					continue
+				}
+
+				m.Lock()
+				move, found := moves[key]
+				if !found {
+					move = &flowMove{old_ace: key[0], new_ace: key[1]}
+					moves[key] = move
+				}
+				move.flows++
+				if len(move.samples) < maxMoveSamples {
+					move.samples = append(move.samples, flow)
+				}
+				m.Unlock()
+			}
+			return nil
+		})
+	}
+	err = errs.Wait()
+	if err != nil {
+		return nil, err
+	}
+	err = checkSyslogRead(old_ctx.Name, wait())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*flowMove, 0, len(moves))
+	for _, move := range moves {
+		result = append(result, move)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].flows != result[j].flows {
+			return result[i].flows > result[j].flows
+		}
+		return result[i].old_ace+result[i].new_ace < result[j].old_ace+result[j].new_ace
+	})
+	return result, nil
+}
+
+func printMoves(moves []*flowMove) {
+	fmt.Println("--- Flows decided by another ACE")
+	for _, move := range moves {
+		fmt.Printf("\t# of flows: %d\n", move.flows)
+		fmt.Printf("\t\told: %s\n", move.old_ace)
+		fmt.Printf("\t\tnew: %s\n", move.new_ace)
+		for _, flow := range move.samples {
+			fmt.Printf("\t\t\t %v\n", flow)
+		}
+	}
+	fmt.Println("=== Flows decided by another ACE")
+}
+
+func isResultsFile(file string) bool {
+	return strings.ToLower(filepath.Ext(file)) == ".json"
+}
+
+// results saved by "report --format json"
+func readResults(file string) ([]deviceReport, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var devices []deviceReport
+	err = json.Unmarshal(content, &devices)
+	if err != nil {
+		return nil, fmt.Errorf("results %s: %w", file, err)
+	}
+	return devices, nil
+}
+
+func runDiff(o options, old_file, new_file string) error {
+	if isResultsFile(old_file) != isResultsFile(new_file) {
+		return fmt.Errorf("diff compares two configs or two saved results (old: %v, new: %v)", old_file, new_file)
+	}
+
+	if isResultsFile(old_file) {
+		if o.Syslog != "" {
+			slog.Warn("saved results are compared, syslog is not read", "syslog", o.Syslog)
+		}
+		old_devices, err := readResults(old_file)
+		if err != nil {
+			return err
+		}
+		new_devices, err := readResults(new_file)
+		if err != nil {
+			return err
+		}
+		printDiff(old_devices, new_devices, true)
+		return nil
+	}
+
+	app_options, err := getAppOptions(o)
+	if err != nil {
+		return err
+	}
+	old_ctx, err := loadDevice(Device{Sh_run: old_file, Sh_route: o.Sh_route}, app_options, io.Discard)
+	if err != nil {
+		return err
+	}
+	new_ctx, err := loadDevice(Device{Sh_run: new_file, Sh_route: o.Sh_route}, app_options, io.Discard)
+	if err != nil {
+		return err
+	}
+
+	var moves []*flowMove
+	with_flows := o.Syslog != ""
+	if with_flows {
+		moves, err = matchSyslogBoth(old_ctx, new_ctx, o.Syslog, int(o.Go_routines))
+		if err != nil {
+			return err
+		}
+	}
+
+	old_device, err := getDeviceReport(old_ctx, true)
+	if err != nil {
+		return err
+	}
+	new_device, err := getDeviceReport(new_ctx, true)
+	if err != nil {
+		return err
+	}
+	printDiff([]deviceReport{old_device}, []deviceReport{new_device}, with_flows)
+
+	if with_flows {
+		printMoves(moves)
+	}
+	return nil
+}
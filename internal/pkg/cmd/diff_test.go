@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+)
+
+func Test_diffACL(t *testing.T) {
+	ace := func(line string, entries ...cisco_asa_access_entry.EntryResult) cisco_asa_access_entry.ACEResult {
+		return cisco_asa_access_entry.ACEResult{Line: line, Entries: entries}
+	}
+	host := cisco_asa_access_entry.EntryResult{Entry: "permit ip 10.0.0.1-10.0.0.1 0.0.0.0-255.255.255.255", Capacity: 1, Flows: 3, Flows_capacity: 1}
+	net := cisco_asa_access_entry.EntryResult{Entry: "permit ip 10.0.0.0-10.0.0.255 0.0.0.0-255.255.255.255", Capacity: 256, Flows: 5, Flows_capacity: 64}
+	remark := ace("access-list a remark servers")
+
+	tests := []struct {
+		name       string
+		old_aces   []cisco_asa_access_entry.ACEResult
+		new_aces   []cisco_asa_access_entry.ACEResult
+		with_flows bool
+		want       []string
+	}{
+		{
+			name:     "same ACEs, other spaces and order",
+			old_aces: []cisco_asa_access_entry.ACEResult{ace("access-list a permit ip host 10.0.0.1 any ", host), remark},
+			new_aces: []cisco_asa_access_entry.ACEResult{remark, ace("access-list a  permit ip host 10.0.0.1 any", host)},
+		},
+		{
+			name:     "added and removed",
+			old_aces: []cisco_asa_access_entry.ACEResult{ace("access-list a permit ip host 10.0.0.1 any", host)},
+			new_aces: []cisco_asa_access_entry.ACEResult{remark, ace("access-list a permit ip 10.0.0.0 255.255.255.0 any", net)},
+			want: []string{
+				"\t- access-list a permit ip host 10.0.0.1 any (capacity: 0x1)",
+				"\t+ access-list a remark servers",
+				"\t+ access-list a permit ip 10.0.0.0 255.255.255.0 any (capacity: 0x100)",
+			},
+		},
+		{
+			name:       "object-group changed",
+			old_aces:   []cisco_asa_access_entry.ACEResult{ace("access-list a permit ip object-group SERVERS any", host)},
+			new_aces:   []cisco_asa_access_entry.ACEResult{ace("access-list a permit ip object-group SERVERS any", host, net)},
+			with_flows: true,
+			want: []string{
+				"\t~ access-list a permit ip object-group SERVERS any",
+				"\t\tbefore: capacity: 0x1, # of flows: 3, ACE capacity utilization(%): 100.000",
+				"\t\tafter:  capacity: 0x101, # of flows: 8, ACE capacity utilization(%): 25.292",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffACL(tt.old_aces, tt.new_aces, tt.with_flows)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffACL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getACETotals(t *testing.T) {
+	any_any := cisco_asa_access_entry.EntryResult{Capacity: math.MaxUint, Flows: 2, Flows_capacity: math.MaxUint}
+	host := cisco_asa_access_entry.EntryResult{Capacity: 1, Flows: 3, Flows_capacity: 1}
+
+	tests := []struct {
+		name    string
+		entries []cisco_asa_access_entry.EntryResult
+		want    aceTotals
+	}{
+		{
+			name:    "sum of entries",
+			entries: []cisco_asa_access_entry.EntryResult{host, host},
+			want:    aceTotals{capacity: 2, flows: 6, flows_capacity: 2},
+		},
+		{
+			name:    "capacity and flows capacity saturate",
+			entries: []cisco_asa_access_entry.EntryResult{any_any, host},
+			want:    aceTotals{capacity: math.MaxUint, flows: 5, flows_capacity: math.MaxUint},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getACETotals(cisco_asa_access_entry.ACEResult{Entries: tt.entries}); got != tt.want {
+				t.Errorf("getACETotals() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	acl_match "github.com/ivankuchin/excessive-acl/internal/pkg/acl_match"
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	cisco_asa_nat "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-nat"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain \"<protocol> <src> -> <dst>\"",
+	Short: "shows ACE making a decision on a flow",
+	Long: `shows ACE making a decision on a flow, like packet-tracer does.
+Interfaces are found in the routing table, flow is checked by inbound ACL of the source interface and outbound ACL of the destination interface.
+Examples:
+  excessive-acl explain -r sh_run -i sh_route "tcp 10.1.1.1:1234 -> 8.8.8.8:443"
+  excessive-acl explain -r sh_run "icmp 10.1.1.1 -> 8.8.8.8 8 0"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExplain(opts, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
+
+func permitOrDeny(permit bool) string {
+	if permit {
+		return "permit"
+	}
+	return "deny"
+}
+
+func printVerdict(direction string, verdict *cisco_asa_acl.Verdict) {
+	if verdict == nil {
+		fmt.Printf("%s ACL: none\n", direction)
+		return
+	}
+	fmt.Printf("%s ACL: %s\n", direction, verdict.Acl_name)
+	if verdict.Implicit() {
+		fmt.Println("\tACE: implicit deny")
+	} else {
+		fmt.Println("\tACE:", verdict.Line)
+		fmt.Println("\t\tACE compiled:", verdict.Entry)
+	}
+	fmt.Println("\tresult:", permitOrDeny(verdict.Permit))
+}
+
+// prints ACEs making a decision on the flow given in command line
+func runExplain(o options, flow_args []string) error {
+	if o.Sh_run == "" {
+		return errors.New("explain requires -r")
+	}
+
+	address_form, err := cisco_asa_nat.ParseAddressForm(o.Acl_addresses)
+	if err != nil {
+		return err
+	}
+	app_ctx, err := loadDevice(Device{Sh_run: o.Sh_run, Sh_route: o.Sh_route}, app_context.AppContext{Address_form: address_form}, io.Discard)
+	if err != nil {
+		return err
+	}
+
+	flow, err := acl_match.ParseFlow(flow_args)
+	if err != nil {
+		return err
+	}
+	explanation, err := acl_match.Explain(flow, app_ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("flow:", explanation.Flow)
+	printVerdict("inbound", explanation.Inbound)
+	if explanation.Inbound == nil || explanation.Inbound.Permit {
+		printVerdict("outbound", explanation.Outbound)
+	}
+	if explanation.Inbound == nil && explanation.Outbound == nil {
+		fmt.Println("result: no ACL applied, security levels decide")
+	} else {
+		fmt.Println("result:", permitOrDeny(explanation.Permit))
+	}
+
+	return nil
+}
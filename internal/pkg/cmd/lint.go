@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "finds ACEs shadowed by earlier ACEs, syslog is not needed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLint(opts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(o options) error {
+	devices, err := getCheckedDevices(o, false)
+	if err != nil {
+		return err
+	}
+	app_options, err := getAppOptions(o)
+	if err != nil {
+		return err
+	}
+	multi_device := isMultiDevice(o, devices)
+
+	shadowed_aces := 0
+	for _, device := range devices {
+		app_ctx, err := loadDevice(device, app_options, io.Discard)
+		if err != nil {
+			return err
+		}
+
+		if multi_device {
+			fmt.Println("DEVICE:", app_ctx.Name)
+		}
+		for i := range app_ctx.Access_lists {
+			fmt.Println("ACL:", app_ctx.Access_lists[i].Name)
+			for _, shadowed := range app_ctx.Access_lists[i].Shadowed() {
+				shadowed_aces++
+				fmt.Printf("\tACE: %s\n", shadowed.Line)
+				if shadowed.Conflicting {
+					fmt.Println("\t\tnever matches, earlier ACE has another action:")
+				} else {
+					fmt.Println("\t\tnever matches, redundant:")
+				}
+				for _, line := range shadowed.By {
+					fmt.Printf("\t\tshadowed by: %s\n", line)
+				}
+			}
+		}
+	}
+	fmt.Println("shadowed ACEs:", shadowed_aces)
+
+	return nil
+}
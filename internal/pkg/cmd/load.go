@@ -0,0 +1,379 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	acl_match "github.com/ivankuchin/excessive-acl/internal/pkg/acl_match"
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_acg "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-group"
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+	cisco_asa_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-context"
+	cisco_asa_nat "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-nat"
+	sh_ip_route "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/sh-ip-route"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/hyperloglog"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+)
+
+// accepts date or RFC3339 time, empty string is no limit
+func parseTime(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", str); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, str)
+}
+
+// hostname from show run, empty if not configured
+func getHostname(sh_run sh_run_pipe.Text) string {
+	hostname_text := sh_run.Prefix("hostname ")
+	if hostname_text.Len() == 0 {
+		return ""
+	}
+	line, err := hostname_text.Get(0)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "hostname "))
+}
+
+// every context given in --context must be defined in the system config
+func checkContexts(system_file string, devices []Device) error {
+	system, err := sh_run_pipe.Load(system_file)
+	if err != nil {
+		return err
+	}
+	contexts, err := cisco_asa_context.Parse(system)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	for _, context := range contexts {
+		known[context.Name] = true
+	}
+	given := make(map[string]bool)
+	for _, device := range devices {
+		if !known[device.Name] {
+			return fmt.Errorf("context not found in system config (context: %v)", device.Name)
+		}
+		given[device.Name] = true
+	}
+	for _, context := range contexts {
+		if !given[context.Name] {
+			slog.Warn("context is not analyzed, use --context to add it", "context", context.Name, "config_url", context.Config_url)
+		}
+	}
+
+	return nil
+}
+
+// parses config and routing table of the device, app_ctx carries options common for all devices.
+// Progress goes to report.
+func loadDevice(device Device, app_ctx app_context.AppContext, report io.Writer) (app_context.AppContext, error) {
+	sh_run, err := sh_run_pipe.Load(device.Sh_run)
+	if err != nil {
+		return app_ctx, err
+	}
+
+	hostname := getHostname(sh_run)
+	app_ctx.Name = device.Name
+	if app_ctx.Name == "" {
+		app_ctx.Name = hostname
+	}
+	if app_ctx.Name == "" {
+		app_ctx.Name = "asa"
+	}
+	if device.Context {
+		// --- "logging device-id" tags messages by context name or by hostname
+		app_ctx.Device_ids = []string{device.Name}
+		if hostname != "" && hostname != device.Name {
+			app_ctx.Device_ids = append(app_ctx.Device_ids, hostname)
+		}
+	}
+
+	// --- parse access-groups in "sh run"
+	access_groups, err := cisco_asa_acg.Parse(sh_run)
+	if err != nil {
+		return app_ctx, err
+	}
+	if len(access_groups) == 0 {
+		return app_ctx, fmt.Errorf("no access-group found (device: %v)", app_ctx.Name)
+	}
+
+	fmt.Fprintln(report, "--- Access-groups")
+	for _, access_group := range access_groups {
+		access_group.Print(report)
+	}
+
+	// parse access-lists in "sh run"
+	t0 := time.Now()
+	access_lists, err := cisco_asa_acl.Parse(sh_run, access_groups)
+	if err != nil {
+		return app_ctx, err
+	}
+	t1 := time.Since(t0)
+
+	if len(access_lists) == 0 {
+		return app_ctx, fmt.Errorf("no access-lists found (device: %v)", app_ctx.Name)
+	}
+
+	fmt.Fprintf(report, "--- Access-lists\n")
+	if utils.IsTrace() {
+		for _, acl := range access_lists {
+			var dump strings.Builder
+			acl.Print(&dump)
+			utils.Trace("access-list", "device", app_ctx.Name, "acl", acl.Name, "dump", dump.String())
+		}
+	}
+	fmt.Fprintf(report, "=== Access-lists (%v sec)\n", t1.Seconds())
+
+	for i := range access_lists {
+		model, ok := app_ctx.Acl_capacity_models[access_lists[i].Name]
+		if !ok {
+			model = app_ctx.Capacity_model
+		}
+		access_lists[i].SetCapacityModel(model)
+	}
+
+	if device.Sh_acl != "" {
+		err = loadHashes(device.Sh_acl, access_lists)
+		if err != nil {
+			return app_ctx, err
+		}
+	}
+
+	fmt.Fprintf(report, "--- NAT\n")
+	nat_rules, err := cisco_asa_nat.Parse(sh_run)
+	if err != nil {
+		return app_ctx, err
+	}
+	fmt.Fprintf(report, "=== NAT\n")
+
+	fmt.Fprintf(report, "--- Routing table\n")
+	var routing_table sh_ip_route.RoutingTable
+	if device.Sh_route != "" {
+		routing_table, err = sh_ip_route.Fit(sh_run, device.Sh_route)
+	} else {
+		slog.Info("no show route given, routing table is derived from connected interfaces and static routes", "device", app_ctx.Name)
+		routing_table, err = sh_ip_route.FitConfig(sh_run)
+	}
+	if err != nil {
+		return app_ctx, err
+	}
+
+	if utils.IsTrace() {
+		var dump strings.Builder
+		routing_table.PrintTree(&dump)
+		utils.Trace("routing table", "device", app_ctx.Name, "dump", dump.String())
+	}
+	fmt.Fprintf(report, "=== Routing table\n")
+
+	app_ctx.Access_groups = access_groups
+	app_ctx.Access_lists = access_lists
+	app_ctx.Routing_table = routing_table
+	app_ctx.Nat_rules = nat_rules
+	app_ctx.Address_form = app_ctx.Address_form.Resolve(sh_run)
+
+	return app_ctx, nil
+}
+
+// ACE hashes of "show access-list", 106100 messages are credited to ACE of the hash
+func loadHashes(sh_acl string, access_lists []cisco_asa_acl.Accesslist) error {
+	readFile, err := os.Open(sh_acl)
+	if err != nil {
+		return fmt.Errorf("can't open show access-list: %w", err)
+	}
+	defer readFile.Close()
+
+	hashes, err := cisco_asa_acl.ParseHashes(readFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", sh_acl, err)
+	}
+	for i := range access_lists {
+		access_lists[i].SetHashes(hashes)
+	}
+	return nil
+}
+
+// matches syslog of the device against its access-lists
+func matchSyslog(app_ctx app_context.AppContext, syslog_file string, num_goroutines int, report io.Writer) error {
+	fmt.Fprintf(report, "--- Syslog parsing \n")
+
+	app_ctx.Flows = make(chan network_entities.Flow, 100)
+
+	t0 := time.Now()
+	wait, err := syslog.Fit(app_ctx, syslog_file, num_goroutines)
+	if err != nil {
+		return err
+	}
+
+	err = acl_match.StartRoutines(num_goroutines, app_ctx)
+	if err != nil {
+		return err
+	}
+	err = checkSyslogRead(app_ctx.Name, wait())
+	if err != nil {
+		return err
+	}
+	t1 := time.Since(t0)
+	fmt.Fprintf(report, "=== Syslog parsing (%v sec)\n", t1.Seconds())
+
+	return nil
+}
+
+// skipped lines don't stop analysis, they are reported as a warning. Syslog not read to the end is an error.
+func checkSyslogRead(device string, err error) error {
+	var skipped *syslog.SkippedLinesError
+	if errors.As(err, &skipped) {
+		slog.Warn("syslog lines can't be parsed, they are skipped", "device", device, "lines", skipped.Lines, "first", skipped.First)
+		return nil
+	}
+	return err
+}
+
+// options common for all devices: time window and statistics
+func getAppOptions(o options) (app_context.AppContext, error) {
+	var app_options app_context.AppContext
+
+	since, err := parseTime(o.Since)
+	if err != nil {
+		return app_options, err
+	}
+	until, err := parseTime(o.Until)
+	if err != nil {
+		return app_options, err
+	}
+	bucket, err := network_entities.ParseBucket(o.Bucket)
+	if err != nil {
+		return app_options, err
+	}
+	var hll_precision uint8
+	if o.Approximate {
+		if o.Hll_precision < hyperloglog.MinPrecision || o.Hll_precision > hyperloglog.MaxPrecision {
+			return app_options, fmt.Errorf("--hll-precision must be in %d..%d range", hyperloglog.MinPrecision, hyperloglog.MaxPrecision)
+		}
+		hll_precision = o.Hll_precision
+	}
+	if o.Max_prefix_length > 32 {
+		return app_options, errors.New("--max-prefix-length must be in 1..32 range")
+	}
+	if o.Max_prefix_length != 0 && o.Approximate {
+		return app_options, errors.New("--max-prefix-length requires exact count of addresses, it can't be used with --approximate")
+	}
+
+	app_options.Address_form, err = cisco_asa_nat.ParseAddressForm(o.Acl_addresses)
+	if err != nil {
+		return app_options, err
+	}
+	app_options.Capacity_model, err = network_entities.ParseCapacityModel(o.Capacity_model, network_entities.CapacityModel{})
+	if err != nil {
+		return app_options, err
+	}
+	for _, acl_model := range o.Acl_capacity_models {
+		acl_name, spec, found := strings.Cut(acl_model, ":")
+		if !found || acl_name == "" {
+			return app_options, fmt.Errorf("ACL capacity model must be acl_name:model (acl-capacity-model: %v)", acl_model)
+		}
+		if app_options.Acl_capacity_models == nil {
+			app_options.Acl_capacity_models = make(map[string]network_entities.CapacityModel)
+		}
+		app_options.Acl_capacity_models[acl_name], err = network_entities.ParseCapacityModel(spec, app_options.Capacity_model)
+		if err != nil {
+			return app_options, err
+		}
+	}
+
+	app_options.Since = since
+	app_options.Until = until
+	app_options.Stats_options = network_entities.StatsOptions{Bucket: bucket, Hll_precision: hll_precision, Samples: o.Samples, Max_prefix_length: o.Max_prefix_length, Max_port_range: o.Max_port_range}
+	return app_options, nil
+}
+
+// devices given in options, contexts are checked against the system config
+func getCheckedDevices(o options, require_syslog bool) ([]Device, error) {
+	devices, err := getDevices(o, require_syslog)
+	if err != nil {
+		return nil, err
+	}
+	if o.System != "" {
+		err = checkContexts(o.System, devices)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return devices, nil
+}
+
+func isMultiDevice(o options, devices []Device) bool {
+	return len(devices) > 1 || o.System != ""
+}
+
+// loads every device and matches its syslog, progress goes to report
+func analyzeDevices(o options, devices []Device, report io.Writer) ([]app_context.AppContext, error) {
+	app_options, err := getAppOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	multi_device := isMultiDevice(o, devices)
+
+	var state stateFile
+	if o.State != "" {
+		state, err = readState(o.State, o.Bucket, app_options.Stats_options.Hll_precision, capacityModels(app_options))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var app_ctxs []app_context.AppContext
+	for _, device := range devices {
+		if multi_device {
+			fmt.Fprintln(report, "--- Device", device.Name)
+		}
+
+		app_ctx, err := loadDevice(device, app_options, report)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.State != "" {
+			err = loadDeviceState(&app_ctx, state.Devices[app_ctx.Name])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = matchSyslog(app_ctx, device.Syslog, int(o.Go_routines), report)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.State != "" {
+			state.Devices[app_ctx.Name] = saveDeviceState(app_ctx)
+		}
+
+		if multi_device {
+			fmt.Fprintln(report, "=== Device", device.Name)
+		}
+		app_ctxs = append(app_ctxs, app_ctx)
+	}
+
+	if o.State != "" {
+		err = writeState(o.State, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return app_ctxs, nil
+}
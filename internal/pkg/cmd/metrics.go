@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// samples of a metric in Prometheus text format, written under single HELP and TYPE
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels are name, value pairs
+func (f *metricFamily) add(value float64, labels ...string) {
+	var sample strings.Builder
+	sample.WriteString(f.name)
+	if len(labels) > 0 {
+		sample.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sample.WriteString(",")
+			}
+			fmt.Fprintf(&sample, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		sample.WriteString("}")
+	}
+	sample.WriteString(" ")
+	sample.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	f.samples = append(f.samples, sample.String())
+}
+
+func (f *metricFamily) write(w *strings.Builder) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	for _, sample := range f.samples {
+		w.WriteString(sample)
+		w.WriteString("\n")
+	}
+}
+
+// per ACL and per ACE gauges, syslog parser counters and backlog of flows waiting for matching
+func (s *server) writeMetrics(w *strings.Builder) error {
+	newFamily := func(name, kind, help string) *metricFamily {
+		return &metricFamily{name: "excessive_acl_" + name, kind: kind, help: help}
+	}
+	acl_aces := newFamily("acl_aces", "gauge", "ACEs in ACL, remarks included.")
+	acl_unused_aces := newFamily("acl_unused_aces", "gauge", "ACEs without flows.")
+	acl_flows := newFamily("acl_flows", "gauge", "Flows matched ACL.")
+	ace_flows := newFamily("ace_flows", "gauge", "Flows matched ACE.")
+	ace_src_ips := newFamily("ace_unique_src_ips", "gauge", "Unique source addresses of flows matched ACE, 0 if ACE permits any source.")
+	ace_dst_ips := newFamily("ace_unique_dst_ips", "gauge", "Unique destination addresses of flows matched ACE, 0 if ACE permits any destination.")
+	ace_capacity := newFamily("ace_capacity", "gauge", "Number of flows ACE permits.")
+	ace_flows_capacity := newFamily("ace_flows_capacity", "gauge", "Capacity of flows matched ACE.")
+	ace_utilization := newFamily("ace_utilization_percent", "gauge", "Share of ACE capacity used by flows.")
+	lines_read := newFamily("syslog_lines_read_total", "counter", "Syslog lines read.")
+	lines_skipped := newFamily("syslog_lines_skipped_total", "counter", "Syslog lines with messages not used for analysis.")
+	parse_errors := newFamily("syslog_parse_errors_total", "counter", "Syslog lines failed to parse.")
+	backlog := newFamily("flows_backlog", "gauge", "Flows parsed and waiting for matching against ACLs.")
+
+	// --- entries lock themselves, scrape doesn't need all ACEs at the same moment and doesn't stop matching
+	s.results.RLock()
+	defer s.results.RUnlock()
+
+	for _, app_ctx := range s.app_ctxs {
+		device := app_ctx.Name
+		for i := range app_ctx.Access_lists {
+			acl := app_ctx.Access_lists[i].Name
+			aces, unused_aces, flows := app_ctx.Access_lists[i].Summary()
+			acl_aces.add(float64(aces), "device", device, "acl", acl)
+			acl_unused_aces.add(float64(unused_aces), "device", device, "acl", acl)
+			acl_flows.add(float64(flows), "device", device, "acl", acl)
+
+			results, err := app_ctx.Access_lists[i].GetResults()
+			if err != nil {
+				return err
+			}
+			for index, result := range results {
+				if len(result.Entries) == 0 {
+					continue
+				}
+				totals := getACETotals(result)
+				labels := []string{"device", device, "acl", acl, "ace", strconv.Itoa(index)}
+				ace_flows.add(float64(totals.flows), labels...)
+				ace_src_ips.add(float64(totals.unique_src_ips), labels...)
+				ace_dst_ips.add(float64(totals.unique_dst_ips), labels...)
+				ace_capacity.add(float64(totals.capacity), labels...)
+				ace_flows_capacity.add(float64(totals.flows_capacity), labels...)
+				ace_utilization.add(totals.utilization(), labels...)
+			}
+		}
+
+		read, failed, skipped := app_ctx.Parser_stats.Get()
+		lines_read.add(float64(read), "device", device)
+		parse_errors.add(float64(failed), "device", device)
+		message_ids := make([]string, 0, len(skipped))
+		for message_id := range skipped {
+			message_ids = append(message_ids, message_id)
+		}
+		sort.Strings(message_ids)
+		for _, message_id := range message_ids {
+			lines_skipped.add(float64(skipped[message_id]), "device", device, "message_id", message_id)
+		}
+		backlog.add(float64(len(app_ctx.Flows)), "device", device)
+	}
+
+	for _, family := range []*metricFamily{
+		acl_aces, acl_unused_aces, acl_flows,
+		ace_flows, ace_src_ips, ace_dst_ips, ace_capacity, ace_flows_capacity, ace_utilization,
+		lines_read, lines_skipped, parse_errors, backlog,
+	} {
+		family.write(w)
+	}
+	return nil
+}
+
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var metrics strings.Builder
+	err := s.writeMetrics(&metrics)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(metrics.String()))
+}
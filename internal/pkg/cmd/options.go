@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// options shared by subcommands, set by persistent flags or by config file
+type options struct {
+	Config     string
+	Log_level  string
+	Log_format string
+
+	Sh_run        string
+	Sh_route      string
+	Sh_acl        string
+	Syslog        string
+	Devices       []string
+	Devices_file  string
+	System        string
+	Contexts      []string
+	Acl_addresses string
+
+	Go_routines   int16
+	Since         string
+	Until         string
+	Bucket        string
+	Approximate   bool
+	Hll_precision uint8
+	Samples       int
+	State         string
+
+	Max_prefix_length   uint8
+	Max_port_range      uint16
+	Capacity_model      string
+	Acl_capacity_models []string
+}
+
+var opts options
+
+var ErrorUnknownConfigFormat = errors.New("unknown config format, use .yaml, .yml or .toml")
+
+func addPersistentFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&opts.Config, "config", "", "YAML or TOML file with options, keys are flag names, command line flags take precedence")
+	flags.StringVar(&opts.Log_level, "log-level", "info", "diagnostics on stderr: trace, debug, info, warn or error")
+	flags.StringVar(&opts.Log_format, "log-format", "text", "diagnostics format: text or json")
+
+	flags.StringVarP(&opts.Sh_run, "sh-run", "r", "", "file with \"show run\" output")
+	flags.StringVarP(&opts.Syslog, "syslog", "s", "", "syslog file")
+	flags.StringVarP(&opts.Sh_route, "sh-ip-route", "i", "", "file with \"show ip route\" output, routing table is derived from \"show run\" if not given")
+	flags.StringVar(&opts.Sh_acl, "sh-access-list", "", "file with \"show access-list\" output, ACE hashes of 106100 messages are looked up in it")
+
+	flags.StringArrayVar(&opts.Devices, "device", nil, "firewall to analyze as name:sh_run:sh_route:syslog[:sh_access_list] (sh_route may be empty), can be repeated")
+	flags.StringVar(&opts.Devices_file, "devices-file", "", "file with one name:sh_run:sh_route:syslog[:sh_access_list] per line")
+
+	flags.StringVar(&opts.System, "system", "", "file with \"show run\" of the system in multiple context mode, syslog from -s is shared by contexts")
+	flags.StringArrayVar(&opts.Contexts, "context", nil, "security context to analyze as name:sh_run[:sh_route], can be repeated")
+
+	flags.Int16VarP(&opts.Go_routines, "go-routines", "g", 1, "number of go routines to process syslog messages")
+
+	flags.StringVar(&opts.Acl_addresses, "acl-addresses", "auto", "addresses used in ACLs: real (ASA 8.3+), mapped (before 8.3) or auto (detect by \"ASA Version\")")
+	flags.StringVar(&opts.Since, "since", "", "skip flows logged before that time (2006-01-02 or 2006-01-02T15:04:05Z07:00)")
+	flags.StringVar(&opts.Until, "until", "", "skip flows logged at or after that time (2006-01-02 or 2006-01-02T15:04:05Z07:00)")
+	flags.StringVar(&opts.Bucket, "bucket", "", "report flows and utilization per day or week")
+
+	flags.BoolVar(&opts.Approximate, "approximate", false, "estimate unique addresses with hyperloglog, memory doesn't grow with number of addresses")
+	flags.Uint8Var(&opts.Hll_precision, "hll-precision", 14, "hyperloglog precision 4..16, standard error is 1.04/sqrt(2^precision)")
+	flags.Uint8Var(&opts.Max_prefix_length, "max-prefix-length", 0, "flows capacity counts addresses summarized to prefixes of that length (1..32) instead of unique addresses, ex: 24")
+	flags.Uint16Var(&opts.Max_port_range, "max-port-range", 0, "flows capacity counts ports summarized to ranges of that size instead of unique ports, ex: 1024")
+	flags.StringVar(&opts.Capacity_model, "capacity-model", "default", "how ACE parts not restricting flows count in capacity, ex: any=full,src-port=full (keys: any, src-any, dst-any, dst-port, src-port, icmp; values: one, full)")
+	flags.StringArrayVar(&opts.Acl_capacity_models, "acl-capacity-model", nil, "capacity model of ACL as acl_name:model, keys not given are taken from --capacity-model, can be repeated")
+	flags.IntVar(&opts.Samples, "samples", 100, "flows kept per compiled entry as examples")
+	flags.StringVar(&opts.State, "state", "", "file with per-ACE aggregates of previous runs, flows of this run are merged and the file is updated")
+}
+
+func readConfig(file string) (map[string]any, error) {
+	values := make(map[string]any)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		_, err = toml.Decode(string(content), &values)
+	default:
+		return nil, fmt.Errorf("%w (config: %s)", ErrorUnknownConfigFormat, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", file, err)
+	}
+
+	return values, nil
+}
+
+// names of flags of the command and of all its subcommands
+func knownFlags(command *cobra.Command, known map[string]bool) {
+	command.Flags().VisitAll(func(flag *pflag.Flag) {
+		known[flag.Name] = true
+	})
+	command.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		known[flag.Name] = true
+	})
+	for _, sub := range command.Commands() {
+		knownFlags(sub, known)
+	}
+}
+
+// sets flags not given in command line from config file.
+// Keys of other subcommands are skipped, so a single config serves all of them.
+func applyConfig(command *cobra.Command, file string) error {
+	values, err := readConfig(file)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	knownFlags(command.Root(), known)
+
+	flags := command.Flags()
+	for key, value := range values {
+		if !known[key] {
+			return fmt.Errorf("unknown option in config (config: %v, option: %v)", file, key)
+		}
+
+		flag := flags.Lookup(key)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		// --- repeatable flags (ex: device) are given as lists
+		items, is_list := value.([]any)
+		if !is_list {
+			items = []any{value}
+		}
+		for _, item := range items {
+			err = flag.Value.Set(fmt.Sprint(item))
+			if err != nil {
+				return fmt.Errorf("config %s, option %s: %w", file, key, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_applyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		config  string
+		want    options
+		wantErr bool
+	}{
+		{
+			name:   "yaml",
+			config: "testdata/config.yaml",
+			want: options{
+				Sh_run: "asa1/sh_run.txt", Syslog: "asa1/syslog.log", Bucket: "week", Approximate: true, Hll_precision: 12,
+				Capacity_model: "any=full", Acl_capacity_models: []string{"outside_in:any=one"},
+				Devices: []string{"asa2:asa2/sh_run.txt::asa2/syslog.log", "asa3:asa3/sh_run.txt::asa3/syslog.log"},
+			},
+		},
+		{
+			name:   "toml",
+			config: "testdata/config.toml",
+			want: options{
+				Sh_run: "asa1/sh_run.txt", Syslog: "asa1/syslog.log", Bucket: "week", Approximate: true, Hll_precision: 12,
+				Capacity_model: "any=full", Acl_capacity_models: []string{"outside_in:any=one"},
+				Devices: []string{"asa2:asa2/sh_run.txt::asa2/syslog.log", "asa3:asa3/sh_run.txt::asa3/syslog.log"},
+			},
+		},
+		{
+			name:   "command line takes precedence",
+			args:   []string{"--bucket", "day", "--device", "asa4:asa4/sh_run.txt::asa4/syslog.log"},
+			config: "testdata/config.yaml",
+			want: options{
+				Sh_run: "asa1/sh_run.txt", Syslog: "asa1/syslog.log", Bucket: "day", Approximate: true, Hll_precision: 12,
+				Capacity_model: "any=full", Acl_capacity_models: []string{"outside_in:any=one"},
+				Devices: []string{"asa4:asa4/sh_run.txt::asa4/syslog.log"},
+			},
+		},
+		{name: "unknown option", config: "testdata/config_unknown.yaml", wantErr: true},
+		{name: "unknown format", config: "testdata/devices.txt", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts = options{}
+			defer func() { opts = options{} }()
+
+			root := &cobra.Command{Use: "test"}
+			addPersistentFlags(root.PersistentFlags())
+			sub := &cobra.Command{Use: "sub"}
+			sub.Flags().Float64("threshold", 1, "")
+			root.AddCommand(sub)
+
+			err := root.ParseFlags(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = applyConfig(root, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// --- defaults not touched by config
+			tt.want.Log_level, tt.want.Log_format, tt.want.Acl_addresses, tt.want.Go_routines = "info", "text", "auto", 1
+			tt.want.Samples = 100
+			if !reflect.DeepEqual(opts, tt.want) {
+				t.Errorf("applyConfig() = %+v, want %+v", opts, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_acg "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-group"
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	"github.com/spf13/cobra"
+)
+
+// weights of risk factors, every factor is 0..1 and score is their weighted average in percents
+type riskWeights struct {
+	capacity    float64
+	utilization float64
+	exposure    float64
+	ports       float64
+}
+
+var (
+	rank_top             int
+	rank_weights         riskWeights
+	rank_sensitive_ports []uint
+	rank_internet_ifaces []string
+)
+
+var rankCmd = &cobra.Command{
+	Use:   "rank",
+	Short: "lists permit ACEs to fix first, ranked by capacity, utilization, exposure and sensitive ports",
+	Long: `lists permit ACEs to fix first across all ACLs of the firewall. Score (0..100) is weighted average of:
+  capacity    - log2 of ACE capacity over 64 bits
+  utilization - unused share of ACE capacity, 1 - utilization
+  exposure    - source is any, or ACL filters inbound (or to-the-box) traffic of internet-facing interface
+  ports       - ACE permits a sensitive destination port
+Internet-facing interfaces are the ones of the default route, unless --internet-iface given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRank(opts, rank_top, rank_weights, rank_sensitive_ports, rank_internet_ifaces)
+	},
+}
+
+func init() {
+	rankCmd.Flags().IntVar(&rank_top, "top", 10, "number of ACEs listed, 0 lists all")
+	rankCmd.Flags().Float64Var(&rank_weights.capacity, "weight-capacity", 1, "weight of ACE capacity")
+	rankCmd.Flags().Float64Var(&rank_weights.utilization, "weight-utilization", 1, "weight of unused share of ACE capacity")
+	rankCmd.Flags().Float64Var(&rank_weights.exposure, "weight-exposure", 1, "weight of any source or internet-facing ACL")
+	rankCmd.Flags().Float64Var(&rank_weights.ports, "weight-ports", 1, "weight of sensitive destination ports")
+	rankCmd.Flags().UintSliceVar(&rank_sensitive_ports, "sensitive-ports", []uint{22, 3389, 445, 1433}, "sensitive destination ports")
+	rankCmd.Flags().StringArrayVar(&rank_internet_ifaces, "internet-iface", nil, "internet-facing interface, can be repeated (default: interfaces of the default route)")
+	rootCmd.AddCommand(rankCmd)
+}
+
+// permit ACE with its risk factors
+type aceRisk struct {
+	acl             string
+	line            string
+	score           float64
+	totals          aceTotals
+	exposure        cisco_asa_access_entry.Exposure
+	internet_facing bool
+}
+
+func (w riskWeights) check() error {
+	if w.capacity < 0 || w.utilization < 0 || w.exposure < 0 || w.ports < 0 || w.capacity+w.utilization+w.exposure+w.ports == 0 {
+		return fmt.Errorf("weights must not be negative, at least one of them must be positive (weights: %+v)", w)
+	}
+	return nil
+}
+
+func (w riskWeights) score(totals aceTotals, exposure cisco_asa_access_entry.Exposure, internet_facing bool) float64 {
+	var capacity, unused, exposed, ports float64
+	if totals.capacity > 1 {
+		capacity = min(math.Log2(float64(totals.capacity))/64, 1)
+	}
+	unused = 1 - min(totals.utilization()/100, 1)
+	if exposure.Any_source || internet_facing {
+		exposed = 1
+	}
+	if len(exposure.Sensitive_ports) > 0 {
+		ports = 1
+	}
+
+	sum := w.capacity*capacity + w.utilization*unused + w.exposure*exposed + w.ports*ports
+	return sum / (w.capacity + w.utilization + w.exposure + w.ports) * 100
+}
+
+// ACLs filtering inbound or to-the-box traffic of the interfaces
+func internetFacingACLs(access_groups []cisco_asa_acg.Accessgroup, ifaces []string) map[string]bool {
+	acls := make(map[string]bool)
+	for _, access_group := range access_groups {
+		if slices.Contains(ifaces, access_group.Iface) && (access_group.Direction == cisco_asa_acg.Inbound || access_group.Control_plane) {
+			acls[access_group.Acl_name] = true
+		}
+	}
+	return acls
+}
+
+// permit ACEs of all ACLs, highest score first
+func rankACEs(app_ctx app_context.AppContext, weights riskWeights, sensitive_ports []uint16, internet_ifaces []string) ([]aceRisk, error) {
+	if len(internet_ifaces) == 0 {
+		internet_ifaces = app_ctx.Routing_table.DefaultRouteIfaces()
+	}
+	internet_facing := internetFacingACLs(app_ctx.Access_groups, internet_ifaces)
+
+	var risks []aceRisk
+	for i := range app_ctx.Access_lists {
+		acl := &app_ctx.Access_lists[i]
+		results, err := acl.GetResults()
+		if err != nil {
+			return nil, err
+		}
+		exposures := acl.Exposures(sensitive_ports)
+
+		for j, result := range results {
+			if len(result.Entries) == 0 || !result.Entries[0].Permit {
+				continue
+			}
+			risk := aceRisk{
+				acl:             acl.Name,
+				line:            result.Line,
+				totals:          getACETotals(result),
+				exposure:        exposures[j],
+				internet_facing: internet_facing[acl.Name],
+			}
+			risk.score = weights.score(risk.totals, risk.exposure, risk.internet_facing)
+			risks = append(risks, risk)
+		}
+	}
+
+	// --- equal scores keep wider ACE first, then ACL order
+	sort.SliceStable(risks, func(i, j int) bool {
+		if risks[i].score != risks[j].score {
+			return risks[i].score > risks[j].score
+		}
+		return risks[i].totals.capacity > risks[j].totals.capacity
+	})
+	return risks, nil
+}
+
+func (r aceRisk) String() string {
+	var exposure []string
+	if r.exposure.Any_source {
+		exposure = append(exposure, "any source")
+	}
+	if r.internet_facing {
+		exposure = append(exposure, "internet-facing")
+	}
+	if len(exposure) == 0 {
+		exposure = append(exposure, "none")
+	}
+
+	ports := "none"
+	if len(r.exposure.Sensitive_ports) > 0 {
+		ports = strings.Trim(fmt.Sprint(r.exposure.Sensitive_ports), "[]")
+	}
+
+	return fmt.Sprintf("%s, exposure: %s, sensitive ports: %s", r.totals.String(true), strings.Join(exposure, ", "), ports)
+}
+
+func runRank(o options, top int, weights riskWeights, sensitive_ports []uint, internet_ifaces []string) error {
+	err := weights.check()
+	if err != nil {
+		return err
+	}
+	if top < 0 {
+		return fmt.Errorf("--top must not be negative (top: %d)", top)
+	}
+	ports := make([]uint16, 0, len(sensitive_ports))
+	for _, p := range sensitive_ports {
+		if p == 0 || p > 65535 {
+			return fmt.Errorf("sensitive port must be in 1..65535 range (port: %d)", p)
+		}
+		ports = append(ports, uint16(p))
+	}
+
+	devices, err := getCheckedDevices(o, true)
+	if err != nil {
+		return err
+	}
+	multi_device := isMultiDevice(o, devices)
+
+	app_ctxs, err := analyzeDevices(o, devices, io.Discard)
+	if err != nil {
+		return err
+	}
+
+	for _, app_ctx := range app_ctxs {
+		if multi_device {
+			fmt.Println("DEVICE:", app_ctx.Name)
+		}
+		risks, err := rankACEs(app_ctx, weights, ports, internet_ifaces)
+		if err != nil {
+			return err
+		}
+		if top > 0 && len(risks) > top {
+			risks = risks[:top]
+		}
+
+		fmt.Println("--- Fix first")
+		for i, risk := range risks {
+			fmt.Printf("%d. score: %.1f, ACL: %s, ACE: %s\n", i+1, risk.score, risk.acl, risk.line)
+			fmt.Printf("\t%s\n", risk)
+		}
+	}
+	return nil
+}
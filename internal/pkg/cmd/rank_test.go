@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func Test_rankACEs(t *testing.T) {
+	app_ctx := loadTestDevice(t)
+	sensitive_ports := []uint16{22, 3389, 445, 1433}
+
+	tests := []struct {
+		name            string
+		weights         riskWeights
+		internet_ifaces []string
+		want            []string
+	}{
+		{
+			name:    "default weights, internet-facing by default route",
+			weights: riskWeights{capacity: 1, utilization: 1, exposure: 1, ports: 1},
+			want: []string{
+				"53.1 access-list inside_in extended permit icmp any any echo",
+				"50.0 access-list outside_out extended permit ip any any",
+				"27.8 access-list inside_in extended permit tcp 192.168.0.0 255.255.255.0 any eq 443",
+			},
+		},
+		{
+			name:    "capacity and utilization only",
+			weights: riskWeights{capacity: 1, utilization: 1},
+			want: []string{
+				"56.2 access-list inside_in extended permit icmp any any echo",
+				"55.7 access-list inside_in extended permit tcp 192.168.0.0 255.255.255.0 any eq 443",
+				"0.0 access-list outside_out extended permit ip any any",
+			},
+		},
+		{
+			name:            "inside is internet-facing",
+			weights:         riskWeights{exposure: 1},
+			internet_ifaces: []string{"inside"},
+			want: []string{
+				"100.0 access-list inside_in extended permit tcp 192.168.0.0 255.255.255.0 any eq 443",
+				"100.0 access-list inside_in extended permit icmp any any echo",
+				"100.0 access-list outside_out extended permit ip any any",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risks, err := rankACEs(app_ctx, tt.weights, sensitive_ports, tt.internet_ifaces)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, risk := range risks {
+				got = append(got, fmt.Sprintf("%.1f %s", risk.score, risk.line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankACEs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_riskWeights_check(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights riskWeights
+		wantErr bool
+	}{
+		{name: "single factor", weights: riskWeights{ports: 2}},
+		{name: "all zero", weights: riskWeights{}, wantErr: true},
+		{name: "negative", weights: riskWeights{capacity: 1, exposure: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.weights.check(); (err != nil) != tt.wantErr {
+				t.Errorf("riskWeights.check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
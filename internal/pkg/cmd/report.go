@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	"github.com/spf13/cobra"
+)
+
+var report_format string
+
+// numbers of a single ACL in json report
+type aclReport struct {
+	Name        string
+	Aces        int
+	Unused_aces int
+	Flows       uint64
+	Results     []cisco_asa_access_entry.ACEResult `json:",omitempty"`
+}
+
+type deviceReport struct {
+	Name         string
+	Access_lists []aclReport
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "prints per ACL summary (text) or results of every ACE (json) without progress output",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReport(opts, report_format)
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&report_format, "format", "text", "report format: text or json")
+	rootCmd.AddCommand(reportCmd)
+}
+
+// summary of every ACL, with_results adds results of every ACE
+func getDeviceReport(app_ctx app_context.AppContext, with_results bool) (deviceReport, error) {
+	var err error
+
+	device := deviceReport{Name: app_ctx.Name}
+	for i := range app_ctx.Access_lists {
+		acl := aclReport{Name: app_ctx.Access_lists[i].Name}
+		acl.Aces, acl.Unused_aces, acl.Flows = app_ctx.Access_lists[i].Summary()
+		if with_results {
+			acl.Results, err = app_ctx.Access_lists[i].GetResults()
+			if err != nil {
+				return device, err
+			}
+		}
+		device.Access_lists = append(device.Access_lists, acl)
+	}
+	return device, nil
+}
+
+func runReport(o options, format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown report format (format: %v)", format)
+	}
+
+	devices, err := getCheckedDevices(o, true)
+	if err != nil {
+		return err
+	}
+
+	app_ctxs, err := analyzeDevices(o, devices, io.Discard)
+	if err != nil {
+		return err
+	}
+
+	var reports []deviceReport
+	for _, app_ctx := range app_ctxs {
+		device, err := getDeviceReport(app_ctx, format == "json")
+		if err != nil {
+			return err
+		}
+		reports = append(reports, device)
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+
+	for _, device := range reports {
+		fmt.Println("DEVICE:", device.Name)
+		for _, acl := range device.Access_lists {
+			fmt.Printf("\tACL: %s, ACEs: %d, ACEs without flows: %d, # of flows: %d\n", acl.Name, acl.Aces, acl.Unused_aces, acl.Flows)
+		}
+	}
+	return nil
+}
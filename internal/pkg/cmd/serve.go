@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	acl_match "github.com/ivankuchin/excessive-acl/internal/pkg/acl_match"
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// samples per page if limit is not given, and the largest page
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+//go:embed web
+var web_files embed.FS
+
+var serve_listen string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serves results over HTTP: JSON API and web UI",
+	Long: `matches syslog against ACLs and serves results over HTTP: JSON API and web UI on /.
+  GET /api/devices                                       devices and their ACLs
+  GET /api/aces?device=&acl=                             ACEs with capacity and utilization
+  GET /api/samples?device=&acl=&ace=&offset=&limit=      sample flows of ACE (by index), paginated
+  GET /api/explain?device=&flow=tcp 10.1.1.1:1234 -> 8.8.8.8:443
+device may be omitted if single device is analyzed. Only flows kept as samples are served, see --samples.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServe(opts, serve_listen)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serve_listen, "listen", "127.0.0.1:8080", "address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// analysis results, syslog is matched in background while served
+type server struct {
+	app_ctxs []app_context.AppContext
+	// --- matching workers add flows under read lock, ACEs lock themselves.
+	// API readers take write lock to see all ACEs at the same moment, metrics take read lock.
+	results sync.RWMutex
+}
+
+// ACE with numbers summed over its compiled entries, sample flows are served by /api/samples
+type aceView struct {
+	Index          int
+	Line           string
+	Capacity       uint
+	Flows          uint64
+	Flows_capacity uint
+	Utilization    float64
+	Entries        []cisco_asa_access_entry.EntryResult
+}
+
+type flowView struct {
+	// --- RFC3339, empty if flow has no timestamp
+	Timestamp string
+	Protocol  string
+	Src_iface string
+	Src_ip    string
+	Src_port  uint16
+	Dst_iface string
+	Dst_ip    string
+	Dst_port  uint16
+	Icmp_type int
+	Icmp_code int
+	Bytes     uint64
+	// --- as printed by analyze
+	Text string
+}
+
+// flows kept as samples of ACE, not every flow matched it
+type samplesPage struct {
+	// --- flows matched the ACE, only Kept of them are kept as samples
+	Matched uint64
+	Kept    int
+	Offset  int
+	Limit   int
+	Samples []flowView
+}
+
+type explainView struct {
+	Flow     flowView
+	Inbound  *cisco_asa_acl.Verdict
+	Outbound *cisco_asa_acl.Verdict
+	Permit   bool
+	// --- no access-group applied, security levels decide
+	No_acl bool
+}
+
+func newFlowView(flow network_entities.Flow) flowView {
+	view := flowView{
+		Src_iface: flow.Src_iface,
+		Src_ip:    utils.IpToString(flow.Src_ip),
+		Src_port:  flow.Src_port,
+		Dst_iface: flow.Dst_iface,
+		Dst_ip:    utils.IpToString(flow.Dst_ip),
+		Dst_port:  flow.Dst_port,
+		Icmp_type: flow.Icmp_type,
+		Icmp_code: flow.Icmp_code,
+		Bytes:     flow.Bytes,
+		Text:      flow.String(),
+	}
+	if flow.Protocol != nil {
+		view.Protocol = flow.Protocol.Title
+	}
+	if !flow.Timestamp.IsZero() {
+		view.Timestamp = flow.Timestamp.Format(time.RFC3339)
+	}
+	return view
+}
+
+func newServer(app_ctxs []app_context.AppContext) *server {
+	return &server{app_ctxs: app_ctxs}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/devices", s.handleDevices)
+	mux.HandleFunc("/api/aces", s.handleACEs)
+	mux.HandleFunc("/api/samples", s.handleSamples)
+	mux.HandleFunc("/api/explain", s.handleExplain)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+
+	web, _ := fs.Sub(web_files, "web")
+	mux.Handle("/", http.FileServer(http.FS(web)))
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		slog.Error("can't write response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"Error": err.Error()})
+}
+
+// device given by name, name may be omitted if there is a single device
+func (s *server) getDevice(r *http.Request) (*app_context.AppContext, error) {
+	name := r.URL.Query().Get("device")
+	if name == "" && len(s.app_ctxs) == 1 {
+		return &s.app_ctxs[0], nil
+	}
+	for i := range s.app_ctxs {
+		if s.app_ctxs[i].Name == name {
+			return &s.app_ctxs[i], nil
+		}
+	}
+	return nil, errors.New("device not found")
+}
+
+func (s *server) getResults(r *http.Request) ([]cisco_asa_access_entry.ACEResult, error) {
+	app_ctx, err := s.getDevice(r)
+	if err != nil {
+		return nil, err
+	}
+	name := r.URL.Query().Get("acl")
+	for i := range app_ctx.Access_lists {
+		if app_ctx.Access_lists[i].Name == name {
+			s.results.Lock()
+			defer s.results.Unlock()
+			return app_ctx.Access_lists[i].GetResults()
+		}
+	}
+	return nil, errors.New("ACL not found")
+}
+
+// non-negative integer query parameter
+func getInt(r *http.Request, name string, default_value int) (int, error) {
+	str := r.URL.Query().Get(name)
+	if str == "" {
+		return default_value, nil
+	}
+	value, err := strconv.Atoi(str)
+	if err != nil || value < 0 {
+		return 0, errors.New(name + " must be a non-negative integer")
+	}
+	return value, nil
+}
+
+func (s *server) handleDevices(w http.ResponseWriter, r *http.Request) {
+	s.results.Lock()
+	defer s.results.Unlock()
+
+	devices := make([]deviceReport, 0, len(s.app_ctxs))
+	for _, app_ctx := range s.app_ctxs {
+		device, err := getDeviceReport(app_ctx, false)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		devices = append(devices, device)
+	}
+	writeJSON(w, devices)
+}
+
+func (s *server) handleACEs(w http.ResponseWriter, r *http.Request) {
+	results, err := s.getResults(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	aces := make([]aceView, 0, len(results))
+	for i, result := range results {
+		totals := getACETotals(result)
+		ace := aceView{
+			Index:          i,
+			Line:           result.Line,
+			Capacity:       totals.capacity,
+			Flows:          totals.flows,
+			Flows_capacity: totals.flows_capacity,
+			Utilization:    totals.utilization(),
+		}
+		for _, entry := range result.Entries {
+			entry.Samples = nil
+			ace.Entries = append(ace.Entries, entry)
+		}
+		aces = append(aces, ace)
+	}
+	writeJSON(w, aces)
+}
+
+func (s *server) handleSamples(w http.ResponseWriter, r *http.Request) {
+	results, err := s.getResults(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	index, err := getInt(r, "ace", -1)
+	if err == nil && (index < 0 || index >= len(results)) {
+		err = errors.New("ace must be index of ACE in ACL")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	offset, err := getInt(r, "offset", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, err := getInt(r, "limit", defaultPageSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit = min(limit, maxPageSize)
+
+	var samples []network_entities.Flow
+	for _, entry := range results[index].Entries {
+		samples = append(samples, entry.Samples...)
+	}
+
+	page := samplesPage{
+		Matched: getACETotals(results[index]).flows,
+		Kept:    len(samples),
+		Offset:  offset,
+		Limit:   limit,
+		Samples: []flowView{},
+	}
+	for i := offset; i < len(samples) && i < offset+limit; i++ {
+		page.Samples = append(page.Samples, newFlowView(samples[i]))
+	}
+	writeJSON(w, page)
+}
+
+func (s *server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	app_ctx, err := s.getDevice(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	flow, err := acl_match.ParseFlow([]string{r.URL.Query().Get("flow")})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	explanation, err := acl_match.Explain(flow, *app_ctx)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, explainView{
+		Flow:     newFlowView(explanation.Flow),
+		Inbound:  explanation.Inbound,
+		Outbound: explanation.Outbound,
+		Permit:   explanation.Permit,
+		No_acl:   explanation.Inbound == nil && explanation.Outbound == nil,
+	})
+}
+
+// matches syslog of every device, devices are matched at the same time
+func (s *server) matchSyslog(devices []Device, num_goroutines int) error {
+	devices_group, _ := errgroup.WithContext(context.TODO())
+	for i := range s.app_ctxs {
+		app_ctx, syslog_file := s.app_ctxs[i], devices[i].Syslog
+		devices_group.Go(func() error {
+			wait, err := syslog.Fit(app_ctx, syslog_file, num_goroutines)
+			if err != nil {
+				return err
+			}
+
+			workers, _ := errgroup.WithContext(context.TODO())
+			for j := 0; j < num_goroutines; j++ {
+				workers.Go(func() error {
+					for flow := range app_ctx.Flows {
+						s.results.RLock()
+						err := acl_match.MatchFlow(flow, app_ctx)
+						s.results.RUnlock()
+						if err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			}
+			err = workers.Wait()
+			if err != nil {
+				return err
+			}
+			return checkSyslogRead(app_ctx.Name, wait())
+		})
+	}
+	return devices_group.Wait()
+}
+
+// configs are loaded before serving, syslog is matched in background:
+// results and metrics grow while syslog is read, streamed syslog (-s -) is read until closed
+func runServe(o options, listen string) error {
+	devices, err := getCheckedDevices(o, true)
+	if err != nil {
+		return err
+	}
+	stdin_readers := 0
+	for _, device := range devices {
+		if device.Syslog == "-" {
+			stdin_readers++
+		}
+	}
+	if stdin_readers > 1 {
+		return errors.New("syslog from standard input can be read by a single device")
+	}
+
+	app_options, err := getAppOptions(o)
+	if err != nil {
+		return err
+	}
+	var state stateFile
+	if o.State != "" {
+		state, err = readState(o.State, o.Bucket, app_options.Stats_options.Hll_precision, capacityModels(app_options))
+		if err != nil {
+			return err
+		}
+	}
+
+	var app_ctxs []app_context.AppContext
+	for _, device := range devices {
+		app_ctx, err := loadDevice(device, app_options, io.Discard)
+		if err != nil {
+			return err
+		}
+		if o.State != "" {
+			err = loadDeviceState(&app_ctx, state.Devices[app_ctx.Name])
+			if err != nil {
+				return err
+			}
+		}
+		app_ctx.Flows = make(chan network_entities.Flow, 100)
+		app_ctx.Parser_stats = app_context.NewParserStats()
+		app_ctxs = append(app_ctxs, app_ctx)
+	}
+	s := newServer(app_ctxs)
+
+	go func() {
+		err := s.matchSyslog(devices, int(o.Go_routines))
+		if err != nil {
+			slog.Error("syslog matching stopped", "err", err)
+			return
+		}
+		slog.Info("syslog matched")
+
+		if o.State != "" {
+			s.results.Lock()
+			for _, app_ctx := range s.app_ctxs {
+				state.Devices[app_ctx.Name] = saveDeviceState(app_ctx)
+			}
+			s.results.Unlock()
+			err = writeState(o.State, state)
+			if err != nil {
+				slog.Error("can't save state", "state", o.State, "err", err)
+			}
+		}
+	}()
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	slog.Info("serving results", "url", "http://"+listen+"/")
+	return server.ListenAndServe()
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	acl_match "github.com/ivankuchin/excessive-acl/internal/pkg/acl_match"
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_nat "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-nat"
+)
+
+// test config with 3 flows from inside to outside
+func loadTestDevice(t *testing.T) app_context.AppContext {
+	app_ctx, err := loadDevice(Device{Sh_run: "../acl_match/testdata/sh_run_test.txt"}, app_context.AppContext{Address_form: cisco_asa_nat.Auto}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, str := range []string{"tcp 192.168.0.10:1000 -> 8.8.8.8:443", "tcp 192.168.0.11:1001 -> 8.8.8.8:443", "tcp 192.168.0.12:1002 -> 1.1.1.1:443"} {
+		flow, err := acl_match.ParseFlow([]string{str})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flow.Src_iface, flow.Dst_iface = "inside", "outside"
+		err = acl_match.MatchFlow(flow, app_ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return app_ctx
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(newServer([]app_context.AppContext{loadTestDevice(t)}).handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_server(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		query      url.Values
+		wantStatus int
+		check      func(t *testing.T, body []byte)
+	}{
+		{
+			name:       "devices",
+			path:       "/api/devices",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var devices []deviceReport
+				if err := json.Unmarshal(body, &devices); err != nil {
+					t.Fatal(err)
+				}
+				if len(devices) != 1 || len(devices[0].Access_lists) != 2 || devices[0].Access_lists[0].Flows != 3 {
+					t.Errorf("devices = %+v, want one device with two ACLs, 3 flows in inside_in", devices)
+				}
+			},
+		},
+		{
+			name:       "aces",
+			path:       "/api/aces",
+			query:      url.Values{"acl": {"inside_in"}},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var aces []aceView
+				if err := json.Unmarshal(body, &aces); err != nil {
+					t.Fatal(err)
+				}
+				if len(aces) != 3 || aces[1].Flows != 3 || aces[1].Entries[0].Samples != nil {
+					t.Errorf("aces = %+v, want 3 ACEs, second one with 3 flows and no samples", aces)
+				}
+			},
+		},
+		{
+			name:       "samples, second page",
+			path:       "/api/samples",
+			query:      url.Values{"acl": {"inside_in"}, "ace": {"1"}, "offset": {"2"}, "limit": {"2"}},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var page samplesPage
+				if err := json.Unmarshal(body, &page); err != nil {
+					t.Fatal(err)
+				}
+				if page.Matched != 3 || page.Kept != 3 || len(page.Samples) != 1 || page.Samples[0].Src_ip != "192.168.0.12" {
+					t.Errorf("page = %+v, want last of 3 samples", page)
+				}
+			},
+		},
+		{
+			name:       "explain",
+			path:       "/api/explain",
+			query:      url.Values{"flow": {"tcp 192.168.0.10:1234 -> 8.8.4.4:443"}},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var explanation explainView
+				if err := json.Unmarshal(body, &explanation); err != nil {
+					t.Fatal(err)
+				}
+				if explanation.Permit || explanation.Outbound == nil || explanation.Outbound.Line != "access-list outside_out extended deny tcp any host 8.8.4.4 eq 443" {
+					t.Errorf("explanation = %+v, want deny by outside_out", explanation)
+				}
+			},
+		},
+		{
+			name:       "metrics",
+			path:       "/metrics",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				for _, want := range []string{
+					"# TYPE excessive_acl_ace_flows gauge\n",
+					`excessive_acl_ace_flows{device="asa1",acl="inside_in",ace="1"} 3` + "\n",
+					`excessive_acl_acl_unused_aces{device="asa1",acl="inside_in"} 2` + "\n",
+					`excessive_acl_syslog_lines_read_total{device="asa1"} 0` + "\n",
+				} {
+					if !strings.Contains(string(body), want) {
+						t.Errorf("metrics have no %q:\n%s", want, body)
+					}
+				}
+			},
+		},
+		{name: "unknown ACL", path: "/api/aces", query: url.Values{"acl": {"nope"}}, wantStatus: http.StatusNotFound},
+		{name: "unknown device", path: "/api/aces", query: url.Values{"device": {"nope"}, "acl": {"inside_in"}}, wantStatus: http.StatusNotFound},
+		{name: "ACE out of range", path: "/api/samples", query: url.Values{"acl": {"inside_in"}, "ace": {"3"}}, wantStatus: http.StatusBadRequest},
+		{name: "bad offset", path: "/api/samples", query: url.Values{"acl": {"inside_in"}, "ace": {"1"}, "offset": {"-1"}}, wantStatus: http.StatusBadRequest},
+		{name: "bad flow", path: "/api/explain", query: url.Values{"flow": {"tcp 10.1.1.1"}}, wantStatus: http.StatusBadRequest},
+		{name: "web UI", path: "/", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := http.Get(server.URL + tt.path + "?" + tt.query.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tt.wantStatus {
+				t.Fatalf("GET %s status = %d, want %d: %s", tt.path, response.StatusCode, tt.wantStatus, body)
+			}
+			if tt.check != nil {
+				tt.check(t, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+const stateVersion = 1
+
+// per-ACE aggregates saved after a run, next run merges new flows into them
+type stateFile struct {
+	Version int
+	// --- statistics options the state was collected with, must not change between runs
+	Bucket        string
+	Hll_precision uint8
+	// --- any address is counted only by some capacity models, empty if default model is used
+	Capacity_models string `json:",omitempty"`
+	Devices         map[string]deviceState
+}
+
+type deviceState struct {
+	// --- newest second of flows counted and number of flows of it, older flows of the syslog are skipped
+	Seen_until    time.Time
+	Seen_at_until uint64 `json:",omitempty"`
+	Acls          map[string]cisco_asa_acl.State
+}
+
+// capacity models of options as "model; acl_name:model; ...", empty if default model is used
+func capacityModels(app_options app_context.AppContext) string {
+	if app_options.Capacity_model == (network_entities.CapacityModel{}) && len(app_options.Acl_capacity_models) == 0 {
+		return ""
+	}
+
+	models := []string{app_options.Capacity_model.String()}
+	for acl_name, model := range app_options.Acl_capacity_models {
+		models = append(models, acl_name+":"+model.String())
+	}
+	sort.Strings(models[1:])
+	return strings.Join(models, "; ")
+}
+
+// missing file is an empty state, first run creates it
+func readState(file string, bucket string, hll_precision uint8, capacity_models string) (stateFile, error) {
+	state := stateFile{
+		Version:         stateVersion,
+		Bucket:          bucket,
+		Hll_precision:   hll_precision,
+		Capacity_models: capacity_models,
+		Devices:         make(map[string]deviceState),
+	}
+
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Info("no state found, it will be created", "state", file)
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	var saved stateFile
+	err = json.Unmarshal(content, &saved)
+	if err != nil {
+		return state, fmt.Errorf("state %s: %w", file, err)
+	}
+	if saved.Version != stateVersion {
+		return state, fmt.Errorf("state %s: unsupported state version %d, want %d", file, saved.Version, stateVersion)
+	}
+	if saved.Bucket != state.Bucket || saved.Hll_precision != state.Hll_precision {
+		return state, fmt.Errorf("state %s: collected with other --bucket, --approximate or --hll-precision (bucket: %s, hll_precision: %d), want bucket %s, hll_precision %d",
+			file, saved.Bucket, saved.Hll_precision, state.Bucket, state.Hll_precision)
+	}
+	if saved.Capacity_models != state.Capacity_models {
+		return state, fmt.Errorf("state %s: collected with other --capacity-model or --acl-capacity-model (capacity models: %q), want %q",
+			file, saved.Capacity_models, state.Capacity_models)
+	}
+	if saved.Devices != nil {
+		state.Devices = saved.Devices
+	}
+
+	return state, nil
+}
+
+// file is replaced at once, interrupted run leaves previous state intact
+func writeState(file string, state stateFile) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// merges state of previous runs into ACEs of the device.
+// ACEs are matched by text, state of ACEs changed or removed from config is dropped.
+func loadDeviceState(app_ctx *app_context.AppContext, state deviceState) error {
+	app_ctx.Seen_flows = app_context.NewSeenFlows(state.Seen_until, state.Seen_at_until)
+
+	for i := range app_ctx.Access_lists {
+		acl := &app_ctx.Access_lists[i]
+		acl_state, ok := state.Acls[acl.Name]
+		if !ok {
+			continue
+		}
+		dropped, err := acl.LoadState(acl_state, app_ctx.Stats_options)
+		if err != nil {
+			return err
+		}
+		for _, ace := range dropped {
+			slog.Info("ACE changed or removed, its state is dropped", "device", app_ctx.Name, "acl", acl.Name, "ace", ace)
+		}
+	}
+	for acl_name := range state.Acls {
+		found := false
+		for i := range app_ctx.Access_lists {
+			found = found || app_ctx.Access_lists[i].Name == acl_name
+		}
+		if !found {
+			slog.Info("ACL is not applied anymore, its state is dropped", "device", app_ctx.Name, "acl", acl_name)
+		}
+	}
+
+	return nil
+}
+
+func saveDeviceState(app_ctx app_context.AppContext) deviceState {
+	state := deviceState{
+		Acls: make(map[string]cisco_asa_acl.State),
+	}
+
+	var no_timestamp uint64
+	state.Seen_until, state.Seen_at_until, no_timestamp = app_ctx.Seen_flows.Get()
+	if no_timestamp > 0 {
+		slog.Warn("flows without timestamp are skipped, they can't be told from flows counted by previous runs", "device", app_ctx.Name, "flows", no_timestamp)
+	}
+
+	for i := range app_ctx.Access_lists {
+		state.Acls[app_ctx.Access_lists[i].Name] = app_ctx.Access_lists[i].SaveState()
+	}
+	return state
+}
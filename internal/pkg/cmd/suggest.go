@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	"github.com/spf13/cobra"
+)
+
+// utilization in percents below which a permit entry is worth tightening
+var suggest_threshold float64
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "lists ACEs to remove (no flows) or to tighten (low utilization)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSuggest(opts, suggest_threshold)
+	},
+}
+
+func init() {
+	suggestCmd.Flags().Float64Var(&suggest_threshold, "threshold", 1, "permit entries with utilization(%) below threshold are suggested to tighten")
+	rootCmd.AddCommand(suggestCmd)
+}
+
+func runSuggest(o options, threshold float64) error {
+	devices, err := getCheckedDevices(o, true)
+	if err != nil {
+		return err
+	}
+	multi_device := isMultiDevice(o, devices)
+
+	app_ctxs, err := analyzeDevices(o, devices, io.Discard)
+	if err != nil {
+		return err
+	}
+
+	for _, app_ctx := range app_ctxs {
+		if multi_device {
+			fmt.Println("DEVICE:", app_ctx.Name)
+		}
+		for i := range app_ctx.Access_lists {
+			fmt.Println("ACL:", app_ctx.Access_lists[i].Name)
+
+			results, err := app_ctx.Access_lists[i].GetResults()
+			if err != nil {
+				return err
+			}
+			for _, ace := range results {
+				if len(ace.Entries) == 0 {
+					continue
+				}
+
+				var ace_flows uint64
+				permits := false
+				for _, entry := range ace.Entries {
+					ace_flows += entry.Flows
+					permits = permits || entry.Permit
+				}
+				// --- deny without flows may guard against traffic not seen yet
+				if !permits {
+					continue
+				}
+				if ace_flows == 0 {
+					fmt.Printf("\tACE: %s\n", ace.Line)
+					fmt.Println("\t\tremove: no flows")
+					continue
+				}
+
+				var suggestions []string
+				for _, entry := range ace.Entries {
+					switch {
+					case entry.Flows == 0 && entry.Permit:
+						suggestions = append(suggestions, fmt.Sprintf("remove entry: %s (no flows)", entry.Entry))
+					case entry.Permit && entry.Utilization.Percent < threshold:
+						suggestions = append(suggestions, fmt.Sprintf("tighten entry: %s, ACE capacity utilization(%%): %s (%s)",
+							entry.Entry, entry.Utilization, cisco_asa_access_entry.FormatDimensions(entry.Dimensions)))
+					}
+				}
+				if len(suggestions) == 0 {
+					continue
+				}
+				fmt.Printf("\tACE: %s\n", ace.Line)
+				for _, suggestion := range suggestions {
+					fmt.Printf("\t\t%s\n", suggestion)
+				}
+			}
+		}
+	}
+
+	return nil
+}
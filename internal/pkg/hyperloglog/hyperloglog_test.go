@@ -0,0 +1,103 @@
+package hyperloglog
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSketch_Count(t *testing.T) {
+	tests := []struct {
+		name   string
+		p      uint8
+		unique uint64
+		repeat int
+	}{
+		{name: "empty", p: 14, unique: 0, repeat: 1},
+		{name: "few values", p: 14, unique: 10, repeat: 3},
+		{name: "sequential addresses", p: 14, unique: 65536, repeat: 1},
+		{name: "many values, low precision", p: 10, unique: 1000000, repeat: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.repeat; i++ {
+				for v := uint64(0); v < tt.unique; v++ {
+					s.Add(0x0a000000 + v)
+				}
+			}
+
+			got := s.Count()
+			// --- 4 standard errors, test must not be flaky
+			margin := 4*RelativeError(tt.p)*float64(tt.unique) + 1
+			if math.Abs(float64(got)-float64(tt.unique)) > margin {
+				t.Errorf("Sketch.Count() = %v, want %v ±%.0f", got, tt.unique, margin)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       uint8
+		wantErr bool
+	}{
+		{name: "min", p: MinPrecision, wantErr: false},
+		{name: "max", p: MaxPrecision, wantErr: false},
+		{name: "too small", p: 3, wantErr: true},
+		{name: "too big", p: 17, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSketch_Merge(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       uint8
+		other_p uint8
+		want    uint64
+		wantErr bool
+	}{
+		{name: "overlapping halves", p: 14, other_p: 14, want: 15000, wantErr: false},
+		{name: "other precision", p: 14, other_p: 12, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := New(tt.other_p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for v := uint64(0); v < 10000; v++ {
+				s.Add(v)
+				other.Add(v + 5000)
+			}
+
+			err = s.Merge(other.Registers())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Sketch.Merge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := s.Count()
+			margin := 4*RelativeError(tt.p)*float64(tt.want) + 1
+			if math.Abs(float64(got)-float64(tt.want)) > margin {
+				t.Errorf("Sketch.Count() = %v, want %v ±%.0f", got, tt.want, margin)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package network_entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// period flows are grouped by in the report
+type Bucket int
+
+const (
+	BucketNone Bucket = iota
+	BucketDay
+	BucketWeek
+)
+
+func ParseBucket(name string) (Bucket, error) {
+	switch name {
+	case "":
+		return BucketNone, nil
+	case "day":
+		return BucketDay, nil
+	case "week":
+		return BucketWeek, nil
+	default:
+		return BucketNone, fmt.Errorf("bucket must be day or week (name: %v)", name)
+	}
+}
+
+// returns first day of the period timestamp belongs to, weeks start on Monday
+func (b Bucket) Start(timestamp time.Time) time.Time {
+	day := time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, timestamp.Location())
+
+	switch b {
+	case BucketWeek:
+		days_since_monday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -days_since_monday)
+	default:
+		return day
+	}
+}
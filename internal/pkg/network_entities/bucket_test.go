@@ -0,0 +1,41 @@
+package network_entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket_Start(t *testing.T) {
+	tests := []struct {
+		name      string
+		b         Bucket
+		timestamp time.Time
+		want      time.Time
+	}{
+		{
+			name:      "day",
+			b:         BucketDay,
+			timestamp: time.Date(2023, time.October, 19, 10, 11, 12, 0, time.UTC),
+			want:      time.Date(2023, time.October, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "week from thursday",
+			b:         BucketWeek,
+			timestamp: time.Date(2023, time.October, 19, 10, 11, 12, 0, time.UTC),
+			want:      time.Date(2023, time.October, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "week from sunday",
+			b:         BucketWeek,
+			timestamp: time.Date(2023, time.October, 22, 23, 59, 59, 0, time.UTC),
+			want:      time.Date(2023, time.October, 16, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.b.Start(tt.timestamp); !got.Equal(tt.want) {
+				t.Errorf("Bucket.Start() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
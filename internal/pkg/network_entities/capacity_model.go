@@ -0,0 +1,112 @@
+package network_entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// how a part of ACE that doesn't restrict flows is counted in capacity
+type Space int
+
+const (
+	// --- model default, see CapacityModel
+	SpaceDefault Space = iota
+	// --- part is not counted (x1), flows are not counted on it either
+	SpaceOne
+	// --- part counts as whole space: 2^32 addresses, 65536 ports, 256 ICMP types * 256 codes
+	SpaceFull
+)
+
+// policy choices of ACE capacity, zero value is the default model:
+// any address counts as 1, omitted destination port as 65536 ports,
+// omitted source port as 1 (ephemeral ports), omitted ICMP type and code as 256 * 256
+type CapacityModel struct {
+	Any_src_address  Space
+	Any_dst_address  Space
+	Omitted_dst_port Space
+	Omitted_src_port Space
+	Omitted_icmp     Space
+}
+
+func (m CapacityModel) AnySrcAddressFull() bool {
+	return m.Any_src_address == SpaceFull
+}
+
+func (m CapacityModel) AnyDstAddressFull() bool {
+	return m.Any_dst_address == SpaceFull
+}
+
+func (m CapacityModel) OmittedDstPortFull() bool {
+	return m.Omitted_dst_port != SpaceOne
+}
+
+func (m CapacityModel) OmittedSrcPortFull() bool {
+	return m.Omitted_src_port == SpaceFull
+}
+
+func (m CapacityModel) OmittedICMPFull() bool {
+	return m.Omitted_icmp != SpaceOne
+}
+
+// ex: "any=full,src-port=full". Keys are src-any, dst-any, dst-port, src-port and icmp, values are one or full.
+// any is a shorthand of src-any and dst-any. Keys not given keep values of base, "default" or empty spec is base itself.
+func ParseCapacityModel(spec string, base CapacityModel) (CapacityModel, error) {
+	model := base
+	if spec == "" || spec == "default" {
+		return model, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		var space Space
+		switch value {
+		case "one":
+			space = SpaceOne
+		case "full":
+			space = SpaceFull
+		default:
+			found = false
+		}
+		if !found {
+			return base, fmt.Errorf("capacity model item must be key=one or key=full (item: %v)", item)
+		}
+
+		switch key {
+		case "any":
+			model.Any_src_address = space
+			model.Any_dst_address = space
+		case "src-any":
+			model.Any_src_address = space
+		case "dst-any":
+			model.Any_dst_address = space
+		case "dst-port":
+			model.Omitted_dst_port = space
+		case "src-port":
+			model.Omitted_src_port = space
+		case "icmp":
+			model.Omitted_icmp = space
+		default:
+			return base, fmt.Errorf("capacity model key must be any, src-any, dst-any, dst-port, src-port or icmp (key: %v)", key)
+		}
+	}
+	return model, nil
+}
+
+// spec of the model with every key, ParseCapacityModel of it returns the model.
+// any is used if source and destination any are the same, so specs of states saved before src-any and dst-any keep matching.
+func (m CapacityModel) String() string {
+	value := func(full bool) string {
+		if full {
+			return "full"
+		}
+		return "one"
+	}
+	any_spec := "any=" + value(m.AnySrcAddressFull())
+	if m.AnySrcAddressFull() != m.AnyDstAddressFull() {
+		any_spec = "src-any=" + value(m.AnySrcAddressFull()) + ",dst-any=" + value(m.AnyDstAddressFull())
+	}
+	return any_spec +
+		",dst-port=" + value(m.OmittedDstPortFull()) +
+		",src-port=" + value(m.OmittedSrcPortFull()) +
+		",icmp=" + value(m.OmittedICMPFull())
+}
@@ -0,0 +1,37 @@
+package network_entities
+
+import (
+	"testing"
+)
+
+func TestParseCapacityModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		base    CapacityModel
+		want    string
+		wantErr bool
+	}{
+		{name: "default", spec: "default", want: "any=one,dst-port=full,src-port=one,icmp=full"},
+		{name: "empty keeps base", spec: "", base: CapacityModel{Any_src_address: SpaceFull, Any_dst_address: SpaceFull}, want: "any=full,dst-port=full,src-port=one,icmp=full"},
+		{name: "any fully open", spec: "any=full, src-port=full", want: "any=full,dst-port=full,src-port=full,icmp=full"},
+		{name: "override of base", spec: "any=one", base: CapacityModel{Any_src_address: SpaceFull, Any_dst_address: SpaceFull, Omitted_icmp: SpaceOne}, want: "any=one,dst-port=full,src-port=one,icmp=one"},
+		{name: "destination any fully open", spec: "dst-any=full", want: "src-any=one,dst-any=full,dst-port=full,src-port=one,icmp=full"},
+		{name: "any is shorthand of both", spec: "src-any=full,dst-any=full", want: "any=full,dst-port=full,src-port=one,icmp=full"},
+		{name: "later key overrides any", spec: "any=full,src-any=one", want: "src-any=one,dst-any=full,dst-port=full,src-port=one,icmp=full"},
+		{name: "unknown key", spec: "port=one", wantErr: true},
+		{name: "unknown value", spec: "any=half", wantErr: true},
+		{name: "no value", spec: "any", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCapacityModel(tt.spec, tt.base)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCapacityModel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseCapacityModel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,10 +10,17 @@ func (f Flow) String() string {
 	if f.Protocol == nil {
 		return "protocol is nil"
 	}
+	if f.Accounted {
+		return f.tuple() + " (bytes: " + strconv.FormatUint(f.Bytes, 10) + ", duration: " + f.Duration.String() + ")"
+	}
+	return f.tuple()
+}
+
+func (f Flow) tuple() string {
 	switch f.Protocol.Title {
 	case "icmp":
 		return f.Src_iface + "->" + f.Dst_iface + " " + f.Protocol.Title + "://" + utils.IpToString(f.Src_ip) + " -> " + utils.IpToString(f.Dst_ip) + " (type: " + strconv.Itoa(f.Icmp_type) + ", code: " + strconv.Itoa(f.Icmp_code) + ")"
-	case "tcp", "udp":
+	case "tcp", "udp", "sctp":
 		return f.Src_iface + "->" + f.Dst_iface + " " + f.Protocol.Title + "://" + utils.IpToString(f.Src_ip) + ":" + strconv.Itoa(int(f.Src_port)) + " -> " + utils.IpToString(f.Dst_ip) + ":" + strconv.Itoa(int(f.Dst_port))
 	default:
 		return "unknown protocol"
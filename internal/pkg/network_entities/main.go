@@ -2,17 +2,14 @@ package network_entities
 
 import (
 	"errors"
-	"log"
 	"strconv"
 )
 
 func getSingleProtoByName(name string) (*Protocol, error) {
 	elem, ok := Protocols_map[name]
 	if !ok {
-		error_message := "ERROR: protocol (" + name + ") doesn't exists"
-		log.Println(error_message)
 		// utils.PrintStackTrace()
-		return nil, errors.New(error_message)
+		return nil, errors.New("protocol (" + name + ") doesn't exists")
 	}
 	return elem, nil
 }
@@ -40,10 +37,8 @@ func GetProtoByName(name string) ([]*Protocol, error) {
 func GetTCPPortByName(name string) (*TcpPorts, error) {
 	elem, ok := tcp_ports_map[name]
 	if !ok {
-		error_message := "ERROR: named tcp port (" + name + ") doesn't exists"
-		log.Println(error_message)
 		// utils.PrintStackTrace()
-		return nil, errors.New(error_message)
+		return nil, errors.New("named tcp port (" + name + ") doesn't exists")
 	}
 	return elem, nil
 }
@@ -61,12 +56,31 @@ func GetTcpPortFromString(str string) (int, error) {
 	return p, nil
 }
 
+func GetUDPPortByName(name string) (*UdpPorts, error) {
+	elem, ok := udp_ports_map[name]
+	if !ok {
+		return nil, errors.New("named udp port (" + name + ") doesn't exists")
+	}
+	return elem, nil
+}
+
+func GetUdpPortFromString(str string) (int, error) {
+	p, err := strconv.Atoi(str)
+	if err != nil {
+		port_struct, err := GetUDPPortByName(str)
+		if err != nil {
+			return 0, err
+		}
+		p = int(port_struct.Id)
+	}
+
+	return p, nil
+}
+
 func GetICMPTypeCodeByName(name string) (*IcmpTypeCodes, error) {
 	elem, ok := icmp_type_codes_map[name]
 	if !ok {
-		error_message := "ERROR: named icmp type code (" + name + ") doesn't exists"
-		log.Println(error_message)
-		return nil, errors.New(error_message)
+		return nil, errors.New("named icmp type code (" + name + ") doesn't exists")
 	}
 	return elem, nil
 }
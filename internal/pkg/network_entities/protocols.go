@@ -4,6 +4,8 @@ var protocols []Protocol
 var Protocols_map map[string]*Protocol
 var tcp_ports []TcpPorts
 var tcp_ports_map map[string]*TcpPorts
+var udp_ports []UdpPorts
+var udp_ports_map map[string]*UdpPorts
 var icmp_type_codes []IcmpTypeCodes
 var icmp_type_codes_map map[string]*IcmpTypeCodes
 
@@ -217,6 +219,46 @@ func init() {
 		{5060, "sip"},
 	}
 
+	// --- names ASA uses for UDP ports, some of them differ from TCP names of the same port (ex: 514 is cmd in TCP, syslog in UDP)
+	udp_ports = []UdpPorts{
+		{512, "biff"},
+		{68, "bootpc"},
+		{67, "bootps"},
+		{3020, "cifs"},
+		{9, "discard"},
+		{195, "dnsix"},
+		{53, "domain"},
+		{7, "echo"},
+		{80, "http"},
+		{500, "isakmp"},
+		{750, "kerberos"},
+		{434, "mobile-ip"},
+		{42, "nameserver"},
+		{138, "netbios-dgm"},
+		{137, "netbios-ns"},
+		{2049, "nfs"},
+		{123, "ntp"},
+		{5632, "pcanywhere-status"},
+		{496, "pim-auto-rp"},
+		{1645, "radius"},
+		{1646, "radius-acct"},
+		{520, "rip"},
+		{5510, "secureid-udp"},
+		{5060, "sip"},
+		{161, "snmp"},
+		{162, "snmptrap"},
+		{111, "sunrpc"},
+		{514, "syslog"},
+		{49, "tacacs"},
+		{517, "talk"},
+		{69, "tftp"},
+		{37, "time"},
+		{4789, "vxlan"},
+		{513, "who"},
+		{80, "www"},
+		{177, "xdmcp"},
+	}
+
 	icmp_type_codes = []IcmpTypeCodes{
 		{0, "echo-reply"},
 		{3, "unreachable"},
@@ -249,6 +291,11 @@ func init() {
 		tcp_ports_map[tcp_port.Title] = &tcp_ports[idx]
 	}
 
+	udp_ports_map = make(map[string]*UdpPorts)
+	for idx, udp_port := range udp_ports {
+		udp_ports_map[udp_port.Title] = &udp_ports[idx]
+	}
+
 	icmp_type_codes_map = make(map[string]*IcmpTypeCodes)
 	for idx, icmp_type_code := range icmp_type_codes {
 		icmp_type_codes_map[icmp_type_code.Title] = &icmp_type_codes[idx]
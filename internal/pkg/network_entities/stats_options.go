@@ -0,0 +1,14 @@
+package network_entities
+
+// how per-ACE statistics are collected
+type StatsOptions struct {
+	Bucket Bucket
+	// --- 0 is exact count of unique addresses, otherwise hyperloglog precision
+	Hll_precision uint8
+	// --- flows kept per compiled entry as examples, 0 keeps default
+	Samples int
+	// --- addresses of flows are summarized to prefixes not longer than that (1..32), 0 counts unique addresses
+	Max_prefix_length uint8
+	// --- ports of flows are summarized to ranges of that size (1..65535), 0 counts unique ports
+	Max_port_range uint16
+}
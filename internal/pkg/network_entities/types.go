@@ -1,5 +1,7 @@
 package network_entities
 
+import "time"
+
 type Protocol struct {
 	Id    uint
 	Title string
@@ -10,6 +12,11 @@ type TcpPorts struct {
 	Title string
 }
 
+type UdpPorts struct {
+	Id    uint
+	Title string
+}
+
 type IcmpTypeCodes struct {
 	Id    uint
 	Title string
@@ -25,4 +32,24 @@ type Flow struct {
 	Dst_port  uint16
 	Icmp_code int
 	Icmp_type int
+
+	// --- time message was logged, zero if syslog line has no timestamp
+	Timestamp time.Time
+
+	// --- address as seen on the other side of the firewall (after NAT), 0 if unknown
+	Src_mapped_ip   uint32
+	Dst_mapped_ip   uint32
+	Src_mapped_port uint16
+	Dst_mapped_port uint16
+
+	// --- flow destined to the firewall itself, checked by control-plane ACLs
+	To_box bool
+	// --- ACL and ACE hash named in the message (ex: 106100)
+	Acl_name string
+	Ace_hash uint32
+
+	// --- connection accounting, taken from a teardown message
+	Accounted bool
+	Bytes     uint64
+	Duration  time.Duration
 }
@@ -1,20 +1,69 @@
 package utils
 
-const (
-	Critical = iota
-	Error
-	Warning
-	Info
-	Debug
-	Trace
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
 )
 
-var log_level int = 0
+// below slog.LevelDebug: every flow and ACE it is matched against
+const LevelTrace = slog.Level(-8)
 
-func SetLogLevel(level int) {
-	log_level = level
+var ErrorUnknownLogLevel = errors.New("unknown log level")
+var ErrorUnknownLogFormat = errors.New("unknown log format")
+
+// trace, debug, info, warn or error
+func ParseLogLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("%w (%s)", ErrorUnknownLogLevel, name)
+	}
+}
+
+// text or json logger, trace level is shown as TRACE
+func NewLogger(w io.Writer, level_name, format string) (*slog.Logger, error) {
+	level, err := ParseLogLevel(level_name)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && a.Value.Any() == LevelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
+	}
+
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("%w (%s)", ErrorUnknownLogFormat, format)
+	}
+}
+
+func IsTrace() bool {
+	return slog.Default().Enabled(context.Background(), LevelTrace)
 }
 
-func GetLogLevel() int {
-	return log_level
+func Trace(msg string, args ...any) {
+	slog.Log(context.Background(), LevelTrace, msg, args...)
 }
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "info hides debug", level: "info", format: "text", want: []string{"level=INFO msg=info", "level=ERROR msg=error"}},
+		{name: "trace", level: "trace", format: "text", want: []string{"level=TRACE msg=trace", "level=DEBUG msg=debug", "level=INFO msg=info", "level=ERROR msg=error"}},
+		{name: "json", level: "error", format: "json", want: []string{`"level":"ERROR","msg":"error"`}},
+		{name: "unknown level", level: "verbose", format: "text", wantErr: true},
+		{name: "unknown format", level: "info", format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := NewLogger(&buf, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLogger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			logger.Log(context.Background(), LevelTrace, "trace")
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Error("error")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("NewLogger() logged %v, want %v", lines, tt.want)
+			}
+			for i, line := range lines {
+				if !strings.Contains(line, tt.want[i]) {
+					t.Errorf("line %q doesn't contain %q", line, tt.want[i])
+				}
+				if tt.format == "json" && !json.Valid([]byte(line)) {
+					t.Errorf("line %q is not json", line)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,12 @@
 package utils
 
 import (
-	"log"
+	"log/slog"
 	"runtime"
 )
 
 func PrintStackTrace() {
 	buf := make([]byte, 1<<16)
 	runtime.Stack(buf, true)
-	log.Printf("%s", buf)
+	slog.Error("stack trace", "stack", string(buf))
 }
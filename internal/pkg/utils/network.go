@@ -3,7 +3,7 @@ package utils
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/netip"
 )
 
@@ -11,15 +11,11 @@ func ParseIP(ip_str string) (uint32, error) {
 
 	ipAddr, err := netip.ParseAddr(ip_str)
 	if err != nil {
-		error_string := "ERROR: failed to parse ip address"
-		log.Print(error_string, "(", ip_str, ")")
-		return 0, errors.New(error_string)
+		return 0, fmt.Errorf("failed to parse ip address (ip_str: %v)", ip_str)
 	}
 
 	if ipAddr.Is6() {
-		error_string := "ERROR: ipv6 not implemented"
-		log.Print(error_string)
-		return 0, errors.New(error_string)
+		return 0, errors.New("ipv6 not implemented")
 	}
 
 	octets := ipAddr.As4()
@@ -95,9 +91,7 @@ func ParseMask(mask_str string) (uint32, error) {
 	case "255.255.255.255":
 		return uint32(255)<<24 + uint32(255)<<16 + uint32(255)<<8 + uint32(255), nil
 	default:
-		error_string := "ERROR: failed to parse mask"
-		log.Print(error_string, "(", mask_str, ")")
-		return 0, errors.New(error_string)
+		return 0, fmt.Errorf("failed to parse mask (mask_str: %v)", mask_str)
 	}
 }
 
@@ -105,9 +99,7 @@ func ParseSubnet(parsing_pos uint, fields []string) (uint, AddressObject, error)
 	var _address_object AddressObject
 
 	if len(fields) < int(parsing_pos+2) {
-		error_string := "ERROR: not enough fields to parse subnet"
-		log.Print(error_string, "(", fields, ")")
-		return 0, _address_object, errors.New(error_string)
+		return 0, _address_object, fmt.Errorf("not enough fields to parse subnet (fields: %v)", fields)
 	}
 
 	ip, err := ParseIP(fields[parsing_pos])
@@ -134,5 +126,5 @@ func (a *AddressObject) print() {
 	ip1 := IpToString(a.Start)
 	ip2 := IpToString(a.Finish)
 	s := fmt.Sprintf("prefix: %v -> %v ", ip1, ip2)
-	log.Print(s)
+	slog.Debug(s)
 }
@@ -0,0 +1,311 @@
+// Package excessiveacl finds over-permissive entries of Cisco ASA access-lists
+// by matching flows (or ASA syslog) against ACLs applied by access-groups.
+package excessiveacl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/netip"
+	"strings"
+
+	acl_match "github.com/ivankuchin/excessive-acl/internal/pkg/acl_match"
+	cisco_asa_acg "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-group"
+	cisco_asa_acl "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list"
+	cisco_asa_access_entry "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/cisco-asa-access-entry"
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+	cisco_asa_nat "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-nat"
+	sh_ip_route "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/sh-ip-route"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/cisco/syslog"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/hyperloglog"
+	"github.com/ivankuchin/excessive-acl/internal/pkg/network_entities"
+)
+
+// reads "show running-config" and compiles ACLs applied by access-groups.
+// Routing table is derived from the config, use LoadRoutes to replace it by "show route".
+func Load(config io.Reader, options Options) (*Device, error) {
+	sh_run, err := sh_run_pipe.Read(config)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	bucket, err := network_entities.ParseBucket(options.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if options.Hll_precision != 0 && (options.Hll_precision < hyperloglog.MinPrecision || options.Hll_precision > hyperloglog.MaxPrecision) {
+		return nil, fmt.Errorf("hll precision must be in %d..%d range", hyperloglog.MinPrecision, hyperloglog.MaxPrecision)
+	}
+	if options.Max_prefix_length > 32 {
+		return nil, fmt.Errorf("max prefix length must be in 1..32 range")
+	}
+	if options.Max_prefix_length != 0 && options.Hll_precision != 0 {
+		return nil, fmt.Errorf("max prefix length requires exact count of addresses, hll precision must be 0")
+	}
+
+	capacity_model, err := network_entities.ParseCapacityModel(options.Capacity_model, network_entities.CapacityModel{})
+	if err != nil {
+		return nil, err
+	}
+
+	access_groups, err := cisco_asa_acg.Parse(sh_run)
+	if err != nil {
+		return nil, fmt.Errorf("parse access-groups: %w", err)
+	}
+	if len(access_groups) == 0 {
+		return nil, ErrorNoAccessGroups
+	}
+	access_lists, err := cisco_asa_acl.Parse(sh_run, access_groups)
+	if err != nil {
+		return nil, fmt.Errorf("compile access-lists: %w", err)
+	}
+	for i := range access_lists {
+		model := capacity_model
+		if spec, ok := options.Acl_capacity_models[access_lists[i].Name]; ok {
+			model, err = network_entities.ParseCapacityModel(spec, capacity_model)
+			if err != nil {
+				return nil, err
+			}
+		}
+		access_lists[i].SetCapacityModel(model)
+	}
+
+	nat_rules, err := cisco_asa_nat.Parse(sh_run)
+	if err != nil {
+		return nil, fmt.Errorf("parse nat: %w", err)
+	}
+	acl_addresses := options.Acl_addresses
+	if acl_addresses == "" {
+		acl_addresses = "auto"
+	}
+	address_form, err := cisco_asa_nat.ParseAddressForm(acl_addresses)
+	if err != nil {
+		return nil, err
+	}
+	address_form = address_form.Resolve(sh_run)
+
+	routing_table, err := sh_ip_route.FitConfig(sh_run)
+	if err != nil {
+		return nil, fmt.Errorf("routing table from config: %w", err)
+	}
+
+	device := &Device{}
+	device.app_ctx.Access_groups = access_groups
+	device.app_ctx.Access_lists = access_lists
+	device.app_ctx.Nat_rules = nat_rules
+	device.app_ctx.Address_form = address_form
+	device.app_ctx.Routing_table = routing_table
+	device.app_ctx.Since = options.Since
+	device.app_ctx.Until = options.Until
+	device.app_ctx.Stats_options = network_entities.StatsOptions{Bucket: bucket, Hll_precision: options.Hll_precision, Max_prefix_length: options.Max_prefix_length, Max_port_range: options.Max_port_range}
+
+	// --- keep config for LoadRoutes, interface names are taken from it
+	device.sh_run = sh_run
+
+	return device, nil
+}
+
+// replaces routing table derived from the config by "show route" output.
+// Must be called before flows are added.
+func (d *Device) LoadRoutes(show_route io.Reader) error {
+	routing_table, err := sh_ip_route.FitReader(d.sh_run, show_route)
+	if err != nil {
+		return fmt.Errorf("parse show route: %w", err)
+	}
+	d.app_ctx.Routing_table = routing_table
+	return nil
+}
+
+// ACE hashes of "show access-list" output, syslog messages naming ACE by hash (106100) are credited to that ACE.
+// Must be called before flows are added.
+func (d *Device) LoadHashes(show_access_list io.Reader) error {
+	hashes, err := cisco_asa_acl.ParseHashes(show_access_list)
+	if err != nil {
+		return fmt.Errorf("parse show access-list: %w", err)
+	}
+	for i := range d.app_ctx.Access_lists {
+		d.app_ctx.Access_lists[i].SetHashes(hashes)
+	}
+	return nil
+}
+
+func (d *Device) toInternalFlow(flow Flow) (network_entities.Flow, error) {
+	var result network_entities.Flow
+
+	proto, ok := network_entities.Protocols_map[strings.ToLower(flow.Protocol)]
+	if !ok {
+		return result, fmt.Errorf("%w (%s)", ErrorUnknownProtocol, flow.Protocol)
+	}
+	if !flow.Src_ip.Is4() || !flow.Dst_ip.Is4() {
+		return result, fmt.Errorf("only ipv4 flows supported (%v -> %v)", flow.Src_ip, flow.Dst_ip)
+	}
+
+	result = network_entities.Flow{
+		Src_iface: flow.Src_iface,
+		Dst_iface: flow.Dst_iface,
+		Protocol:  proto,
+		Src_ip:    addrToUint32(flow.Src_ip),
+		Dst_ip:    addrToUint32(flow.Dst_ip),
+		Src_port:  flow.Src_port,
+		Dst_port:  flow.Dst_port,
+		Icmp_type: flow.Icmp_type,
+		Icmp_code: flow.Icmp_code,
+		Timestamp: flow.Timestamp,
+		To_box:    flow.To_box,
+		Accounted: flow.Accounted,
+		Bytes:     flow.Bytes,
+		Duration:  flow.Duration,
+	}
+
+	var err error
+	if result.Src_iface == "" {
+		result.Src_iface, err = d.app_ctx.Routing_table.GetIface(result.Src_ip)
+		if err != nil {
+			return result, err
+		}
+	}
+	if result.Dst_iface == "" && !result.To_box {
+		result.Dst_iface, err = d.app_ctx.Routing_table.GetIface(result.Dst_ip)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// credits flow to ACEs it matches, flows outside of Options.Since/Until are skipped
+func (d *Device) AddFlow(flow Flow) error {
+	internal_flow, err := d.toInternalFlow(flow)
+	if err != nil {
+		return err
+	}
+	if !d.app_ctx.IsInTimeWindow(internal_flow) {
+		return nil
+	}
+	return acl_match.MatchFlow(internal_flow, d.app_ctx)
+}
+
+// lines of syslog that can't be parsed, AddSyslog skips them and credits flows of the rest
+type SkippedLinesError = syslog.SkippedLinesError
+
+// parses ASA syslog messages in num_workers goroutines and credits flows to ACEs.
+// Build and teardown messages are paired within a single call.
+// Lines that can't be parsed are skipped and reported by *SkippedLinesError after the rest is credited.
+func (d *Device) AddSyslog(r io.Reader, num_workers int) error {
+	app_ctx := d.app_ctx
+	app_ctx.Flows = make(chan network_entities.Flow, 100)
+
+	wait := syslog.FitReader(app_ctx, io.NopCloser(r), num_workers)
+
+	err := acl_match.StartRoutines(num_workers, app_ctx)
+	if err != nil {
+		// --- let the reader finish, it is blocked on the channel otherwise
+		for range app_ctx.Flows {
+		}
+		return err
+	}
+	return wait()
+}
+
+// utilization of every ACE of ACLs applied by access-groups
+func (d *Device) Results() ([]ACLResult, error) {
+	var results []ACLResult
+
+	for i := range d.app_ctx.Access_lists {
+		acl := &d.app_ctx.Access_lists[i]
+		ace_results, err := acl.GetResults()
+		if err != nil {
+			return nil, fmt.Errorf("acl %s: %w", acl.Name, err)
+		}
+
+		acl_result := ACLResult{Name: acl.Name}
+		for _, ace_result := range ace_results {
+			acl_result.ACEs = append(acl_result.ACEs, toACEResult(ace_result))
+		}
+		results = append(results, acl_result)
+	}
+
+	return results, nil
+}
+
+func addrToUint32(addr netip.Addr) uint32 {
+	octets := addr.As4()
+	return uint32(octets[0])<<24 + uint32(octets[1])<<16 + uint32(octets[2])<<8 + uint32(octets[3])
+}
+
+func uint32ToAddr(ip uint32) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)})
+}
+
+func toFlow(flow network_entities.Flow) Flow {
+	result := Flow{
+		Src_iface: flow.Src_iface,
+		Dst_iface: flow.Dst_iface,
+		Src_ip:    uint32ToAddr(flow.Src_ip),
+		Dst_ip:    uint32ToAddr(flow.Dst_ip),
+		Src_port:  flow.Src_port,
+		Dst_port:  flow.Dst_port,
+		Icmp_type: flow.Icmp_type,
+		Icmp_code: flow.Icmp_code,
+		Timestamp: flow.Timestamp,
+		To_box:    flow.To_box,
+		Accounted: flow.Accounted,
+		Bytes:     flow.Bytes,
+		Duration:  flow.Duration,
+	}
+	if flow.Protocol != nil {
+		result.Protocol = flow.Protocol.Title
+	}
+	return result
+}
+
+func toUtilization(utilization cisco_asa_access_entry.Utilization) Utilization {
+	return Utilization{Percent: utilization.Percent, Margin: utilization.Margin, Approximate: utilization.Approximate}
+}
+
+func toACEResult(ace cisco_asa_access_entry.ACEResult) ACEResult {
+	result := ACEResult{Line: ace.Line}
+
+	for _, entry := range ace.Entries {
+		entry_result := EntryResult{
+			Entry:                      entry.Entry,
+			Capacity:                   entry.Capacity,
+			Flows:                      entry.Flows,
+			Bytes:                      entry.Bytes,
+			Flows_capacity:             entry.Flows_capacity,
+			Utilization:                toUtilization(entry.Utilization),
+			Bytes_weighted_utilization: toUtilization(entry.Bytes_weighted_utilization),
+		}
+		for _, dim := range entry.Dimensions {
+			entry_result.Dimensions = append(entry_result.Dimensions, DimensionUtilization{
+				Dimension:   dim.Dimension,
+				Space:       dim.Space,
+				Flows_space: dim.Flows_space,
+				Utilization: toUtilization(dim.Utilization),
+			})
+		}
+		for _, bucket := range entry.Buckets {
+			entry_result.Buckets = append(entry_result.Buckets, BucketResult{
+				Start:       bucket.Start,
+				Flows:       bucket.Flows,
+				Utilization: toUtilization(bucket.Utilization),
+			})
+		}
+		for _, flow := range entry.Samples {
+			entry_result.Samples = append(entry_result.Samples, toFlow(flow))
+		}
+
+		result.Flows += entry.Flows
+		result.Bytes += entry.Bytes
+		// --- capacity of any to any may be the max uint already, see capacity model
+		if result.Capacity > math.MaxUint-entry.Capacity {
+			result.Capacity = math.MaxUint
+		} else {
+			result.Capacity += entry.Capacity
+		}
+		result.Entries = append(result.Entries, entry_result)
+	}
+
+	return result
+}
@@ -0,0 +1,130 @@
+package excessiveacl
+
+import (
+	"math"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadTestDevice(t *testing.T) *Device {
+	config, err := os.Open("testdata/sh_run_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer config.Close()
+
+	device, err := Load(config, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return device
+}
+
+func TestDevice_AddFlow(t *testing.T) {
+	tests := []struct {
+		name      string
+		flow      Flow
+		wantErr   bool
+		wantFlows []uint64
+	}{
+		{
+			name:      "https",
+			flow:      Flow{Protocol: "tcp", Src_ip: netip.MustParseAddr("192.168.0.10"), Dst_ip: netip.MustParseAddr("8.8.8.8"), Src_port: 50000, Dst_port: 443},
+			wantFlows: []uint64{1, 0, 0},
+		},
+		{
+			name:      "icmp",
+			flow:      Flow{Protocol: "icmp", Src_ip: netip.MustParseAddr("192.168.0.10"), Dst_ip: netip.MustParseAddr("8.8.8.8"), Icmp_type: 8},
+			wantFlows: []uint64{0, 1, 0},
+		},
+		{
+			name:      "ssh matches the last entry",
+			flow:      Flow{Protocol: "tcp", Src_ip: netip.MustParseAddr("192.168.0.10"), Dst_ip: netip.MustParseAddr("8.8.8.8"), Src_port: 50000, Dst_port: 22},
+			wantFlows: []uint64{0, 0, 1},
+		},
+		{
+			name:      "outbound flow is not checked by inbound ACL",
+			flow:      Flow{Protocol: "tcp", Src_ip: netip.MustParseAddr("8.8.8.8"), Dst_ip: netip.MustParseAddr("192.168.0.10"), Src_port: 50000, Dst_port: 443},
+			wantFlows: []uint64{0, 0, 0},
+		},
+		{
+			name:    "unknown protocol",
+			flow:    Flow{Protocol: "foo", Src_ip: netip.MustParseAddr("192.168.0.10"), Dst_ip: netip.MustParseAddr("8.8.8.8")},
+			wantErr: true,
+		},
+		{
+			name:    "ipv6",
+			flow:    Flow{Protocol: "tcp", Src_ip: netip.MustParseAddr("2001:db8::1"), Dst_ip: netip.MustParseAddr("8.8.8.8")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := loadTestDevice(t)
+
+			err := device.AddFlow(tt.flow)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Device.AddFlow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			results, err := device.Results()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 1 || len(results[0].ACEs) != len(tt.wantFlows) {
+				t.Fatalf("Device.Results() = %v, want 1 ACL with %d ACEs", results, len(tt.wantFlows))
+			}
+			for i, ace := range results[0].ACEs {
+				if ace.Flows != tt.wantFlows[i] {
+					t.Errorf("ACE %q flows = %d, want %d", ace.Line, ace.Flows, tt.wantFlows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDevice_AddSyslog(t *testing.T) {
+	device := loadTestDevice(t)
+
+	syslog, err := os.Open("testdata/syslog_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syslog.Close()
+
+	err = device.AddSyslog(syslog, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := device.Results()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ace := results[0].ACEs[0]
+	if ace.Flows != 2 || ace.Bytes != 10240 {
+		t.Errorf("ACE flows = %d, bytes = %d, want 2 and 10240", ace.Flows, ace.Bytes)
+	}
+	// --- 2 source hosts of 256, single port of single port, any destination
+	entry := ace.Entries[0]
+	if entry.Capacity != 256 || entry.Flows_capacity != 2 {
+		t.Errorf("entry capacity = %d, flows capacity = %d, want 256 and 2", entry.Capacity, entry.Flows_capacity)
+	}
+	// --- 10240 bytes in 62 seconds reach the first rate class only, zero bytes connection none
+	if want := entry.Utilization.Percent / 2 / 3; math.Abs(entry.Bytes_weighted_utilization.Percent-want) > 1e-9 {
+		t.Errorf("bytes-weighted utilization = %v, want %v", entry.Bytes_weighted_utilization.Percent, want)
+	}
+}
+
+func TestLoad_no_access_groups(t *testing.T) {
+	_, err := Load(strings.NewReader("hostname asa1\n"), Options{})
+	if err != ErrorNoAccessGroups {
+		t.Errorf("Load() error = %v, want %v", err, ErrorNoAccessGroups)
+	}
+}
@@ -0,0 +1,119 @@
+package excessiveacl
+
+import (
+	"errors"
+	"net/netip"
+	"time"
+
+	app_context "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/app-context"
+	sh_run_pipe "github.com/ivankuchin/excessive-acl/internal/pkg/cisco/cisco-asa-access-list/sh-run-pipe"
+)
+
+// how flows are aggregated, zero value is the default of the command line tool
+type Options struct {
+	// --- addresses used in ACLs: "auto" (default, by "ASA Version"), "real" or "mapped"
+	Acl_addresses string
+	// --- flows outside of [Since, Until) are skipped, zero means no limit
+	Since time.Time
+	Until time.Time
+	// --- "", "day" or "week", utilization per period
+	Bucket string
+	// --- estimate unique addresses with hyperloglog of given precision (4..16), 0 counts exactly
+	Hll_precision uint8
+	// --- flows capacity counts addresses summarized to prefixes of that length (1..32), 0 counts unique addresses
+	Max_prefix_length uint8
+	// --- flows capacity counts ports summarized to ranges of that size, 0 counts unique ports
+	Max_port_range uint16
+	// --- how ACE parts not restricting flows count in capacity, ex: "any=full,src-port=full", "" is default.
+	// Keys are src-any, dst-any, dst-port, src-port and icmp, any sets both src-any and dst-any. Values are one or full.
+	Capacity_model string
+	// --- capacity model per ACL name, keys not given are taken from Capacity_model
+	Acl_capacity_models map[string]string
+}
+
+// firewall config with compiled ACLs, flows are credited to ACEs they match.
+// AddFlow and AddSyslog may be called concurrently.
+type Device struct {
+	app_ctx app_context.AppContext
+	sh_run  sh_run_pipe.Text
+}
+
+// single connection or packet seen by the firewall
+type Flow struct {
+	// --- interfaces are found by routing table if empty
+	Src_iface string
+	Dst_iface string
+	// --- protocol name as in ACL: "tcp", "udp", "icmp", ...
+	Protocol  string
+	Src_ip    netip.Addr
+	Dst_ip    netip.Addr
+	Src_port  uint16
+	Dst_port  uint16
+	Icmp_type int
+	Icmp_code int
+	Timestamp time.Time
+	// --- flow destined to the firewall itself, checked by control-plane ACLs
+	To_box bool
+	// --- connection accounting, bytes per second weigh flow in bytes-weighted utilization
+	Accounted bool
+	Bytes     uint64
+	Duration  time.Duration
+}
+
+// share of ACE capacity used by flows, in percents
+type Utilization struct {
+	Percent float64
+	// --- ±Margin percents, only if unique addresses are estimated
+	Margin      float64
+	Approximate bool
+}
+
+// utilization of a single dimension of entry: "src ips", "dst ips", "src ports", "dst ports" or "icmp"
+type DimensionUtilization struct {
+	Dimension   string
+	Space       uint
+	Flows_space uint
+	Utilization Utilization
+}
+
+// flows and utilization during a period of Options.Bucket
+type BucketResult struct {
+	// --- zero for flows without timestamp
+	Start       time.Time
+	Flows       uint64
+	Utilization Utilization
+}
+
+// single compiled entry, object-groups in ACE compile to several entries
+type EntryResult struct {
+	Entry                      string
+	Capacity                   uint
+	Flows                      uint64
+	Bytes                      uint64
+	Flows_capacity             uint
+	Utilization                Utilization
+	Bytes_weighted_utilization Utilization
+	// --- breakdown of Utilization, empty if entry has no flows
+	Dimensions []DimensionUtilization
+	Buckets    []BucketResult
+	// --- first flows matched the entry
+	Samples []Flow
+}
+
+// ACE line from the config
+type ACEResult struct {
+	Line string
+	// --- sums over Entries
+	Flows    uint64
+	Bytes    uint64
+	Capacity uint
+	Entries  []EntryResult
+}
+
+type ACLResult struct {
+	Name string
+	ACEs []ACEResult
+}
+
+var ErrorNoAccessGroups = errors.New("no access-group found")
+var ErrorUnknownProtocol = errors.New("unknown protocol")